@@ -0,0 +1,275 @@
+// Package bulk sends batches of daraja b2c payments, e.g. payroll or winnings payouts.
+//
+// A Batch reads rows from csv with ReadCSV or from any iter.Seq2[Row, error], and sends
+// them with bounded concurrency under a rate limit. Each row is sent with its own
+// OriginatorConversationID, derived from the batch id and the row number.
+//
+// Progress is recorded in a Journal, an append only json lines results file with the
+// sync response of each row and later its async result. A row is recorded as pending
+// before it is sent, and a batch that is run again with the same journal skips every row
+// that was recorded before. Rows that were in flight during a crash are never resent,
+// they are reported as StateUnknown and should be checked with a transaction status query.
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/internal/exchange"
+	"github.com/SirWaithaka/payments/money"
+)
+
+var (
+	ErrSourceChanged = errors.New("row does not match the journal")
+	ErrUnknownResult = errors.New("result does not match any row")
+)
+
+// Row is a single b2c payment of a batch
+type Row struct {
+	// Number identifies the row within the batch, it must be stable across runs
+	// of the same batch. Rows with a zero Number are numbered by their position.
+	Number   int
+	PartyB   string
+	Amount   money.Money
+	Remarks  string
+	Occasion string
+}
+
+// RowError is the error of a row with invalid values. Batches record such rows as
+// StateInvalid and continue with the next row.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Sender sends b2c requests, it is implemented by daraja.Client
+type Sender interface {
+	B2C(ctx context.Context, payload daraja.RequestB2C) (daraja.ResponseB2C, error)
+}
+
+// Config configures a Batch
+type Config struct {
+	// ID uniquely identifies the batch, it prefixes the OriginatorConversationID of each row
+	ID string
+	// Sender sends the requests, usually a daraja.Client with authentication hooks
+	Sender Sender
+	// Template has the fields shared by every request, e.g. InitiatorName, SecurityCredential,
+	// CommandID, PartyA, QueueTimeOutURL and ResultURL. Remarks and Occasion are used for
+	// rows that do not have their own.
+	Template daraja.RequestB2C
+	// Journal records the progress of the batch
+	Journal *Journal
+	// Concurrency is the number of requests sent at the same time, it defaults to 1
+	Concurrency int
+	// Rate is the maximum number of requests sent per second, zero means no limit
+	Rate int
+}
+
+// Batch sends the rows of a bulk b2c payment
+type Batch struct {
+	cfg Config
+}
+
+func New(cfg Config) (*Batch, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("batch id is required")
+	}
+	if cfg.Sender == nil {
+		return nil, errors.New("sender is required")
+	}
+	if cfg.Journal == nil {
+		return nil, errors.New("journal is required")
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+
+	return &Batch{cfg: cfg}, nil
+}
+
+// OriginatorConversationID returns the OriginatorConversationID of the row with the given number
+func (b *Batch) OriginatorConversationID(row int) string {
+	return fmt.Sprintf("%s-%d", b.cfg.ID, row)
+}
+
+// Run sends every row that is not yet in the journal, and returns the number of rows
+// in each state once all sent rows have a sync response. Run stops early if ctx is
+// cancelled, or if the journal cannot be written.
+func (b *Batch) Run(ctx context.Context, rows iter.Seq2[Row, error]) (map[State]int, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var tick <-chan time.Time
+	if b.cfg.Rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(b.cfg.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	jobs := make(chan Row)
+	var wg sync.WaitGroup
+	for range b.cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range jobs {
+				if tick != nil {
+					select {
+					case <-tick:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if err := b.send(ctx, row); err != nil {
+					cancel(err)
+					return
+				}
+			}
+		}()
+	}
+
+	err := b.dispatch(ctx, rows, jobs)
+	close(jobs)
+	wg.Wait()
+
+	if err == nil {
+		err = context.Cause(ctx)
+	}
+	return b.cfg.Journal.Summary(), err
+}
+
+// dispatch queues the rows that are not in the journal
+func (b *Batch) dispatch(ctx context.Context, rows iter.Seq2[Row, error], jobs chan<- Row) error {
+	position := 0
+	for row, err := range rows {
+		position++
+		if row.Number == 0 {
+			row.Number = position
+		}
+
+		if rowErr := (*RowError)(nil); errors.As(err, &rowErr) {
+			if rowErr.Row == 0 {
+				rowErr.Row = row.Number
+			}
+			if _, ok := b.cfg.Journal.Get(rowErr.Row); ok {
+				continue
+			}
+			record := Record{Row: rowErr.Row, State: StateInvalid, Error: rowErr.Err.Error()}
+			if err = b.cfg.Journal.Append(record); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if record, ok := b.cfg.Journal.Get(row.Number); ok {
+			// the row was handled by a previous run, make sure it is the same row
+			if record.State != StateInvalid && (record.OriginatorConversationID != b.OriginatorConversationID(row.Number) ||
+				record.PartyB != row.PartyB || record.Amount != row.Amount.Decimal()) {
+				return fmt.Errorf("%w: row %d", ErrSourceChanged, row.Number)
+			}
+			continue
+		}
+
+		select {
+		case jobs <- row:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// send records the row as pending, sends it and records the sync response. Rows taken
+// after ctx is cancelled are not recorded, so that they are sent by the next run.
+func (b *Batch) send(ctx context.Context, row Row) error {
+	journal := b.cfg.Journal
+
+	payload := b.cfg.Template
+	payload.OriginatorConversationID = b.OriginatorConversationID(row.Number)
+	payload.PartyB = row.PartyB
+	if row.Remarks != "" {
+		payload.Remarks = row.Remarks
+	}
+	if row.Occasion != "" {
+		payload.Occasion = row.Occasion
+	}
+	if err := payload.SetMoney(row.Amount); err != nil {
+		return journal.Append(Record{Row: row.Number, State: StateInvalid, Error: err.Error()})
+	}
+
+	record := Record{
+		Row:                      row.Number,
+		OriginatorConversationID: payload.OriginatorConversationID,
+		State:                    StatePending,
+		PartyB:                   row.PartyB,
+		Amount:                   row.Amount.Decimal(),
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	// the row must be recorded before it is sent, so that it is not sent again
+	if err := journal.Append(record); err != nil {
+		return err
+	}
+
+	response, err := b.cfg.Sender.B2C(ctx, payload)
+	record = Record{Row: row.Number}
+	switch {
+	case err != nil && exchange.Ambiguous(err):
+		record.State, record.Error = StateUnknown, err.Error()
+	case err != nil:
+		record.State, record.Error = StateRejected, err.Error()
+	default:
+		record.State = StateAccepted
+		if response.ResponseCode != daraja.SuccessSubmission {
+			record.State = StateRejected
+		}
+		record.ConversationID = response.ConversationID
+		record.ResponseCode = response.ResponseCode.String()
+		record.ResponseDescription = response.ResponseDescription
+	}
+	return journal.Append(record)
+}
+
+// HandleResult records the async result of a row, matched by the OriginatorConversationID
+// or ConversationID of the result. It can be called from the ResultURL webhook handler
+// while the batch is running, or afterward with the same journal.
+func (b *Batch) HandleResult(result daraja.WebhookRequestB2C) error {
+	record, ok := b.cfg.Journal.Lookup(result.Result.OriginatorConversationID)
+	if !ok {
+		record, ok = b.cfg.Journal.Lookup(result.Result.ConversationID)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownResult, result.Result.OriginatorConversationID)
+	}
+
+	code := result.Result.ResultCode
+	update := Record{
+		Row:            record.Row,
+		State:          StateCompleted,
+		ConversationID: result.Result.ConversationID,
+		ResultCode:     &code,
+		ResultDesc:     result.Result.ResultDesc,
+		TransactionID:  result.Result.TransactionID,
+	}
+	if code != daraja.ResultCodeSuccess {
+		update.State = StateFailed
+	}
+	return b.cfg.Journal.Append(update)
+}
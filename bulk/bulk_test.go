@@ -0,0 +1,310 @@
+package bulk_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/bulk"
+	"github.com/SirWaithaka/payments/daraja"
+)
+
+// sender is a fake daraja b2c client that records the requests it receives
+type sender struct {
+	mu       sync.Mutex
+	requests []daraja.RequestB2C
+	// fail returns an error for the given PartyB
+	fail map[string]error
+}
+
+func (s *sender) B2C(_ context.Context, payload daraja.RequestB2C) (daraja.ResponseB2C, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, payload)
+	if err := s.fail[payload.PartyB]; err != nil {
+		return daraja.ResponseB2C{}, err
+	}
+	return daraja.ResponseB2C{
+		ConversationID:           "AG_" + payload.OriginatorConversationID,
+		OriginatorConversationID: payload.OriginatorConversationID,
+		ResponseCode:             daraja.SuccessSubmission,
+		ResponseDescription:      "Accept the service request successfully.",
+	}, nil
+}
+
+func (s *sender) sent() map[string]daraja.RequestB2C {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := make(map[string]daraja.RequestB2C)
+	for _, req := range s.requests {
+		sent[req.PartyB] = req
+	}
+	return sent
+}
+
+var template = daraja.RequestB2C{
+	InitiatorName:      "testapi",
+	SecurityCredential: "credential",
+	CommandID:          daraja.CommandSalaryPayment,
+	PartyA:             "600000",
+	Remarks:            "salary",
+	QueueTimeOutURL:    "https://example.com/timeout",
+	ResultURL:          "https://example.com/result",
+}
+
+func csvRows(n int) iter.Seq2[bulk.Row, error] {
+	var sb strings.Builder
+	sb.WriteString("phone,amount,remarks\n")
+	for i := range n {
+		fmt.Fprintf(&sb, "07%08d,%d,\n", i, 100+i)
+	}
+	return bulk.ReadCSV(strings.NewReader(sb.String()))
+}
+
+func openJournal(t *testing.T, name string) *bulk.Journal {
+	t.Helper()
+
+	journal, err := bulk.OpenJournal(name)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = journal.Close() })
+	return journal
+}
+
+func TestBatch_Run(t *testing.T) {
+	client := &sender{}
+	batch, err := bulk.New(bulk.Config{
+		ID:          "payroll-2025-08",
+		Sender:      client,
+		Template:    template,
+		Journal:     openJournal(t, filepath.Join(t.TempDir(), "journal.jsonl")),
+		Concurrency: 4,
+		Rate:        1000,
+	})
+	assert.NoError(t, err)
+
+	summary, err := batch.Run(t.Context(), csvRows(20))
+	assert.NoError(t, err)
+	assert.Equal(t, map[bulk.State]int{bulk.StateAccepted: 20}, summary)
+
+	sent := client.sent()
+	assert.Len(t, sent, 20)
+
+	req := sent["254700000003"]
+	assert.Equal(t, "payroll-2025-08-4", req.OriginatorConversationID)
+	assert.Equal(t, "103", req.Amount)
+	assert.Equal(t, "salary", req.Remarks)
+	assert.Equal(t, template.ResultURL, req.ResultURL)
+
+	// ids are unique per row
+	ids := make(map[string]bool)
+	for _, req := range sent {
+		ids[req.OriginatorConversationID] = true
+	}
+	assert.Len(t, ids, 20)
+}
+
+func TestBatch_Resume(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	// simulate a crash: row 1 was accepted, row 2 was being sent
+	journal := openJournal(t, name)
+	assert.NoError(t, journal.Append(bulk.Record{Row: 1, State: bulk.StatePending, OriginatorConversationID: "batch-1", PartyB: "254700000000", Amount: "100.00"}))
+	assert.NoError(t, journal.Append(bulk.Record{Row: 1, State: bulk.StateAccepted, ConversationID: "AG_batch-1"}))
+	assert.NoError(t, journal.Append(bulk.Record{Row: 2, State: bulk.StatePending, OriginatorConversationID: "batch-2", PartyB: "254700000001", Amount: "101.00"}))
+	assert.NoError(t, journal.Close())
+
+	client := &sender{}
+	batch, err := bulk.New(bulk.Config{ID: "batch", Sender: client, Template: template, Journal: openJournal(t, name)})
+	assert.NoError(t, err)
+
+	summary, err := batch.Run(t.Context(), csvRows(4))
+	assert.NoError(t, err)
+	assert.Equal(t, map[bulk.State]int{bulk.StateAccepted: 3, bulk.StateUnknown: 1}, summary)
+
+	// only rows that were not in the journal are sent
+	sent := client.sent()
+	assert.Len(t, sent, 2)
+	assert.Contains(t, sent, "254700000002")
+	assert.Contains(t, sent, "254700000003")
+
+	// running the batch again sends nothing
+	_, err = batch.Run(t.Context(), csvRows(4))
+	assert.NoError(t, err)
+	assert.Len(t, client.requests, 2)
+
+	t.Run("test that a changed source is rejected", func(t *testing.T) {
+		rows := bulk.ReadCSV(strings.NewReader("phone,amount\n0700000000,500\n"))
+		_, err := batch.Run(t.Context(), rows)
+		assert.ErrorIs(t, err, bulk.ErrSourceChanged)
+	})
+}
+
+func TestBatch_Errors(t *testing.T) {
+	client := &sender{fail: map[string]error{
+		"254700000000": errors.New("<400.002.02> Bad Request - Invalid PartyB"),
+		"254700000001": &url.Error{Op: "Post", URL: "https://sandbox.safaricom.co.ke", Err: context.DeadlineExceeded},
+	}}
+	journal := openJournal(t, filepath.Join(t.TempDir(), "journal.jsonl"))
+	batch, err := bulk.New(bulk.Config{ID: "batch", Sender: client, Template: template, Journal: journal})
+	assert.NoError(t, err)
+
+	rows := bulk.ReadCSV(strings.NewReader("phone,amount\n0700000000,100\n0700000001,100\n0800000000,100\n0700000003,1.50\n"))
+	summary, err := batch.Run(t.Context(), rows)
+	assert.NoError(t, err)
+	assert.Equal(t, map[bulk.State]int{bulk.StateRejected: 1, bulk.StateUnknown: 1, bulk.StateInvalid: 2}, summary)
+
+	record, _ := journal.Get(1)
+	assert.Equal(t, bulk.StateRejected, record.State)
+	assert.Equal(t, "<400.002.02> Bad Request - Invalid PartyB", record.Error)
+}
+
+func TestBatch_ProviderErrors(t *testing.T) {
+	// create a mock daraja server that fails each recipient differently
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointB2cPayment, func(w http.ResponseWriter, r *http.Request) {
+		var payload daraja.RequestB2C
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+
+		switch payload.PartyB {
+		case "254700000000":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`<html><body>502 Bad Gateway</body></html>`))
+		case "254700000001":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"requestId":"10101","errorCode":"500.002.1001","errorMessage":"Service is currently unreachable. Please try again later."}`))
+		case "254700000002":
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"requestId":"10102","errorCode":"500.003.02","errorMessage":"Spike Arrest Violation"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"requestId":"10103","errorCode":"400.002.02","errorMessage":"Bad Request - Invalid PartyB"}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	journal := openJournal(t, filepath.Join(t.TempDir(), "journal.jsonl"))
+	batch, err := bulk.New(bulk.Config{ID: "batch", Sender: client, Template: template, Journal: journal})
+	assert.NoError(t, err)
+
+	summary, err := batch.Run(t.Context(), csvRows(4))
+	assert.NoError(t, err)
+	assert.Equal(t, map[bulk.State]int{bulk.StateUnknown: 2, bulk.StateRejected: 2}, summary)
+
+	// server errors that could not be decoded and unavailable services may have paid out
+	for row, state := range map[int]bulk.State{1: bulk.StateUnknown, 2: bulk.StateUnknown, 3: bulk.StateRejected, 4: bulk.StateRejected} {
+		record, _ := journal.Get(row)
+		assert.Equal(t, state, record.State, "row %d", row)
+	}
+}
+
+// cancelSender cancels the batch once it has sent a row
+type cancelSender struct {
+	sender
+	cancel context.CancelFunc
+}
+
+func (s *cancelSender) B2C(ctx context.Context, payload daraja.RequestB2C) (daraja.ResponseB2C, error) {
+	if err := ctx.Err(); err != nil {
+		return daraja.ResponseB2C{}, err
+	}
+	defer s.cancel()
+	return s.sender.B2C(ctx, payload)
+}
+
+func TestBatch_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	client := &cancelSender{cancel: cancel}
+	journal := openJournal(t, filepath.Join(t.TempDir(), "journal.jsonl"))
+	batch, err := bulk.New(bulk.Config{ID: "batch", Sender: client, Template: template, Journal: journal, Concurrency: 1})
+	assert.NoError(t, err)
+
+	summary, err := batch.Run(ctx, csvRows(20))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// rows taken by the workers after the batch was cancelled are not recorded
+	assert.Len(t, client.requests, summary[bulk.StateAccepted])
+	assert.Zero(t, summary[bulk.StatePending])
+	assert.Zero(t, summary[bulk.StateUnknown])
+}
+
+func TestBatch_HandleResult(t *testing.T) {
+	journal := openJournal(t, filepath.Join(t.TempDir(), "journal.jsonl"))
+	batch, err := bulk.New(bulk.Config{ID: "batch", Sender: &sender{}, Template: template, Journal: journal})
+	assert.NoError(t, err)
+
+	_, err = batch.Run(t.Context(), csvRows(2))
+	assert.NoError(t, err)
+
+	var result daraja.WebhookRequestB2C
+	result.Result.OriginatorConversationID = "batch-1"
+	result.Result.ConversationID = "AG_batch-1"
+	result.Result.ResultDesc = "The service request is processed successfully."
+	result.Result.TransactionID = "NLJ41HAY6Q"
+	assert.NoError(t, batch.HandleResult(result))
+
+	// results can be matched by ConversationID only
+	result.Result.OriginatorConversationID = ""
+	result.Result.ConversationID = "AG_batch-2"
+	result.Result.ResultCode = daraja.ResultCodeInsufficientBalance
+	result.Result.TransactionID = ""
+	assert.NoError(t, batch.HandleResult(result))
+
+	record, _ := journal.Get(1)
+	assert.Equal(t, bulk.StateCompleted, record.State)
+	assert.Equal(t, "NLJ41HAY6Q", record.TransactionID)
+	assert.Equal(t, "0", record.ResponseCode)
+
+	record, _ = journal.Get(2)
+	assert.Equal(t, bulk.StateFailed, record.State)
+
+	result.Result.ConversationID = "AG_other"
+	assert.ErrorIs(t, batch.HandleResult(result), bulk.ErrUnknownResult)
+
+	var sb strings.Builder
+	assert.NoError(t, bulk.WriteCSV(&sb, journal))
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[1], "1,254700000000,100.00,completed,batch-1,AG_batch-1,0,"))
+}
+
+func TestOpenJournal(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	// a partial last line is left by a crash while writing
+	content := `{"row":1,"state":"accepted","originator_conversation_id":"batch-1"}` + "\n" + `{"row":2,"sta`
+	assert.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+
+	journal := openJournal(t, name)
+	_, ok := journal.Get(2)
+	assert.False(t, ok)
+
+	assert.NoError(t, journal.Append(bulk.Record{Row: 2, State: bulk.StatePending}))
+	assert.NoError(t, journal.Close())
+
+	journal = openJournal(t, name)
+	record, ok := journal.Lookup("batch-1")
+	assert.True(t, ok)
+	assert.Equal(t, bulk.StateAccepted, record.State)
+	_, ok = journal.Get(2)
+	assert.True(t, ok)
+
+	assert.NoError(t, os.WriteFile(name, []byte("not json\n"), 0o644))
+	_, err := bulk.OpenJournal(name)
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+
+	"github.com/SirWaithaka/payments/internal/csvx"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/phone"
+)
+
+var ErrMissingColumn = errors.New("missing column")
+
+// csv header names accepted for each Row field
+var columns = csvx.Columns{
+	"party_b":  {"party_b", "partyb", "msisdn", "phone", "phone_number"},
+	"amount":   {"amount"},
+	"remarks":  {"remarks"},
+	"occasion": {"occasion"},
+}
+
+// ReadCSV reads rows from csv with a header line. The recipient column can be named
+// party_b, msisdn, phone or phone_number, and the amount column amount. The remarks and
+// occasion columns are optional. Rows are numbered from 1 in the order they are read.
+//
+// Rows with invalid values are yielded as a *RowError, and reading continues. Any other
+// error ends the sequence.
+func ReadCSV(r io.Reader) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+
+		header, err := reader.Read()
+		if err != nil {
+			yield(Row{}, fmt.Errorf("csv header: %w", err))
+			return
+		}
+
+		index := columns.Index(header)
+		if field := index.Missing("party_b", "amount"); field != "" {
+			yield(Row{}, fmt.Errorf("%w: %s", ErrMissingColumn, field))
+			return
+		}
+
+		// allow rows with a different number of fields, missing values are reported per row
+		reader.FieldsPerRecord = -1
+		for number := 1; ; number++ {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(Row{}, err)
+				return
+			}
+
+			row, err := parseRow(number, index.Value(record, "party_b"), index.Value(record, "amount"))
+			row.Remarks, row.Occasion = index.Value(record, "remarks"), index.Value(record, "occasion")
+			if !yield(row, err) {
+				return
+			}
+		}
+	}
+}
+
+func parseRow(number int, partyB, amount string) (Row, error) {
+	row := Row{Number: number}
+
+	n, err := phone.Parse(partyB)
+	if err != nil {
+		return row, &RowError{Row: number, Err: err}
+	}
+	row.PartyB = n.For(phone.ProviderDaraja)
+
+	if row.Amount, err = money.ParseUnits(amount, money.KES); err != nil {
+		return row, &RowError{Row: number, Err: err}
+	}
+	return row, nil
+}
+
+// WriteCSV writes the latest state of each row in the journal as csv, for sharing the
+// results of a batch
+func WriteCSV(w io.Writer, j *Journal) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"row", "party_b", "amount", "state", "originator_conversation_id", "conversation_id",
+		"response_code", "response_description", "result_code", "result_desc", "transaction_id", "error"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range j.Records() {
+		var resultCode string
+		if r.ResultCode != nil {
+			resultCode = strconv.Itoa(int(*r.ResultCode))
+		}
+
+		err := writer.Write([]string{strconv.Itoa(r.Row), r.PartyB, r.Amount, string(r.State), r.OriginatorConversationID,
+			r.ConversationID, r.ResponseCode, r.ResponseDescription, resultCode, r.ResultDesc, r.TransactionID, r.Error})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
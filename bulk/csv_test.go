@@ -0,0 +1,46 @@
+package bulk_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/bulk"
+	"github.com/SirWaithaka/payments/money"
+)
+
+func TestReadCSV(t *testing.T) {
+	input := "MSISDN, Amount, Remarks, Occasion\n" +
+		"0712345678, 1500, bonus, august\n" +
+		"+254 700 000 001, 200,,\n" +
+		"0700, 200,,\n" +
+		"0700000002, 20.50,,\n"
+
+	var rows []bulk.Row
+	var errs []error
+	for row, err := range bulk.ReadCSV(strings.NewReader(input)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	assert.Equal(t, []bulk.Row{
+		{Number: 1, PartyB: "254712345678", Amount: money.FromUnits(1500, money.KES), Remarks: "bonus", Occasion: "august"},
+		{Number: 2, PartyB: "254700000001", Amount: money.FromUnits(200, money.KES)},
+	}, rows)
+
+	assert.Len(t, errs, 2)
+	var rowErr *bulk.RowError
+	assert.ErrorAs(t, errs[0], &rowErr)
+	assert.Equal(t, 3, rowErr.Row)
+	assert.ErrorIs(t, errs[1], money.ErrFractionalAmount)
+
+	t.Run("test that the required columns are checked", func(t *testing.T) {
+		for _, err := range bulk.ReadCSV(strings.NewReader("phone,value\n0712345678,100\n")) {
+			assert.ErrorIs(t, err, bulk.ErrMissingColumn)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package bulk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/payments/daraja"
+)
+
+// State is the state of a row in a batch
+type State string
+
+const (
+	// StateInvalid rows could not be turned into a b2c request and were not sent
+	StateInvalid State = "invalid"
+	// StatePending rows are about to be sent. A row that is still pending when a batch
+	// is resumed may or may not have been sent, and is reported as StateUnknown.
+	StatePending State = "pending"
+	// StateAccepted rows were accepted by daraja and are waiting for the async result
+	StateAccepted State = "accepted"
+	// StateRejected rows were rejected by daraja, no money was sent
+	StateRejected State = "rejected"
+	// StateUnknown rows may have been sent, e.g. the request timed out. They are never
+	// resent, and should be checked with a transaction status query using their
+	// OriginatorConversationID.
+	StateUnknown State = "unknown"
+	// StateCompleted rows have a successful async result
+	StateCompleted State = "completed"
+	// StateFailed rows have a failed async result
+	StateFailed State = "failed"
+)
+
+// Record is a line of the journal. Each line records a change in the state of a row,
+// the latest state of a row is the merge of all its records.
+type Record struct {
+	Row                      int    `json:"row"`
+	OriginatorConversationID string `json:"originator_conversation_id,omitempty"`
+	State                    State  `json:"state"`
+	PartyB                   string `json:"party_b,omitempty"`
+	Amount                   string `json:"amount,omitempty"`
+
+	// sync response
+	ConversationID      string `json:"conversation_id,omitempty"`
+	ResponseCode        string `json:"response_code,omitempty"`
+	ResponseDescription string `json:"response_description,omitempty"`
+
+	// async result
+	ResultCode    *daraja.ResultCode `json:"result_code,omitempty"`
+	ResultDesc    string             `json:"result_desc,omitempty"`
+	TransactionID string             `json:"transaction_id,omitempty"`
+
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// merge updates r with the non-empty fields of o
+func (r Record) merge(o Record) Record {
+	set := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+
+	r.Row, r.State, r.Time = o.Row, o.State, o.Time
+	set(&r.OriginatorConversationID, o.OriginatorConversationID)
+	set(&r.PartyB, o.PartyB)
+	set(&r.Amount, o.Amount)
+	set(&r.ConversationID, o.ConversationID)
+	set(&r.ResponseCode, o.ResponseCode)
+	set(&r.ResponseDescription, o.ResponseDescription)
+	set(&r.ResultDesc, o.ResultDesc)
+	set(&r.TransactionID, o.TransactionID)
+	set(&r.Error, o.Error)
+	if o.ResultCode != nil {
+		r.ResultCode = o.ResultCode
+	}
+	return r
+}
+
+// Journal is the append only results file of a batch. Every state change of a row is
+// written and synced to the file before the batch moves on, which is what makes a
+// batch resumable after a crash.
+type Journal struct {
+	mu      sync.Mutex
+	w       io.Writer
+	records map[int]Record
+	// ids maps the OriginatorConversationID and ConversationID of rows to the row
+	ids map[string]int
+}
+
+// OpenJournal opens the journal file with the given name, creating it if it does not
+// exist. The records of an existing file are loaded, so that a batch can be resumed.
+func OpenJournal(name string) (*Journal, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := openJournal(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+func openJournal(f *os.File) (*Journal, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// records are written with their newline in a single write, so a crash while
+	// writing can only leave a partial last line, which is discarded
+	if i := bytes.LastIndexByte(data, '\n'); i+1 < len(data) {
+		data = data[:i+1]
+		if err = f.Truncate(int64(len(data))); err != nil {
+			return nil, err
+		}
+	}
+	return NewJournal(bytes.NewReader(data), f)
+}
+
+// NewJournal loads the records in r and appends new records to w. If w is an *os.File
+// it is synced after every record. Use OpenJournal for files, which also discards a
+// partial last line so that new records are appended on their own line.
+func NewJournal(r io.Reader, w io.Writer) (*Journal, error) {
+	j := &Journal{w: w, records: make(map[int]Record), ids: make(map[string]int)}
+	if r == nil {
+		return j, nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// a partial last line, left by a crash while writing, is ignored
+	lines := bytes.Split(data, []byte("\n"))
+	lines = lines[:len(lines)-1]
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var record Record
+		if err = jsoniter.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("journal line %d: %w", i+1, err)
+		}
+		j.apply(record)
+	}
+	return j, nil
+}
+
+func (j *Journal) apply(record Record) {
+	current := j.records[record.Row].merge(record)
+	j.records[record.Row] = current
+
+	if current.OriginatorConversationID != "" {
+		j.ids[current.OriginatorConversationID] = current.Row
+	}
+	if current.ConversationID != "" {
+		j.ids[current.ConversationID] = current.Row
+	}
+}
+
+// Append writes record to the journal
+func (j *Journal) Append(record Record) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+
+	b, err := jsoniter.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = j.w.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if f, ok := j.w.(*os.File); ok {
+		if err = f.Sync(); err != nil {
+			return err
+		}
+	}
+
+	j.apply(record)
+	return nil
+}
+
+// Get returns the latest state of row
+func (j *Journal) Get(row int) (Record, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	record, ok := j.records[row]
+	return record, ok
+}
+
+// Lookup returns the latest state of the row sent with the given OriginatorConversationID
+// or ConversationID
+func (j *Journal) Lookup(id string) (Record, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	row, ok := j.ids[id]
+	if !ok {
+		return Record{}, false
+	}
+	return j.records[row], true
+}
+
+// Records returns the latest state of all rows ordered by row number. Rows that were
+// left pending by a previous run are reported as StateUnknown.
+func (j *Journal) Records() []Record {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	records := make([]Record, 0, len(j.records))
+	for _, record := range j.records {
+		if record.State == StatePending {
+			record.State = StateUnknown
+		}
+		records = append(records, record)
+	}
+	slices.SortFunc(records, func(a, b Record) int { return a.Row - b.Row })
+	return records
+}
+
+// Summary counts the rows in each state
+func (j *Journal) Summary() map[State]int {
+	summary := make(map[State]int)
+	for _, record := range j.Records() {
+		summary[record.State]++
+	}
+	return summary
+}
+
+// Close closes the writer of the journal if it is an io.Closer
+func (j *Journal) Close() error {
+	if c, ok := j.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Package cassette records the HTTP exchanges of daraja, quikk and tanda requests to
+// cassette files and replays them, so that an exchange captured in production can be
+// reproduced in a test.
+//
+// A Recorder is installed on the Hooks of a client and appends every exchange that gets a
+// response to the cassette of its gorequest.Operation.Name, a file of JSON lines named
+// <operation>.jsonl. Exchanges are sanitized before they are written: credentials in
+// headers and bodies are replaced with Redacted and MSISDNs are masked, unless configured
+// otherwise. A Replayer installed on the Hooks of a client serves the exchanges of each
+// operation back in the order they were recorded, without network access.
+package cassette
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/SirWaithaka/payments/internal/redact"
+)
+
+// Redacted is recorded in place of redacted values
+const Redacted = redact.Redacted
+
+// DefaultRedactedHeaders are the headers redacted from every exchange
+var DefaultRedactedHeaders = redact.DefaultHeaders
+
+// DefaultRedactedKeys are the JSON and form fields redacted from every exchange, matched
+// without regard to case
+var DefaultRedactedKeys = []string{
+	"SecurityCredential", "Password", "access_token", "client_secret", "app_key",
+}
+
+// Interaction is an HTTP exchange recorded in a cassette
+type Interaction struct {
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Config configures a Recorder
+type Config struct {
+	// Dir is the directory of the cassette files
+	Dir string
+	// RedactHeaders are redacted in addition to DefaultRedactedHeaders
+	RedactHeaders []string
+	// RedactKeys are redacted in addition to DefaultRedactedKeys
+	RedactKeys []string
+	// KeepMSISDNs disables the masking of MSISDNs
+	KeepMSISDNs bool
+}
+
+// redactor returns the redact.Redactor of the headers and keys configured in addition to
+// the defaults
+func (cfg Config) redactor() redact.Redactor {
+	headers := append(append([]string{}, DefaultRedactedHeaders...), cfg.RedactHeaders...)
+	keys := append(append([]string{}, DefaultRedactedKeys...), cfg.RedactKeys...)
+	return redact.New(headers, keys, cfg.KeepMSISDNs)
+}
+
+// reFilename matches the characters of an operation name that are not kept in file names
+var reFilename = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// filename returns the name of the cassette file of an operation
+func filename(operation string) string {
+	return reFilename.ReplaceAllString(operation, "_") + ".jsonl"
+}
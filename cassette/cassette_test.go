@@ -0,0 +1,183 @@
+package cassette_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SirWaithaka/payments/cassette"
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/daraja/daratest"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/quikk/quikktest"
+	"github.com/SirWaithaka/payments/tanda"
+	"github.com/SirWaithaka/payments/tanda/tandatest"
+)
+
+// interactions reads the interactions of a cassette file
+func interactions(t *testing.T, dir, operation string) []cassette.Interaction {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join(dir, operation+".jsonl"))
+	require.NoError(t, err)
+
+	var out []cassette.Interaction
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var interaction cassette.Interaction
+		require.NoError(t, jsoniter.UnmarshalFromString(line, &interaction))
+		out = append(out, interaction)
+	}
+	return out
+}
+
+// callbacks starts an https server that accepts webhooks
+func callbacks(t *testing.T) string {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func b2c(url string) daraja.RequestB2C {
+	return daraja.RequestB2C{
+		OriginatorConversationID: "fake_id",
+		InitiatorName:            "fake_name",
+		SecurityCredential:       "fake_credential",
+		CommandID:                daraja.CommandBusinessPayment,
+		Amount:                   "150",
+		PartyA:                   "600000",
+		PartyB:                   "254712345678",
+		Remarks:                  "test payment",
+		QueueTimeOutURL:          url,
+		ResultURL:                url,
+	}
+}
+
+func TestRecorder_Daraja(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	dir := t.TempDir()
+	url := callbacks(t)
+
+	client := server.Client()
+	cassette.NewRecorder(cassette.Config{Dir: dir}).Install(&client.Hooks)
+
+	recorded, err := client.B2C(t.Context(), b2c(url))
+	require.NoError(t, err)
+
+	t.Run("test that the exchange is sanitized", func(t *testing.T) {
+		got := interactions(t, dir, daraja.OperationB2C)
+		require.Len(t, got, 1)
+
+		request := got[0].Request
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, cassette.Redacted, request.Header.Get("Authorization"))
+		assert.Contains(t, request.Body, `"SecurityCredential":"REDACTED"`)
+		assert.Contains(t, request.Body, `"PartyB":"2547*****678"`)
+		assert.NotContains(t, request.Body, "254712345678")
+		assert.Equal(t, http.StatusOK, got[0].Response.Status)
+
+		// the token sub request of daraja.Authenticate does not use the client hooks
+		assert.NoFileExists(t, filepath.Join(dir, "Authenticate.jsonl"))
+	})
+
+	t.Run("test that the exchange is replayed", func(t *testing.T) {
+		client := daraja.New(daraja.Config{Endpoint: "https://replay.invalid"})
+		cassette.NewReplayer(dir).Install(&client.Hooks)
+
+		res, err := client.B2C(t.Context(), b2c(url))
+		assert.NoError(t, err)
+		assert.Equal(t, recorded, res)
+
+		_, err = client.B2C(t.Context(), b2c(url))
+		assert.ErrorIs(t, err, cassette.ErrNoInteraction)
+	})
+
+	t.Run("test that a request of another path is not replayed", func(t *testing.T) {
+		client := daraja.New(daraja.Config{Endpoint: "https://replay.invalid/v2"})
+		cassette.NewReplayer(dir).Install(&client.Hooks)
+
+		_, err := client.B2C(t.Context(), b2c(url))
+		assert.ErrorContains(t, err, "does not match the recorded")
+	})
+
+	t.Run("test that a missing cassette fails the request", func(t *testing.T) {
+		client := daraja.New(daraja.Config{Endpoint: "https://replay.invalid"})
+		cassette.NewReplayer(t.TempDir()).Install(&client.Hooks)
+
+		_, err := client.B2C(t.Context(), b2c(url))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestRecorder_Quikk(t *testing.T) {
+	server := quikktest.NewServer(callbacks(t))
+	defer server.Close()
+	dir := t.TempDir()
+
+	client := server.Client()
+	cassette.NewRecorder(cassette.Config{Dir: dir}).Install(&client.Hooks)
+
+	payout := quikk.RequestPayout{Amount: 100, RecipientNo: "254712345678", RecipientType: "msisdn", ShortCode: "174379"}
+	recorded, err := client.Payout(t.Context(), payout, "payout-1")
+	require.NoError(t, err)
+
+	got := interactions(t, dir, quikk.OperationPayout)
+	require.Len(t, got, 1)
+	assert.Equal(t, cassette.Redacted, got[0].Request.Header.Get("Authorization"))
+	assert.Contains(t, got[0].Request.Body, `"recipient_no":"2547*****678"`)
+
+	client = quikk.New(quikk.Config{Endpoint: "https://replay.invalid"})
+	cassette.NewReplayer(dir).Install(&client.Hooks)
+
+	res, err := client.Payout(t.Context(), payout, "payout-1")
+	assert.NoError(t, err)
+	assert.Equal(t, recorded, res)
+}
+
+func TestRecorder_Tanda(t *testing.T) {
+	server := tandatest.NewServer()
+	defer server.Close()
+	dir := t.TempDir()
+
+	client := server.Client()
+	cassette.NewRecorder(cassette.Config{
+		Dir:         dir,
+		RedactKeys:  []string{"reference"},
+		KeepMSISDNs: true,
+	}).Install(&client.Hooks)
+
+	payment := tanda.RequestPayment{CommandID: tanda.CommandMerchantToCustomerMobileMoneyPayment, Reference: "REF00000001"}
+	payment.AddParameter(tanda.ParameterIDAmount, "100")
+	payment.AddParameter(tanda.ParameterIDShortCode, "174379")
+	payment.AddParameter(tanda.ParameterIDAccountNumber, "254712345678")
+	payment.AddParameter(tanda.ParameterIDNarration, "Payment")
+	payment.AddParameter(tanda.ParameterIDIpnUrl, callbacks(t))
+
+	_, err := client.Payment(t.Context(), tandatest.OrganizationID, payment)
+	require.NoError(t, err)
+	_, err = client.Payment(t.Context(), tandatest.OrganizationID, payment)
+	require.ErrorContains(t, err, "<E409000>")
+
+	got := interactions(t, dir, tanda.OperationPayment)
+	require.Len(t, got, 2)
+	assert.Contains(t, got[0].Request.Body, "254712345678")
+	assert.Contains(t, got[0].Request.Body, `"reference":"REDACTED"`)
+	assert.Equal(t, http.StatusConflict, got[1].Response.Status)
+
+	t.Run("test that errors are replayed", func(t *testing.T) {
+		client := tanda.New(tanda.Config{Endpoint: "https://replay.invalid"})
+		cassette.NewReplayer(dir).Install(&client.Hooks)
+
+		_, err := client.Payment(t.Context(), tandatest.OrganizationID, payment)
+		assert.NoError(t, err)
+		_, err = client.Payment(t.Context(), tandatest.OrganizationID, payment)
+		assert.ErrorContains(t, err, "<E409000>")
+	})
+}
@@ -0,0 +1,132 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/internal/redact"
+)
+
+// Recorder appends the sanitized exchanges of requests to the cassettes of their operations
+type Recorder struct {
+	cfg    Config
+	redact redact.Redactor
+
+	mu sync.Mutex
+}
+
+// NewRecorder creates a Recorder that writes cassettes to cfg.Dir
+func NewRecorder(cfg Config) *Recorder {
+	return &Recorder{cfg: cfg, redact: cfg.redactor()}
+}
+
+// Install adds the CaptureRequest hook to the front of the send hooks and the Record hook
+// to the front of the unmarshal hooks, before the response body is decoded
+func (rec *Recorder) Install(hooks *gorequest.Hooks) {
+	hooks.Send.PushFrontHook(rec.CaptureRequest())
+	hooks.Unmarshal.PushFrontHook(rec.Record())
+}
+
+// CaptureRequest is a send hook that buffers the request body, so that it can be read
+// again by the Record hook once the request is sent
+func (rec *Recorder) CaptureRequest() gorequest.Hook {
+	return gorequest.Hook{
+		Name: "cassette.CaptureRequest",
+		Fn: func(r *gorequest.Request) {
+			if r.Request.Body == nil || r.Request.Body == http.NoBody {
+				return
+			}
+
+			body, err := io.ReadAll(r.Request.Body)
+			if err != nil {
+				r.Error = err
+				return
+			}
+			_ = r.Request.Body.Close()
+
+			r.Request.Body = io.NopCloser(bytes.NewReader(body))
+			r.Request.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}}
+}
+
+// Record is an unmarshal hook that appends the sanitized exchange of the request to the
+// cassette of its operation. Failing to write the cassette does not fail the request.
+func (rec *Recorder) Record() gorequest.Hook {
+	return gorequest.Hook{
+		Name: "cassette.Record",
+		Fn: func(r *gorequest.Request) {
+			if r.Response == nil || r.Response.Body == nil {
+				return
+			}
+
+			var reqBody []byte
+			if r.Request.GetBody != nil {
+				if body, err := r.Request.GetBody(); err == nil {
+					reqBody, _ = io.ReadAll(body)
+				}
+			}
+
+			resBody, err := io.ReadAll(r.Response.Body)
+			if err != nil {
+				r.Error = err
+				return
+			}
+			_ = r.Response.Body.Close()
+			r.Response.Body = io.NopCloser(bytes.NewReader(resBody))
+
+			interaction := Interaction{
+				Request: Request{
+					Method: r.Request.Method,
+					URL:    r.Request.URL.String(),
+					Header: rec.redact.Header(r.Request.Header),
+					Body:   rec.redact.Body(reqBody, r.Request.Header.Get("Content-Type")),
+				},
+				Response: Response{
+					Status: r.Response.StatusCode,
+					Header: rec.redact.Header(r.Response.Header),
+					Body:   rec.redact.Body(resBody, r.Response.Header.Get("Content-Type")),
+				},
+				RecordedAt: time.Now().UTC(),
+			}
+
+			err = rec.append(r.Operation.Name, interaction)
+			if err != nil && r.Config.LogLevel.AtLeast(gorequest.LogError) && r.Config.Logger != nil {
+				r.Config.Logger.Log(fmt.Sprintf("cassette: failed to record %s: %v", r.Operation.Name, err))
+			}
+		}}
+}
+
+func (rec *Recorder) append(operation string, interaction Interaction) error {
+	line, err := jsoniter.Marshal(interaction)
+	if err != nil {
+		return err
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if err := os.MkdirAll(rec.cfg.Dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(rec.cfg.Dir, filename(operation)), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
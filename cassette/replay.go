@@ -0,0 +1,144 @@
+package cassette
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/gorequest"
+)
+
+// ErrNoInteraction is returned by a replay transport when the cassette of an operation has
+// no recorded exchange left for a request
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Replayer serves the exchanges recorded in the cassettes of a directory
+type Replayer struct {
+	dir string
+
+	mu        sync.Mutex
+	cassettes map[string][]Interaction
+	next      map[string]int
+}
+
+// NewReplayer creates a Replayer of the cassettes in dir. Cassettes are read when their
+// operation is first replayed.
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{
+		dir:       dir,
+		cassettes: make(map[string][]Interaction),
+		next:      make(map[string]int),
+	}
+}
+
+// Install adds the Replay hook to the back of the build hooks, after the hooks that set
+// the http client of a request
+func (rp *Replayer) Install(hooks *gorequest.Hooks) {
+	hooks.Build.PushBackHook(rp.Replay())
+}
+
+// Replay is a build hook that sends the request with the replay transport of its operation
+func (rp *Replayer) Replay() gorequest.Hook {
+	return gorequest.Hook{
+		Name: "cassette.Replay",
+		Fn: func(r *gorequest.Request) {
+			r.Config.HTTPClient = &http.Client{Transport: rp.Transport(r.Operation.Name)}
+		}}
+}
+
+// Transport returns an http.RoundTripper that responds to requests with the exchanges
+// recorded for operation, in order. A request must have the method and path of the
+// exchange it is served, the host of the recorded url is ignored.
+func (rp *Replayer) Transport(operation string) http.RoundTripper {
+	return transport(func(req *http.Request) (*http.Response, error) {
+		interaction, err := rp.take(operation)
+		if err != nil {
+			return nil, err
+		}
+
+		recorded := interaction.Request
+		u, err := url.Parse(recorded.URL)
+		if err != nil {
+			return nil, fmt.Errorf("cassette %s: %w", operation, err)
+		}
+		if path := u.RequestURI(); recorded.Method != req.Method || path != req.URL.RequestURI() {
+			return nil, fmt.Errorf("cassette %s: request %s %s does not match the recorded %s %s",
+				operation, req.Method, req.URL.RequestURI(), recorded.Method, u.RequestURI())
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+// take returns the next exchange recorded for an operation
+func (rp *Replayer) take(operation string) (Interaction, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	interactions, ok := rp.cassettes[operation]
+	if !ok {
+		var err error
+		if interactions, err = read(filepath.Join(rp.dir, filename(operation))); err != nil {
+			return Interaction{}, fmt.Errorf("cassette %s: %w", operation, err)
+		}
+		rp.cassettes[operation] = interactions
+	}
+
+	i := rp.next[operation]
+	if i >= len(interactions) {
+		return Interaction{}, fmt.Errorf("cassette %s: %w, %d of %d replayed", operation, ErrNoInteraction, i, len(interactions))
+	}
+	rp.next[operation] = i + 1
+	return interactions[i], nil
+}
+
+// read returns the interactions of a cassette file
+func read(path string) ([]Interaction, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var interactions []Interaction
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(nil, len(b)+1)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var interaction Interaction
+		if err := jsoniter.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, err
+		}
+		interactions = append(interactions, interaction)
+	}
+	return interactions, scanner.Err()
+}
+
+// transport adapts a function to http.RoundTripper
+type transport func(*http.Request) (*http.Response, error)
+
+func (fn transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
@@ -0,0 +1,315 @@
+// Package config loads the credentials of daraja, quikk and tanda shortcodes from
+// environment variables, YAML or JSON, and constructs clients from them with their
+// authentication hooks attached.
+//
+// A Config holds the credentials of each provider keyed by shortcode, so that a service
+// that collects or disburses through more than one shortcode keeps them in one place:
+//
+//	daraja:
+//	  "600000":
+//	    environment: sandbox
+//	    consumer_key: ...
+//	    consumer_secret: ...
+//	    initiator_name: ...
+//	    initiator_password: ...
+//	    passphrase: ...
+//	quikk:
+//	  "174379":
+//	    environment: production
+//	    key: ...
+//	    secret: ...
+//	tanda:
+//	  "174379":
+//	    endpoint: ...
+//	    client_id: ...
+//	    client_secret: ...
+//	    organization_id: ...
+//
+// The environment of each daraja and quikk shortcode selects the ProductionUrl or
+// SandboxUrl of its provider, unless an endpoint is set. Tanda shortcodes always set the
+// endpoint of their organization.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+var (
+	ErrRequiredField      = errors.New("is required")
+	ErrInvalidEnvironment = errors.New("must be sandbox or production")
+	ErrUnknownShortCode   = errors.New("unknown shortcode")
+)
+
+// Environment is the environment of a provider's API that a shortcode is registered in
+type Environment string
+
+const (
+	EnvironmentSandbox    Environment = "sandbox"
+	EnvironmentProduction Environment = "production"
+)
+
+// url returns production or sandbox for the environment
+func (e Environment) url(production, sandbox string) string {
+	if e == EnvironmentProduction {
+		return production
+	}
+	return sandbox
+}
+
+// FieldError describes a validation failure of a single field of a shortcode's credentials
+type FieldError struct {
+	Provider  string
+	ShortCode string
+	Field     string
+	Err       error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s %s: %s %s", e.Provider, e.ShortCode, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldErrors accumulates the errors of the fields of a shortcode's credentials
+type fieldErrors struct {
+	provider  string
+	shortCode string
+	errs      []error
+}
+
+func (fe *fieldErrors) add(field string, err error) {
+	fe.errs = append(fe.errs, &FieldError{Provider: fe.provider, ShortCode: fe.shortCode, Field: field, Err: err})
+}
+
+func (fe *fieldErrors) required(field, value string) {
+	if value == "" {
+		fe.add(field, ErrRequiredField)
+	}
+}
+
+func (fe *fieldErrors) environment(env Environment) {
+	if env != EnvironmentSandbox && env != EnvironmentProduction {
+		fe.add("environment", ErrInvalidEnvironment)
+	}
+}
+
+// Daraja is the configuration of a daraja shortcode
+type Daraja struct {
+	// ShortCode is the paybill or till number, it is set from the key of the shortcode
+	ShortCode   string      `json:"-" yaml:"-"`
+	Environment Environment `json:"environment" yaml:"environment"`
+	// Endpoint overrides the url of the environment
+	Endpoint       string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	ConsumerKey    string `json:"consumer_key" yaml:"consumer_key"`
+	ConsumerSecret string `json:"consumer_secret" yaml:"consumer_secret"`
+	// InitiatorName and InitiatorPassword are the api operator credentials of b2c, b2b,
+	// balance, reversal and transaction status requests. They are optional for shortcodes
+	// that only collect payments.
+	InitiatorName     string `json:"initiator_name,omitempty" yaml:"initiator_name,omitempty"`
+	InitiatorPassword string `json:"initiator_password,omitempty" yaml:"initiator_password,omitempty"`
+	// Passphrase is the lipa na mpesa online passkey of stk push requests
+	Passphrase string `json:"passphrase,omitempty" yaml:"passphrase,omitempty"`
+}
+
+// Validate checks that the required credentials are set
+func (d Daraja) Validate() error {
+	errs := fieldErrors{provider: "daraja", shortCode: d.ShortCode}
+	errs.environment(d.Environment)
+	errs.required("consumer_key", d.ConsumerKey)
+	errs.required("consumer_secret", d.ConsumerSecret)
+	// the initiator credentials are only useful together
+	if d.InitiatorName != "" || d.InitiatorPassword != "" {
+		errs.required("initiator_name", d.InitiatorName)
+		errs.required("initiator_password", d.InitiatorPassword)
+	}
+	return errors.Join(errs.errs...)
+}
+
+// URL returns the Endpoint, or the daraja url of the environment
+func (d Daraja) URL() string {
+	if d.Endpoint != "" {
+		return d.Endpoint
+	}
+	return d.Environment.url(daraja.ProductionUrl, daraja.SandboxUrl)
+}
+
+// Client creates a daraja.Client for the shortcode from cfg, with its endpoint set to URL
+// and the daraja.Authenticate hook attached
+func (d Daraja) Client(cfg daraja.Config) daraja.Client {
+	cfg.Endpoint = d.URL()
+	client := daraja.New(cfg)
+	client.Hooks.Build.PushBackHook(daraja.Authenticate(client.AuthenticationRequest(d.ConsumerKey, d.ConsumerSecret)))
+	return client
+}
+
+// SecurityCredential encrypts the InitiatorPassword with the certificate of the environment
+func (d Daraja) SecurityCredential() (string, error) {
+	if d.InitiatorPassword == "" {
+		return "", &FieldError{Provider: "daraja", ShortCode: d.ShortCode, Field: "initiator_password", Err: ErrRequiredField}
+	}
+	return daraja.OpenSSLEncrypt(d.InitiatorPassword, d.Environment.url(daraja.ProductionCertificate, daraja.SandboxCertificate))
+}
+
+// Password encodes the Passphrase into the password of an stk push request sent at timestamp
+func (d Daraja) Password(timestamp daraja.Timestamp) string {
+	return daraja.PasswordEncode(d.ShortCode, d.Passphrase, timestamp.String())
+}
+
+// Quikk is the configuration of a quikk shortcode
+type Quikk struct {
+	// ShortCode is the paybill or till number, it is set from the key of the shortcode
+	ShortCode   string      `json:"-" yaml:"-"`
+	Environment Environment `json:"environment" yaml:"environment"`
+	// Endpoint overrides the url of the environment
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	Key      string `json:"key" yaml:"key"`
+	Secret   string `json:"secret" yaml:"secret"`
+}
+
+// Validate checks that the required credentials are set
+func (q Quikk) Validate() error {
+	errs := fieldErrors{provider: "quikk", shortCode: q.ShortCode}
+	errs.environment(q.Environment)
+	errs.required("key", q.Key)
+	errs.required("secret", q.Secret)
+	return errors.Join(errs.errs...)
+}
+
+// URL returns the Endpoint, or the quikk url of the environment
+func (q Quikk) URL() string {
+	if q.Endpoint != "" {
+		return q.Endpoint
+	}
+	return q.Environment.url(quikk.ProductionUrl, quikk.SandboxUrl)
+}
+
+// Client creates a quikk.Client for the shortcode from cfg, with its endpoint set to URL
+// and the quikk.Sign hook attached
+func (q Quikk) Client(cfg quikk.Config) quikk.Client {
+	cfg.Endpoint = q.URL()
+	client := quikk.New(cfg)
+	client.Hooks.Build.PushBackHook(quikk.Sign(q.Key, q.Secret))
+	return client
+}
+
+// Tanda is the configuration of the tanda organization that a shortcode belongs to
+type Tanda struct {
+	// ShortCode is the paybill or till number, it is set from the key of the shortcode
+	ShortCode string `json:"-" yaml:"-"`
+	// Endpoint is the url of the tanda API
+	Endpoint       string `json:"endpoint" yaml:"endpoint"`
+	ClientID       string `json:"client_id" yaml:"client_id"`
+	ClientSecret   string `json:"client_secret" yaml:"client_secret"`
+	OrganizationID string `json:"organization_id" yaml:"organization_id"`
+}
+
+// Validate checks that the required credentials are set
+func (t Tanda) Validate() error {
+	errs := fieldErrors{provider: "tanda", shortCode: t.ShortCode}
+	errs.required("endpoint", t.Endpoint)
+	errs.required("client_id", t.ClientID)
+	errs.required("client_secret", t.ClientSecret)
+	errs.required("organization_id", t.OrganizationID)
+	return errors.Join(errs.errs...)
+}
+
+// URL returns the Endpoint
+func (t Tanda) URL() string {
+	return t.Endpoint
+}
+
+// Client creates a tanda.Client for the shortcode from cfg, with its endpoint set to URL
+// and the tanda.Authenticate hook attached
+func (t Tanda) Client(cfg tanda.Config) tanda.Client {
+	cfg.Endpoint = t.URL()
+	client := tanda.New(cfg)
+	client.Hooks.Build.PushBackHook(tanda.Authenticate(func() (*gorequest.Request, *tanda.ResponseAuthentication) {
+		return client.AuthenticationRequest(t.ClientID, t.ClientSecret)
+	}))
+	return client
+}
+
+// Config holds the configuration of the shortcodes of each provider, keyed by shortcode
+type Config struct {
+	Daraja map[string]Daraja `json:"daraja,omitempty" yaml:"daraja,omitempty"`
+	Quikk  map[string]Quikk  `json:"quikk,omitempty" yaml:"quikk,omitempty"`
+	Tanda  map[string]Tanda  `json:"tanda,omitempty" yaml:"tanda,omitempty"`
+}
+
+// normalize sets the shortcode of each configuration from its key
+func (c *Config) normalize() {
+	for code, d := range c.Daraja {
+		d.ShortCode = code
+		c.Daraja[code] = d
+	}
+	for code, q := range c.Quikk {
+		q.ShortCode = code
+		c.Quikk[code] = q
+	}
+	for code, t := range c.Tanda {
+		t.ShortCode = code
+		c.Tanda[code] = t
+	}
+}
+
+// Validate checks the configuration of every shortcode, and returns all the errors found
+func (c Config) Validate() error {
+	var errs []error
+	for _, code := range sorted(c.Daraja) {
+		errs = append(errs, c.Daraja[code].Validate())
+	}
+	for _, code := range sorted(c.Quikk) {
+		errs = append(errs, c.Quikk[code].Validate())
+	}
+	for _, code := range sorted(c.Tanda) {
+		errs = append(errs, c.Tanda[code].Validate())
+	}
+	return errors.Join(errs...)
+}
+
+// sorted returns the keys of m in order, so that errors are reported in the same order
+func sorted[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// DarajaClient creates a daraja.Client for shortCode, see Daraja.Client
+func (c Config) DarajaClient(shortCode string, cfg daraja.Config) (daraja.Client, error) {
+	d, ok := c.Daraja[shortCode]
+	if !ok {
+		return daraja.Client{}, fmt.Errorf("daraja %s: %w", shortCode, ErrUnknownShortCode)
+	}
+	return d.Client(cfg), nil
+}
+
+// QuikkClient creates a quikk.Client for shortCode, see Quikk.Client
+func (c Config) QuikkClient(shortCode string, cfg quikk.Config) (quikk.Client, error) {
+	q, ok := c.Quikk[shortCode]
+	if !ok {
+		return quikk.Client{}, fmt.Errorf("quikk %s: %w", shortCode, ErrUnknownShortCode)
+	}
+	return q.Client(cfg), nil
+}
+
+// TandaClient creates a tanda.Client for shortCode, see Tanda.Client
+func (c Config) TandaClient(shortCode string, cfg tanda.Config) (tanda.Client, error) {
+	t, ok := c.Tanda[shortCode]
+	if !ok {
+		return tanda.Client{}, fmt.Errorf("tanda %s: %w", shortCode, ErrUnknownShortCode)
+	}
+	return t.Client(cfg), nil
+}
@@ -0,0 +1,277 @@
+package config_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SirWaithaka/payments/config"
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/daraja/daratest"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/quikk/quikktest"
+	"github.com/SirWaithaka/payments/tanda"
+	"github.com/SirWaithaka/payments/tanda/tandatest"
+)
+
+const yamlConfig = `
+daraja:
+  "600000":
+    environment: sandbox
+    consumer_key: key
+    consumer_secret: secret
+    initiator_name: initiator
+    initiator_password: password
+    passphrase: passphrase
+quikk:
+  "174379":
+    environment: production
+    key: key
+    secret: secret
+tanda:
+  "174379":
+    endpoint: http://localhost:8000
+    client_id: client
+    client_secret: secret
+    organization_id: org
+`
+
+const jsonConfig = `{
+  "daraja": {"600000": {"environment": "sandbox", "consumer_key": "key", "consumer_secret": "secret",
+    "initiator_name": "initiator", "initiator_password": "password", "passphrase": "passphrase"}},
+  "quikk": {"174379": {"environment": "production", "key": "key", "secret": "secret"}},
+  "tanda": {"174379": {"endpoint": "http://localhost:8000", "client_id": "client", "client_secret": "secret", "organization_id": "org"}}
+}`
+
+var expected = config.Config{
+	Daraja: map[string]config.Daraja{"600000": {
+		ShortCode:         "600000",
+		Environment:       config.EnvironmentSandbox,
+		ConsumerKey:       "key",
+		ConsumerSecret:    "secret",
+		InitiatorName:     "initiator",
+		InitiatorPassword: "password",
+		Passphrase:        "passphrase",
+	}},
+	Quikk: map[string]config.Quikk{"174379": {
+		ShortCode:   "174379",
+		Environment: config.EnvironmentProduction,
+		Key:         "key",
+		Secret:      "secret",
+	}},
+	Tanda: map[string]config.Tanda{"174379": {
+		ShortCode:      "174379",
+		Endpoint:       "http://localhost:8000",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		OrganizationID: "org",
+	}},
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("test that yaml is loaded", func(t *testing.T) {
+		cfg, err := config.LoadYAML(strings.NewReader(yamlConfig))
+		require.NoError(t, err)
+		assert.Equal(t, expected, cfg)
+	})
+
+	t.Run("test that json is loaded", func(t *testing.T) {
+		cfg, err := config.LoadJSON(strings.NewReader(jsonConfig))
+		require.NoError(t, err)
+		assert.Equal(t, expected, cfg)
+	})
+
+	t.Run("test that files are loaded by their extension", func(t *testing.T) {
+		dir := t.TempDir()
+		for name, content := range map[string]string{"payments.yml": yamlConfig, "payments.json": jsonConfig} {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			cfg, err := config.LoadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, cfg)
+		}
+
+		_, err := config.LoadFile(filepath.Join(dir, "payments.toml"))
+		assert.ErrorIs(t, err, config.ErrUnsupportedFormat)
+	})
+
+	t.Run("test that environment variables are loaded", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"PAYMENTS_DARAJA_600000_ENVIRONMENT":        "sandbox",
+			"PAYMENTS_DARAJA_600000_CONSUMER_KEY":       "key",
+			"PAYMENTS_DARAJA_600000_CONSUMER_SECRET":    "secret",
+			"PAYMENTS_DARAJA_600000_INITIATOR_NAME":     "initiator",
+			"PAYMENTS_DARAJA_600000_INITIATOR_PASSWORD": "password",
+			"PAYMENTS_DARAJA_600000_PASSPHRASE":         "passphrase",
+			"PAYMENTS_QUIKK_174379_ENVIRONMENT":         "production",
+			"PAYMENTS_QUIKK_174379_KEY":                 "key",
+			"PAYMENTS_QUIKK_174379_SECRET":              "secret",
+			"PAYMENTS_TANDA_174379_ENDPOINT":            "http://localhost:8000",
+			"PAYMENTS_TANDA_174379_CLIENT_ID":           "client",
+			"PAYMENTS_TANDA_174379_CLIENT_SECRET":       "secret",
+			"PAYMENTS_TANDA_174379_ORGANIZATION_ID":     "org",
+			"PAYMENTS_DARAJA_600000_UNKNOWN":            "ignored",
+			"OTHER_DARAJA_600001_CONSUMER_KEY":          "ignored",
+		} {
+			t.Setenv(key, value)
+		}
+
+		cfg, err := config.LoadEnv("PAYMENTS")
+		require.NoError(t, err)
+		assert.Equal(t, expected, cfg)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	_, err := config.LoadYAML(strings.NewReader(`
+daraja:
+  "600000":
+    environment: staging
+    consumer_key: key
+    initiator_name: initiator
+tanda:
+  "174379":
+    client_id: client
+    client_secret: secret
+`))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, config.ErrRequiredField)
+	assert.ErrorIs(t, err, config.ErrInvalidEnvironment)
+	assert.Equal(t, strings.Join([]string{
+		"daraja 600000: environment must be sandbox or production",
+		"daraja 600000: consumer_secret is required",
+		"daraja 600000: initiator_password is required",
+		"tanda 174379: endpoint is required",
+		"tanda 174379: organization_id is required",
+	}, "\n"), err.Error())
+}
+
+func TestConfig_URL(t *testing.T) {
+	assert.Equal(t, daraja.SandboxUrl, expected.Daraja["600000"].URL())
+	assert.Equal(t, quikk.ProductionUrl, expected.Quikk["174379"].URL())
+	assert.Equal(t, "http://localhost:8000", expected.Tanda["174379"].URL())
+
+	d := expected.Daraja["600000"]
+	d.Environment = config.EnvironmentProduction
+	assert.Equal(t, daraja.ProductionUrl, d.URL())
+	d.Endpoint = "http://localhost:8000"
+	assert.Equal(t, "http://localhost:8000", d.URL())
+}
+
+func TestDaraja_Credentials(t *testing.T) {
+	d := expected.Daraja["600000"]
+
+	credential, err := d.SecurityCredential()
+	require.NoError(t, err)
+	assert.NotEmpty(t, credential)
+
+	timestamp := daraja.NewTimestamp()
+	assert.Equal(t, daraja.PasswordEncode("600000", "passphrase", timestamp.String()), d.Password(timestamp))
+
+	d.InitiatorPassword = ""
+	_, err = d.SecurityCredential()
+	assert.ErrorIs(t, err, config.ErrRequiredField)
+}
+
+// callbacks starts an https server that accepts webhooks
+func callbacks(t *testing.T) string {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestConfig_Clients(t *testing.T) {
+	url := callbacks(t)
+
+	darajaServer := daratest.NewServer()
+	defer darajaServer.Close()
+	quikkServer := quikktest.NewServer(url)
+	defer quikkServer.Close()
+	tandaServer := tandatest.NewServer()
+	defer tandaServer.Close()
+
+	cfg := config.Config{
+		Daraja: map[string]config.Daraja{"600000": {
+			ShortCode:      "600000",
+			Environment:    config.EnvironmentSandbox,
+			Endpoint:       darajaServer.URL,
+			ConsumerKey:    daratest.ConsumerKey,
+			ConsumerSecret: daratest.ConsumerSecret,
+		}},
+		Quikk: map[string]config.Quikk{"174379": {
+			ShortCode:   "174379",
+			Environment: config.EnvironmentSandbox,
+			Endpoint:    quikkServer.URL,
+			Key:         quikktest.Key,
+			Secret:      quikktest.Secret,
+		}},
+		Tanda: map[string]config.Tanda{"174379": {
+			ShortCode:      "174379",
+			Endpoint:       tandaServer.URL,
+			ClientID:       tandatest.ClientID,
+			ClientSecret:   tandatest.ClientSecret,
+			OrganizationID: tandatest.OrganizationID,
+		}},
+	}
+	require.NoError(t, cfg.Validate())
+
+	t.Run("test that the daraja client is authenticated", func(t *testing.T) {
+		client, err := cfg.DarajaClient("600000", daraja.Config{})
+		require.NoError(t, err)
+
+		_, err = client.B2C(t.Context(), daraja.RequestB2C{
+			OriginatorConversationID: "fake_id",
+			InitiatorName:            "fake_name",
+			SecurityCredential:       "fake_credential",
+			CommandID:                daraja.CommandBusinessPayment,
+			Amount:                   "150",
+			PartyA:                   "600000",
+			PartyB:                   "254712345678",
+			Remarks:                  "test payment",
+			QueueTimeOutURL:          url,
+			ResultURL:                url,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("test that the quikk client is signed", func(t *testing.T) {
+		client, err := cfg.QuikkClient("174379", quikk.Config{})
+		require.NoError(t, err)
+
+		payout := quikk.RequestPayout{Amount: 100, RecipientNo: "254712345678", RecipientType: "msisdn", ShortCode: "174379"}
+		_, err = client.Payout(t.Context(), payout, "payout-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("test that the tanda client is authenticated", func(t *testing.T) {
+		org := cfg.Tanda["174379"]
+		client, err := cfg.TandaClient("174379", tanda.Config{})
+		require.NoError(t, err)
+
+		payment := tanda.RequestPayment{CommandID: tanda.CommandMerchantToCustomerMobileMoneyPayment, Reference: "REF00000001"}
+		payment.AddParameter(tanda.ParameterIDAmount, "100")
+		payment.AddParameter(tanda.ParameterIDShortCode, "174379")
+		payment.AddParameter(tanda.ParameterIDAccountNumber, "254712345678")
+		payment.AddParameter(tanda.ParameterIDNarration, "Payment")
+		payment.AddParameter(tanda.ParameterIDIpnUrl, url)
+
+		_, err = client.Payment(t.Context(), org.OrganizationID, payment)
+		assert.NoError(t, err)
+	})
+
+	t.Run("test that unknown shortcodes return an error", func(t *testing.T) {
+		_, err := cfg.DarajaClient("000000", daraja.Config{})
+		assert.ErrorIs(t, err, config.ErrUnknownShortCode)
+		_, err = cfg.QuikkClient("000000", quikk.Config{})
+		assert.ErrorIs(t, err, config.ErrUnknownShortCode)
+		_, err = cfg.TandaClient("000000", tanda.Config{})
+		assert.ErrorIs(t, err, config.ErrUnknownShortCode)
+	})
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported config format")
+
+// LoadFile loads and validates the configuration in the file at path, which is read as
+// JSON or YAML by its extension: .json, .yaml or .yml
+func LoadFile(path string) (Config, error) {
+	var load func(io.Reader) (Config, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		load = LoadJSON
+	case ".yaml", ".yml":
+		load = LoadYAML
+	default:
+		return Config{}, fmt.Errorf("%s: %w", path, ErrUnsupportedFormat)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+
+	cfg, err := load(f)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// LoadJSON loads and validates a JSON configuration
+func LoadJSON(r io.Reader) (Config, error) {
+	var cfg Config
+	if err := jsoniter.NewDecoder(r).Decode(&cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg.load()
+}
+
+// LoadYAML loads and validates a YAML configuration
+func LoadYAML(r io.Reader) (Config, error) {
+	var cfg Config
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
+	return cfg.load()
+}
+
+// LoadEnv loads and validates a configuration from the environment variables that start
+// with prefix. Each variable sets one field of a shortcode, and is named after the
+// provider, the shortcode and the field as they are keyed in YAML, in upper case:
+//
+//	PAYMENTS_DARAJA_600000_ENVIRONMENT=sandbox
+//	PAYMENTS_DARAJA_600000_CONSUMER_KEY=...
+//	PAYMENTS_QUIKK_174379_SECRET=...
+//	PAYMENTS_TANDA_174379_ORGANIZATION_ID=...
+//
+// Variables of an unknown provider or field are ignored.
+func LoadEnv(prefix string) (Config, error) {
+	prefix = strings.ToUpper(strings.TrimSuffix(prefix, "_"))
+	if prefix != "" {
+		prefix += "_"
+	}
+
+	var cfg Config
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		provider, rest, ok := strings.Cut(strings.TrimPrefix(key, prefix), "_")
+		if !ok {
+			continue
+		}
+		switch provider {
+		case "DARAJA":
+			setEnv(&cfg.Daraja, rest, value, darajaEnv)
+		case "QUIKK":
+			setEnv(&cfg.Quikk, rest, value, quikkEnv)
+		case "TANDA":
+			setEnv(&cfg.Tanda, rest, value, tandaEnv)
+		}
+	}
+	return cfg.load()
+}
+
+// setEnv sets the field named at the end of key, of the shortcode it starts with, to value.
+// fields maps the environment variable name of each field to a function that sets it.
+func setEnv[T any](m *map[string]T, key, value string, fields map[string]func(*T, string)) {
+	for name, set := range fields {
+		shortCode, ok := strings.CutSuffix(key, "_"+name)
+		if !ok || shortCode == "" {
+			continue
+		}
+
+		if *m == nil {
+			*m = make(map[string]T)
+		}
+		v := (*m)[shortCode]
+		set(&v, value)
+		(*m)[shortCode] = v
+		return
+	}
+}
+
+var darajaEnv = map[string]func(*Daraja, string){
+	"ENVIRONMENT":        func(d *Daraja, v string) { d.Environment = Environment(v) },
+	"ENDPOINT":           func(d *Daraja, v string) { d.Endpoint = v },
+	"CONSUMER_KEY":       func(d *Daraja, v string) { d.ConsumerKey = v },
+	"CONSUMER_SECRET":    func(d *Daraja, v string) { d.ConsumerSecret = v },
+	"INITIATOR_NAME":     func(d *Daraja, v string) { d.InitiatorName = v },
+	"INITIATOR_PASSWORD": func(d *Daraja, v string) { d.InitiatorPassword = v },
+	"PASSPHRASE":         func(d *Daraja, v string) { d.Passphrase = v },
+}
+
+var quikkEnv = map[string]func(*Quikk, string){
+	"ENVIRONMENT": func(q *Quikk, v string) { q.Environment = Environment(v) },
+	"ENDPOINT":    func(q *Quikk, v string) { q.Endpoint = v },
+	"KEY":         func(q *Quikk, v string) { q.Key = v },
+	"SECRET":      func(q *Quikk, v string) { q.Secret = v },
+}
+
+var tandaEnv = map[string]func(*Tanda, string){
+	"ENDPOINT":        func(t *Tanda, v string) { t.Endpoint = v },
+	"CLIENT_ID":       func(t *Tanda, v string) { t.ClientID = v },
+	"CLIENT_SECRET":   func(t *Tanda, v string) { t.ClientSecret = v },
+	"ORGANIZATION_ID": func(t *Tanda, v string) { t.OrganizationID = v },
+}
+
+// load sets the shortcodes of cfg from their keys and validates it
+func (c Config) load() (Config, error) {
+	c.normalize()
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
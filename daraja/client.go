@@ -67,8 +67,9 @@ func (client Client) AuthenticationRequest(key, secret string) AuthenticationReq
 
 		// default hooks
 		hooks := corehooks.Default()
+		// the request carries the client credentials, so it is not logged
+		hooks.Build.RemoveHook(corehooks.LogHTTPRequest)
 		hooks.Build.PushBackHook(corehooks.SetBasicAuth(key, secret))
-		hooks.Send.PushFrontHook(corehooks.LogHTTPRequest)
 		hooks.Unmarshal.PushBackHook(ResponseDecoder)
 
 		output := &ResponseAuthorization{}
@@ -228,6 +229,126 @@ func (client Client) B2B(ctx context.Context, payload RequestB2B) (ResponseB2B,
 	return *out, nil
 }
 
+func (client Client) B2CTopUpRequest(input RequestB2CTopUp, opts ...gorequest.Option) (*gorequest.Request, *ResponseB2CTopUp) {
+	op := gorequest.Operation{
+		Name:   OperationB2CTopUp,
+		Method: http.MethodPost,
+		Path:   EndpointB2cTopUp,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseB2CTopUp{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) B2CTopUp(ctx context.Context, payload RequestB2CTopUp) (ResponseB2CTopUp, error) {
+	req, out := client.B2CTopUpRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseB2CTopUp{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) B2PochiRequest(input RequestB2Pochi, opts ...gorequest.Option) (*gorequest.Request, *ResponseB2Pochi) {
+	op := gorequest.Operation{
+		Name:   OperationB2Pochi,
+		Method: http.MethodPost,
+		Path:   EndpointB2Pochi,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseB2Pochi{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) B2Pochi(ctx context.Context, payload RequestB2Pochi) (ResponseB2Pochi, error) {
+	req, out := client.B2PochiRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseB2Pochi{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) TaxRemittanceRequest(input RequestTaxRemittance, opts ...gorequest.Option) (*gorequest.Request, *ResponseTaxRemittance) {
+	op := gorequest.Operation{
+		Name:   OperationTaxRemittance,
+		Method: http.MethodPost,
+		Path:   EndpointTaxRemittance,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseTaxRemittance{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) TaxRemittance(ctx context.Context, payload RequestTaxRemittance) (ResponseTaxRemittance, error) {
+	req, out := client.TaxRemittanceRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseTaxRemittance{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) B2BExpressCheckoutRequest(input RequestB2BExpressCheckout, opts ...gorequest.Option) (*gorequest.Request, *ResponseB2BExpressCheckout) {
+	op := gorequest.Operation{
+		Name:   OperationB2BExpressCheckout,
+		Method: http.MethodPost,
+		Path:   EndpointB2bExpressCheckout,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseB2BExpressCheckout{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) B2BExpressCheckout(ctx context.Context, payload RequestB2BExpressCheckout) (ResponseB2BExpressCheckout, error) {
+	req, out := client.B2BExpressCheckoutRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseB2BExpressCheckout{}, err
+	}
+
+	return *out, nil
+}
+
 func (client Client) TransactionStatusRequest(input RequestTransactionStatus, opts ...gorequest.Option) (*gorequest.Request, *ResponseTransactionStatus) {
 	op := gorequest.Operation{
 		Name:   OperationTransactionStatus,
@@ -317,3 +438,303 @@ func (client Client) QueryOrgInfo(ctx context.Context, payload RequestOrgInfoQue
 
 	return *out, nil
 }
+
+func (client Client) PullRegisterRequest(input RequestPullRegister, opts ...gorequest.Option) (*gorequest.Request, *ResponsePullRegister) {
+	op := gorequest.Operation{
+		Name:   OperationPullRegister,
+		Method: http.MethodPost,
+		Path:   EndpointPullRegister,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponsePullRegister{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) PullRegister(ctx context.Context, payload RequestPullRegister) (ResponsePullRegister, error) {
+	req, out := client.PullRegisterRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponsePullRegister{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) PullQueryRequest(input RequestPullQuery, opts ...gorequest.Option) (*gorequest.Request, *ResponsePullQuery) {
+	op := gorequest.Operation{
+		Name:   OperationPullQuery,
+		Method: http.MethodPost,
+		Path:   EndpointPullQuery,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponsePullQuery{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) PullQuery(ctx context.Context, payload RequestPullQuery) (ResponsePullQuery, error) {
+	req, out := client.PullQueryRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponsePullQuery{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) DynamicQRRequest(input RequestDynamicQR, opts ...gorequest.Option) (*gorequest.Request, *ResponseDynamicQR) {
+	op := gorequest.Operation{
+		Name:   OperationDynamicQR,
+		Method: http.MethodPost,
+		Path:   EndpointDynamicQR,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseDynamicQR{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) DynamicQR(ctx context.Context, payload RequestDynamicQR) (ResponseDynamicQR, error) {
+	req, out := client.DynamicQRRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseDynamicQR{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) StandingOrderRequest(input RequestStandingOrder, opts ...gorequest.Option) (*gorequest.Request, *ResponseStandingOrder) {
+	op := gorequest.Operation{
+		Name:   OperationStandingOrder,
+		Method: http.MethodPost,
+		Path:   EndpointStandingOrder,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseStandingOrder{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) StandingOrder(ctx context.Context, payload RequestStandingOrder) (ResponseStandingOrder, error) {
+	req, out := client.StandingOrderRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseStandingOrder{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) BillManagerOptInRequest(input RequestBillManagerOptIn, opts ...gorequest.Option) (*gorequest.Request, *ResponseBillManagerOptIn) {
+	op := gorequest.Operation{
+		Name:   OperationBillManagerOptIn,
+		Method: http.MethodPost,
+		Path:   EndpointBillManagerOptIn,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseBillManagerOptIn{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) BillManagerOptIn(ctx context.Context, payload RequestBillManagerOptIn) (ResponseBillManagerOptIn, error) {
+	req, out := client.BillManagerOptInRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseBillManagerOptIn{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) BillManagerInvoiceRequest(input RequestBillManagerInvoice, opts ...gorequest.Option) (*gorequest.Request, *ResponseBillManagerInvoice) {
+	op := gorequest.Operation{
+		Name:   OperationBillManagerInvoice,
+		Method: http.MethodPost,
+		Path:   EndpointBillManagerInvoice,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseBillManagerInvoice{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) BillManagerInvoice(ctx context.Context, payload RequestBillManagerInvoice) (ResponseBillManagerInvoice, error) {
+	req, out := client.BillManagerInvoiceRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseBillManagerInvoice{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) BillManagerBulkInvoiceRequest(input RequestBillManagerBulkInvoice, opts ...gorequest.Option) (*gorequest.Request, *ResponseBillManagerInvoice) {
+	op := gorequest.Operation{
+		Name:   OperationBillManagerBulkInvoice,
+		Method: http.MethodPost,
+		Path:   EndpointBillManagerBulkInvoice,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseBillManagerInvoice{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) BillManagerBulkInvoice(ctx context.Context, payload RequestBillManagerBulkInvoice) (ResponseBillManagerInvoice, error) {
+	req, out := client.BillManagerBulkInvoiceRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseBillManagerInvoice{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) BillManagerCancelInvoiceRequest(input RequestBillManagerCancelInvoice, opts ...gorequest.Option) (*gorequest.Request, *ResponseBillManagerCancelInvoice) {
+	op := gorequest.Operation{
+		Name:   OperationBillManagerCancelInvoice,
+		Method: http.MethodPost,
+		Path:   EndpointBillManagerCancelInvoice,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseBillManagerCancelInvoice{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) BillManagerCancelInvoice(ctx context.Context, payload RequestBillManagerCancelInvoice) (ResponseBillManagerCancelInvoice, error) {
+	req, out := client.BillManagerCancelInvoiceRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseBillManagerCancelInvoice{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) BillManagerCancelBulkInvoiceRequest(input RequestBillManagerCancelBulkInvoice, opts ...gorequest.Option) (*gorequest.Request, *ResponseBillManagerCancelInvoice) {
+	op := gorequest.Operation{
+		Name:   OperationBillManagerCancelBulkInvoice,
+		Method: http.MethodPost,
+		Path:   EndpointBillManagerCancelBulkInvoice,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseBillManagerCancelInvoice{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) BillManagerCancelBulkInvoice(ctx context.Context, payload RequestBillManagerCancelBulkInvoice) (ResponseBillManagerCancelInvoice, error) {
+	req, out := client.BillManagerCancelBulkInvoiceRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseBillManagerCancelInvoice{}, err
+	}
+
+	return *out, nil
+}
+
+func (client Client) BillManagerReconciliationRequest(input RequestBillManagerReconciliation, opts ...gorequest.Option) (*gorequest.Request, *ResponseBillManagerReconciliation) {
+	op := gorequest.Operation{
+		Name:   OperationBillManagerReconciliation,
+		Method: http.MethodPost,
+		Path:   EndpointBillManagerReconciliation,
+	}
+
+	cfg := gorequest.Config{Endpoint: client.endpoint}
+
+	// append to request options
+	opts = append(opts, gorequest.WithRequestHeader("Content-Type", "application/json"))
+
+	output := &ResponseBillManagerReconciliation{}
+	req := gorequest.New(cfg, op, client.Hooks, nil, input, output)
+	req.ApplyOptions(opts...)
+
+	return req, output
+}
+
+func (client Client) BillManagerReconciliation(ctx context.Context, payload RequestBillManagerReconciliation) (ResponseBillManagerReconciliation, error) {
+	req, out := client.BillManagerReconciliationRequest(payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseBillManagerReconciliation{}, err
+	}
+
+	return *out, nil
+}
@@ -1,14 +1,23 @@
 package daraja_test
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"image"
+	"image/png"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	jsoniter "github.com/json-iterator/go"
 	"github.com/oklog/ulid/v2"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/SirWaithaka/gorequest"
+
 	"github.com/SirWaithaka/payments/daraja"
 	"github.com/SirWaithaka/payments/types"
 )
@@ -84,6 +93,25 @@ func TestClient_AuthenticationRequest(t *testing.T) {
 		// expect res to be empty
 		assert.Equal(t, res, &daraja.ResponseAuthorization{})
 	})
+
+	t.Run("test that the request is not logged", func(t *testing.T) {
+		// create a test server
+		mux := http.NewServeMux()
+		mux.HandleFunc(daraja.EndpointAuthentication, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"access_token":"fake_token","expires_in":"3600"}`))
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		var logs []any
+		client := daraja.New(daraja.Config{Endpoint: server.URL})
+		req, _ := client.AuthenticationRequest("fake_key", "fake_secret")()
+		req.Config.LogLevel = gorequest.LogDebugWithHTTPBody
+		req.Config.Logger = gorequest.LoggerFunc(func(args ...any) { logs = append(logs, args...) })
+
+		assert.NoError(t, req.Send())
+		assert.Empty(t, logs)
+	})
 }
 
 func TestClient_C2BExpressRequest(t *testing.T) {
@@ -177,6 +205,56 @@ func TestClient_B2BRequest(t *testing.T) {
 	})
 }
 
+func TestClient_B2BExpressCheckoutRequest(t *testing.T) {
+	endpoint := "http://foo.bar"
+	client := daraja.New(daraja.Config{Endpoint: endpoint})
+
+	t.Run("test that the request is built correctly", func(t *testing.T) {
+		payload := daraja.RequestB2BExpressCheckout{
+			PrimaryShortCode:  "000001",
+			ReceiverShortCode: "000002",
+			Amount:            "100",
+			PaymentRef:        "fake_ref",
+			CallbackURL:       "http://foo.bar/result",
+			PartnerName:       "Vendor",
+			RequestRefID:      "fake_id",
+		}
+		req, _ := client.B2BExpressCheckoutRequest(payload)
+
+		// check payload is set in request
+		assert.Equal(t, req.Params, payload)
+		// check request api url
+		url := endpoint + daraja.EndpointB2bExpressCheckout
+		assert.Equal(t, req.Request.URL.String(), url)
+		// check content-type
+		assert.Equal(t, req.Request.Header.Get("Content-Type"), "application/json")
+	})
+}
+
+func TestClient_StandingOrderRequest(t *testing.T) {
+	endpoint := "http://foo.bar"
+	client := daraja.New(daraja.Config{Endpoint: endpoint})
+
+	t.Run("test that the request is built correctly", func(t *testing.T) {
+		payload := daraja.RequestStandingOrder{
+			StandingOrderName: "Test Standing Order",
+			BusinessShortCode: "174379",
+			Amount:            "4500",
+			PartyA:            "254708374149",
+			Frequency:         daraja.FrequencyMonthly,
+		}
+		req, _ := client.StandingOrderRequest(payload)
+
+		// check payload is set in request
+		assert.Equal(t, req.Params, payload)
+		// check request api url
+		url := endpoint + daraja.EndpointStandingOrder
+		assert.Equal(t, req.Request.URL.String(), url)
+		// check content-type
+		assert.Equal(t, req.Request.Header.Get("Content-Type"), "application/json")
+	})
+}
+
 func TestClient_ReversalRequest(t *testing.T) {
 	endpoint := "http://foo.bar"
 	client := daraja.New(daraja.Config{Endpoint: endpoint})
@@ -341,6 +419,87 @@ func TestClient_B2B(t *testing.T) {
 	assert.Equal(t, res.ResponseCode, daraja.SuccessSubmission)
 }
 
+func TestClient_B2BExpressCheckout(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointB2bExpressCheckout, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":"0","status":"USSD Initiated Successfully"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.B2BExpressCheckout(t.Context(), daraja.RequestB2BExpressCheckout{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0", res.Code)
+	assert.Equal(t, "USSD Initiated Successfully", res.Status)
+}
+
+func TestClient_B2CTopUp(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointB2cTopUp, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"OriginatorConversationID":"5118-111210482-1","ConversationID":"AG_20230420_2010759fd5662ef6d054",
+"ResponseCode":"0","ResponseDescription":"Accept the service request successfully."}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.B2CTopUp(t.Context(), daraja.RequestB2CTopUp{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, daraja.SuccessSubmission, res.ResponseCode)
+	assert.Equal(t, "AG_20230420_2010759fd5662ef6d054", res.ConversationID)
+}
+
+func TestClient_B2Pochi(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointB2Pochi, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ResponseMessage":"Success","ResponseCode":"0"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.B2Pochi(t.Context(), daraja.RequestB2Pochi{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, daraja.SuccessSubmission, res.ResponseCode)
+}
+
+func TestClient_TaxRemittance(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointTaxRemittance, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"OriginatorConversationID":"5118-111210482-1","ConversationID":"AG_20230420_2010759fd5662ef6d054",
+"ResponseCode":"0","ResponseDescription":"Accept the service request successfully."}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.TaxRemittance(t.Context(), daraja.RequestTaxRemittance{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, daraja.SuccessSubmission, res.ResponseCode)
+	assert.Equal(t, "5118-111210482-1", res.OriginatorConversationID)
+}
+
 func TestClient_Reverse(t *testing.T) {
 
 	// create a mock test server
@@ -416,3 +575,299 @@ func TestClient_QueryOrgInfo(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, res.ResponseCode, daraja.SuccessSubmission)
 }
+
+func TestClient_PullRegister(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointPullRegister, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ResponseRefID":"18633-7271215-1","Response Status":"1001","ShortCode":"600000","Response Description":"ShortCode already Registered"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.PullRegister(t.Context(), daraja.RequestPullRegister{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1001", res.ResponseStatus)
+	assert.Equal(t, "ShortCode already Registered", res.ResponseDescription)
+}
+
+func TestClient_PullQuery(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointPullQuery, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ResponseRefID":"4e56-4e6b-a4c6-ef3e2a4b1e4b","ResponseCode":"1000","ResponseMessage":"Success",
+"Response":[[{"transactionId":"OHR7EK5R3T","trxDate":"2020-08-27T11:48:51Z","msisdn":254722000000,"sender":"UTILITY",
+"transactiontype":"c2b-pay-bill-debit","billreference":"INV-1","amount":"1500","organizationname":"Test"}]]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.PullQuery(t.Context(), daraja.RequestPullQuery{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1000", res.ResponseCode)
+
+	transactions := res.Transactions()
+	if assert.Len(t, transactions, 1) {
+		assert.Equal(t, "OHR7EK5R3T", transactions[0].TransID)
+		assert.Equal(t, "254722000000", transactions[0].MSISDN)
+		assert.Equal(t, "INV-1", transactions[0].BillRefNumber)
+		amount, err := transactions[0].Money()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(150000), amount.Cents())
+		at, err := transactions[0].Time()
+		assert.NoError(t, err)
+		assert.Equal(t, 2020, at.Year())
+
+		confirmation := transactions[0].Confirmation()
+		assert.Equal(t, "Pay Bill", confirmation.TransactionType)
+		assert.Equal(t, "20200827114851", confirmation.TransTime)
+		assert.Equal(t, "OHR7EK5R3T", confirmation.TransID)
+	}
+}
+
+func TestClient_DynamicQR(t *testing.T) {
+	// a 1x1 png as returned by daraja
+	var img bytes.Buffer
+	assert.NoError(t, png.Encode(&img, image.NewGray(image.Rect(0, 0, 1, 1))))
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointDynamicQR, func(w http.ResponseWriter, r *http.Request) {
+		body := new(bytes.Buffer)
+		_, _ = body.ReadFrom(r.Body)
+		assert.JSONEq(t, `{"MerchantName":"TEST SUPERMARKET","RefNo":"Invoice Test","Amount":1,"TrxCode":"BG","CPI":"373132","Size":"300"}`, body.String())
+
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"ResponseCode":"00","RequestID":"16738-27456357-1",
+"ResponseDescription":"The service request is processed successfully.","QRCode":"%s"}`, base64.StdEncoding.EncodeToString(img.Bytes()))))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	payload := daraja.RequestDynamicQR{MerchantName: "TEST SUPERMARKET", RefNo: "Invoice Test", Amount: 1,
+		TrxCode: daraja.QRBuyGoods, CPI: "373132", Size: "300"}
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.DynamicQR(t.Context(), payload)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "00", res.ResponseCode)
+
+	decoded, err := res.Image()
+	assert.NoError(t, err)
+	assert.Equal(t, img.Bytes(), decoded)
+
+	var out bytes.Buffer
+	assert.NoError(t, res.WritePNG(&out))
+	_, err = png.Decode(&out)
+	assert.NoError(t, err)
+}
+
+func TestClient_StandingOrder(t *testing.T) {
+	callbacks := make(chan daraja.WebhookRequestStandingOrder, 1)
+
+	// create a mock callback server
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var webhook daraja.WebhookRequestStandingOrder
+		assert.NoError(t, jsoniter.NewDecoder(r.Body).Decode(&webhook))
+		callbacks <- webhook
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer callback.Close()
+
+	// create a mock test server that posts the result to the callback url once the request is accepted
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointStandingOrder, func(w http.ResponseWriter, r *http.Request) {
+		var req daraja.RequestStandingOrder
+		assert.NoError(t, jsoniter.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, daraja.FrequencyMonthly, req.Frequency)
+		assert.Equal(t, daraja.TypeStandingOrderPayBill, req.TransactionType)
+
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ResponseHeader":{"responseRefID":"4dd9b5d9-d738-42ba-9326-2cc99e966000","responseCode":"200",
+"responseDescription":"Request accepted for processing","ResultDesc":"The service request is processed successfully."},
+"ResponseBody":{"responseDescription":"Request accepted for processing","responseCode":"200"}}`))
+
+		body := `{"ResponseHeader":{"responseRefID":"0acb2a5a-ba0c-4d8e-a56a-2b0c1c1f2e3d","requestRefID":"4dd9b5d9-d738-42ba-9326-2cc99e966000",
+"responseCode":"0","responseDescription":"The service request is processed successfully."},"ResponseBody":{"ResponseData":[
+{"Name":"TransactionID","Value":"SC8F2IQMH5"},{"Name":"responseCode","Value":"0"},{"Name":"Status","Value":"OKAY"},
+{"Name":"Msisdn","Value":"254******867"}]}}`
+		go func() {
+			res, err := http.Post(req.CallBackURL, "application/json", strings.NewReader(body))
+			if err == nil {
+				_ = res.Body.Close()
+			}
+		}()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	payload := daraja.RequestStandingOrder{
+		StandingOrderName:           "Test Standing Order",
+		StartDate:                   "20240905",
+		EndDate:                     "20250905",
+		BusinessShortCode:           "174379",
+		TransactionType:             daraja.TypeStandingOrderPayBill,
+		ReceiverPartyIdentifierType: daraja.IdentifierOrgShortCode,
+		Amount:                      "4500",
+		PartyA:                      "254708374149",
+		CallBackURL:                 callback.URL,
+		AccountReference:            "Test",
+		TransactionDesc:             "Test",
+		Frequency:                   daraja.FrequencyMonthly,
+	}
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.StandingOrder(t.Context(), payload)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "200", res.ResponseHeader.ResponseCode)
+	assert.Equal(t, "4dd9b5d9-d738-42ba-9326-2cc99e966000", res.ResponseHeader.ResponseRefID)
+
+	select {
+	case webhook := <-callbacks:
+		assert.True(t, webhook.Success())
+		assert.Equal(t, "4dd9b5d9-d738-42ba-9326-2cc99e966000", webhook.ResponseHeader.RequestRefID)
+		assert.Equal(t, "SC8F2IQMH5", webhook.TransactionID())
+		status, ok := webhook.Value("Status")
+		assert.True(t, ok)
+		assert.Equal(t, "OKAY", status)
+		_, ok = webhook.Value("Missing")
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("callback not received")
+	}
+}
+
+func TestClient_BillManagerOptIn(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointBillManagerOptIn, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"app_key":"AG_2376487236_126732989KJ","resmsg":"Success","rescode":"200"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.BillManagerOptIn(t.Context(), daraja.RequestBillManagerOptIn{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "200", res.ResCode)
+	assert.Equal(t, "AG_2376487236_126732989KJ", res.AppKey)
+}
+
+func TestClient_BillManagerInvoice(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointBillManagerInvoice, func(w http.ResponseWriter, r *http.Request) {
+		body := new(bytes.Buffer)
+		_, _ = body.ReadFrom(r.Body)
+		assert.JSONEq(t, `{"externalReference":"#9932340","billedFullName":"John Doe","billedPhoneNumber":"0710000000",
+"billedPeriod":"August 2021","invoiceName":"Jentrys","dueDate":"2021-10-12","accountReference":"1ASD678H","amount":"800",
+"invoiceItems":[{"itemName":"food","amount":"700"},{"itemName":"water","amount":"100"}]}`, body.String())
+
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Status_Message":"Invoice sent successfully","resmsg":"Success","rescode":"200"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	payload := daraja.RequestBillManagerInvoice{
+		ExternalReference: "#9932340",
+		BilledFullName:    "John Doe",
+		BilledPhoneNumber: "0710000000",
+		BilledPeriod:      "August 2021",
+		InvoiceName:       "Jentrys",
+		DueDate:           "2021-10-12",
+		AccountReference:  "1ASD678H",
+		Amount:            "800",
+		InvoiceItems: []daraja.BillManagerInvoiceItem{
+			{ItemName: "food", Amount: "700"},
+			{ItemName: "water", Amount: "100"},
+		},
+	}
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.BillManagerInvoice(t.Context(), payload)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "200", res.ResCode)
+	assert.Equal(t, "Invoice sent successfully", res.StatusMessage)
+}
+
+func TestClient_BillManagerBulkInvoice(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointBillManagerBulkInvoice, func(w http.ResponseWriter, r *http.Request) {
+		var invoices []daraja.RequestBillManagerInvoice
+		assert.NoError(t, jsoniter.NewDecoder(r.Body).Decode(&invoices))
+		assert.Len(t, invoices, 2)
+
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Status_Message":"Invoice sent successfully","resmsg":"Success","rescode":"200"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	payload := daraja.RequestBillManagerBulkInvoice{{ExternalReference: "1"}, {ExternalReference: "2"}}
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.BillManagerBulkInvoice(t.Context(), payload)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "200", res.ResCode)
+}
+
+func TestClient_BillManagerCancelInvoice(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointBillManagerCancelInvoice, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Status_Message":"Invoice cancelled successfully.","resmsg":"Success","rescode":"200","errors":[]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.BillManagerCancelInvoice(t.Context(), daraja.RequestBillManagerCancelInvoice{ExternalReference: "113"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Invoice cancelled successfully.", res.StatusMessage)
+}
+
+func TestClient_BillManagerReconciliation(t *testing.T) {
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointBillManagerReconciliation, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"resmsg":"Success","rescode":"200"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	res, err := client.BillManagerReconciliation(t.Context(), daraja.RequestBillManagerReconciliation{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "200", res.ResCode)
+	assert.Equal(t, "Success", res.ResMsg)
+}
@@ -4,24 +4,57 @@ const (
 	ProductionUrl = "https://api.safaricom.co.ke"
 	SandboxUrl    = "https://sandbox.safaricom.co.ke"
 
-	EndpointAuthentication    = "/oauth/v1/generate"
-	EndpointC2bExpress        = "/mpesa/stkpush/v1/processrequest"
-	EndpointAccountBalance    = "/mpesa/accountbalance/v1/query"
-	EndpointReversal          = "/mpesa/reversal/v1/request"
-	EndpointC2bExpressQuery   = "/mpesa/stkpushquery/v2/query"
-	EndpointTransactionStatus = "/mpesa/transactionstatus/v1/query"
-	EndpointB2cPayment        = "/mpesa/b2c/v3/paymentrequest"
-	EndpointB2bPayment        = "/mpesa/b2b/v1/paymentrequest"
-	EndpointQueryOrgInfo      = "/sfcverify/v1/query/info"
+	EndpointAuthentication     = "/oauth/v1/generate"
+	EndpointC2bExpress         = "/mpesa/stkpush/v1/processrequest"
+	EndpointAccountBalance     = "/mpesa/accountbalance/v1/query"
+	EndpointReversal           = "/mpesa/reversal/v1/request"
+	EndpointC2bExpressQuery    = "/mpesa/stkpushquery/v2/query"
+	EndpointTransactionStatus  = "/mpesa/transactionstatus/v1/query"
+	EndpointB2cPayment         = "/mpesa/b2c/v3/paymentrequest"
+	EndpointB2bPayment         = "/mpesa/b2b/v1/paymentrequest"
+	EndpointB2bExpressCheckout = "/v1/ussdpush/get-msisdn"
+	EndpointB2cTopUp           = "/mpesa/b2b/v1/paymentrequest"
+	EndpointB2Pochi            = "/mpesa/b2pochi/v1/paymentrequest"
+	EndpointTaxRemittance      = "/mpesa/b2b/v1/remittax"
+	EndpointStandingOrder      = "/standingorder/v1/createStandingOrderExternal"
+	EndpointQueryOrgInfo       = "/sfcverify/v1/query/info"
+	EndpointPullRegister       = "/pulltransactions/v1/register"
+	EndpointPullQuery          = "/pulltransactions/v1/query"
+	EndpointDynamicQR          = "/mpesa/qrcode/v1/generate"
+
+	EndpointBillManagerOptIn             = "/v1/billmanager-invoice/optin"
+	EndpointBillManagerInvoice           = "/v1/billmanager-invoice/single-invoicing"
+	EndpointBillManagerBulkInvoice       = "/v1/billmanager-invoice/bulk-invoicing"
+	EndpointBillManagerCancelInvoice     = "/v1/billmanager-invoice/cancel-single-invoice"
+	EndpointBillManagerCancelBulkInvoice = "/v1/billmanager-invoice/cancel-bulk-invoice"
+	EndpointBillManagerReconciliation    = "/v1/billmanager-invoice/reconciliation"
 )
 
+// KRAShortCode is the shortcode of the Kenya Revenue Authority, which receives tax remittances
+const KRAShortCode = "572572"
+
 const (
-	OperationC2BExpress        = "express"
-	OperationC2BQuery          = "stk_query"
-	OperationReversal          = "reversal"
-	OperationB2C               = "b2c"
-	OperationB2B               = "b2b"
-	OperationBalance           = "balance"
-	OperationTransactionStatus = "search"
-	OperationQueryOrgInfo      = "org_info_query"
+	OperationC2BExpress         = "express"
+	OperationC2BQuery           = "stk_query"
+	OperationReversal           = "reversal"
+	OperationB2C                = "b2c"
+	OperationB2B                = "b2b"
+	OperationB2BExpressCheckout = "b2b_express_checkout"
+	OperationB2CTopUp           = "b2c_topup"
+	OperationB2Pochi            = "b2pochi"
+	OperationTaxRemittance      = "tax_remittance"
+	OperationStandingOrder      = "standing_order"
+	OperationBalance            = "balance"
+	OperationTransactionStatus  = "search"
+	OperationQueryOrgInfo       = "org_info_query"
+	OperationPullRegister       = "pull_register"
+	OperationPullQuery          = "pull_query"
+	OperationDynamicQR          = "dynamic_qr"
+
+	OperationBillManagerOptIn             = "bill_manager_optin"
+	OperationBillManagerInvoice           = "bill_manager_invoice"
+	OperationBillManagerBulkInvoice       = "bill_manager_bulk_invoice"
+	OperationBillManagerCancelInvoice     = "bill_manager_cancel_invoice"
+	OperationBillManagerCancelBulkInvoice = "bill_manager_cancel_bulk_invoice"
+	OperationBillManagerReconciliation    = "bill_manager_reconciliation"
 )
@@ -0,0 +1,536 @@
+package daratest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/payments/daraja"
+)
+
+// layout of the TransCompletedTime, FinalisedTime and TransactionDate webhook parameters
+const numericTime = "20060102150405"
+
+// checkout is an stk push, its result is known once the callback is sent
+type checkout struct {
+	merchantRequestID string
+	done              bool
+	resultCode        daraja.ResultCode
+	resultDesc        string
+}
+
+// receipt is a completed transaction
+type receipt struct {
+	amount      string
+	shortCode   string
+	msisdn      string
+	account     string
+	completedAt time.Time
+}
+
+type parameter struct {
+	Key   string `json:"Key"`
+	Value any    `json:"Value"`
+}
+
+// item is a parameter of the stk callback metadata
+type item struct {
+	Name  string `json:"Name"`
+	Value any    `json:"Value"`
+}
+
+type parameters struct {
+	ResultParameter []parameter `json:"ResultParameter"`
+}
+
+type reference struct {
+	ReferenceItem any `json:"ReferenceItem"`
+}
+
+type result struct {
+	ResultType               int               `json:"ResultType"`
+	ResultCode               daraja.ResultCode `json:"ResultCode"`
+	ResultDesc               string            `json:"ResultDesc"`
+	OriginatorConversationID string            `json:"OriginatorConversationID"`
+	ConversationID           string            `json:"ConversationID"`
+	TransactionID            string            `json:"TransactionID"`
+	ResultParameters         *parameters       `json:"ResultParameters,omitempty"`
+	ReferenceData            *reference        `json:"ReferenceData,omitempty"`
+}
+
+// resultRequest is an accepted request of the operations that send a result to ResultURL
+type resultRequest struct {
+	originatorConversationID string
+	resultURL                string
+	queueTimeOutURL          string
+	// reference items of b2b results, other results only reference QueueTimeOutURL
+	references []parameter
+	// result parameters sent on success
+	params func(receipt string, at time.Time) []parameter
+}
+
+// result accepts a request whose result is sent to ResultURL
+func (s *Server) result(req resultRequest, sc Scenario) (any, error) {
+	res := daraja.ResponseDefault{
+		ConversationID:           s.conversationID(),
+		OriginatorConversationID: req.originatorConversationID,
+		ResponseCode:             daraja.SuccessSubmission,
+		ResponseDescription:      "Accept the service request successfully.",
+	}
+	if res.OriginatorConversationID == "" {
+		res.OriginatorConversationID = s.requestID()
+	}
+
+	webhook := result{
+		ResultCode:               sc.ResultCode,
+		ResultDesc:               sc.ResultDesc,
+		OriginatorConversationID: res.OriginatorConversationID,
+		ConversationID:           res.ConversationID,
+		TransactionID:            s.receiptNumber(),
+	}
+	if sc.ResultCode == daraja.ResultCodeSuccess && req.params != nil {
+		webhook.ResultParameters = &parameters{ResultParameter: req.params(webhook.TransactionID, time.Now())}
+	}
+
+	timeout := parameter{Key: "QueueTimeoutURL", Value: req.queueTimeOutURL}
+	if req.references != nil {
+		webhook.ReferenceData = &reference{ReferenceItem: append(req.references, timeout)}
+	} else {
+		webhook.ReferenceData = &reference{ReferenceItem: timeout}
+	}
+
+	s.deliver(req.resultURL, sc, map[string]any{"Result": webhook}, nil)
+	return res, nil
+}
+
+// record saves a completed transaction for transaction status and pull queries
+func (s *Server) record(id string, r receipt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.receipts[id] = r
+}
+
+func (s *Server) routes(mux *http.ServeMux) {
+	mux.HandleFunc("POST "+daraja.EndpointC2bExpress, handle(s, daraja.OperationC2BExpress, s.c2bExpress))
+	mux.HandleFunc("POST "+daraja.EndpointC2bExpressQuery, handle(s, daraja.OperationC2BQuery, s.c2bExpressQuery))
+	mux.HandleFunc("POST "+daraja.EndpointB2cPayment, handle(s, daraja.OperationB2C, s.b2c))
+	mux.HandleFunc("POST "+daraja.EndpointB2Pochi, handle(s, daraja.OperationB2Pochi, s.b2Pochi))
+	mux.HandleFunc("POST "+daraja.EndpointTaxRemittance, handle(s, daraja.OperationTaxRemittance, s.taxRemittance))
+	mux.HandleFunc("POST "+daraja.EndpointReversal, handle(s, daraja.OperationReversal, s.reversal))
+	mux.HandleFunc("POST "+daraja.EndpointAccountBalance, handle(s, daraja.OperationBalance, s.balance))
+	mux.HandleFunc("POST "+daraja.EndpointTransactionStatus, handle(s, daraja.OperationTransactionStatus, s.transactionStatus))
+	mux.HandleFunc("POST "+daraja.EndpointB2bExpressCheckout, handle(s, daraja.OperationB2BExpressCheckout, s.b2bExpressCheckout))
+	mux.HandleFunc("POST "+daraja.EndpointStandingOrder, handle(s, daraja.OperationStandingOrder, s.standingOrder))
+	mux.HandleFunc("POST "+daraja.EndpointQueryOrgInfo, handle(s, daraja.OperationQueryOrgInfo, s.orgInfo))
+	mux.HandleFunc("POST "+daraja.EndpointPullRegister, handle(s, daraja.OperationPullRegister, s.pullRegister))
+	mux.HandleFunc("POST "+daraja.EndpointPullQuery, handle(s, daraja.OperationPullQuery, s.pullQuery))
+	mux.HandleFunc("POST "+daraja.EndpointDynamicQR, handle(s, daraja.OperationDynamicQR, s.dynamicQR))
+
+	// b2b payments and b2c account top-ups share an endpoint, and are told apart by the command id
+	b2b := handle(s, daraja.OperationB2B, s.b2b)
+	topUp := handle(s, daraja.OperationB2CTopUp, s.b2cTopUp)
+	mux.HandleFunc("POST "+daraja.EndpointB2bPayment, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.fail(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if jsoniter.Get(body, "CommandID").ToString() == string(daraja.CommandBusinessPayToBulk) {
+			topUp(w, r)
+			return
+		}
+		b2b(w, r)
+	})
+
+	// bill manager operations only respond synchronously
+	mux.HandleFunc("POST "+daraja.EndpointBillManagerOptIn, handle(s, daraja.OperationBillManagerOptIn, s.billManagerOptIn))
+	mux.HandleFunc("POST "+daraja.EndpointBillManagerInvoice, handle(s, daraja.OperationBillManagerInvoice,
+		billManager[daraja.RequestBillManagerInvoice]("Invoice sent successfully")))
+	mux.HandleFunc("POST "+daraja.EndpointBillManagerBulkInvoice, handle(s, daraja.OperationBillManagerBulkInvoice,
+		billManager[daraja.RequestBillManagerBulkInvoice]("Invoice sent successfully")))
+	mux.HandleFunc("POST "+daraja.EndpointBillManagerCancelInvoice, handle(s, daraja.OperationBillManagerCancelInvoice,
+		billManager[daraja.RequestBillManagerCancelInvoice]("Invoice cancelled successfully.")))
+	mux.HandleFunc("POST "+daraja.EndpointBillManagerCancelBulkInvoice, handle(s, daraja.OperationBillManagerCancelBulkInvoice,
+		billManager[daraja.RequestBillManagerCancelBulkInvoice]("Invoice cancelled successfully.")))
+	mux.HandleFunc("POST "+daraja.EndpointBillManagerReconciliation, handle(s, daraja.OperationBillManagerReconciliation,
+		billManager[daraja.RequestBillManagerReconciliation]("")))
+}
+
+// billManager responds to bill manager requests of type T with message
+func billManager[T any](message string) func(req T, sc Scenario) (any, error) {
+	return func(req T, sc Scenario) (any, error) {
+		return daraja.ResponseBillManager{StatusMessage: message, ResMsg: "Success", ResCode: "200"}, nil
+	}
+}
+
+func (s *Server) c2bExpress(req daraja.RequestC2BExpress, sc Scenario) (any, error) {
+	res := daraja.ResponseC2BExpress{
+		MerchantRequestID:   s.requestID(),
+		CheckoutRequestID:   s.checkoutID(),
+		ResponseCode:        daraja.SuccessSubmission,
+		ResponseDescription: "Success. Request accepted for processing",
+		CustomerMessage:     "Success. Request accepted for processing",
+	}
+
+	c := &checkout{merchantRequestID: res.MerchantRequestID}
+	s.mu.Lock()
+	s.checkouts[res.CheckoutRequestID] = c
+	s.mu.Unlock()
+
+	callback := map[string]any{
+		"MerchantRequestID": res.MerchantRequestID,
+		"CheckoutRequestID": res.CheckoutRequestID,
+		"ResultCode":        sc.ResultCode,
+		"ResultDesc":        sc.ResultDesc,
+	}
+	if sc.ResultCode == daraja.ResultCodeSuccess {
+		id, now := s.receiptNumber(), time.Now()
+		amount, _ := strconv.ParseFloat(req.Amount, 64)
+		msisdn, _ := strconv.ParseInt(req.PhoneNumber, 10, 64)
+		date, _ := strconv.ParseInt(now.Format(numericTime), 10, 64)
+		callback["CallbackMetadata"] = map[string]any{"Item": []item{
+			{Name: "Amount", Value: amount},
+			{Name: "MpesaReceiptNumber", Value: id},
+			{Name: "TransactionDate", Value: date},
+			{Name: "PhoneNumber", Value: msisdn},
+		}}
+		s.record(id, receipt{amount: req.Amount, shortCode: req.BusinessShortCode, msisdn: req.PhoneNumber,
+			account: req.AccountReference, completedAt: now})
+	}
+
+	done := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		c.done, c.resultCode, c.resultDesc = true, sc.ResultCode, sc.ResultDesc
+	}
+	s.deliver(req.CallBackURL, sc, map[string]any{"Body": map[string]any{"stkCallback": callback}}, done)
+	return res, nil
+}
+
+func (s *Server) c2bExpressQuery(req daraja.RequestC2BExpressQuery, sc Scenario) (any, error) {
+	s.mu.Lock()
+	c, ok := s.checkouts[req.CheckoutRequestID]
+	var snapshot checkout
+	if ok {
+		snapshot = *c
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, badRequest("CheckoutRequestID")
+	}
+	if !snapshot.done {
+		return nil, failure{http.StatusInternalServerError, daraja.SubscriberLock, "The transaction is being processed"}
+	}
+
+	return daraja.ResponseC2BExpressQuery{
+		ResponseCode:        daraja.SuccessSubmission,
+		ResponseDescription: "The service request has been accepted successsfully",
+		MerchantRequestID:   snapshot.merchantRequestID,
+		CheckoutRequestID:   req.CheckoutRequestID,
+		ResultCode:          strconv.Itoa(int(snapshot.resultCode)),
+		ResultDesc:          snapshot.resultDesc,
+	}, nil
+}
+
+// b2cParams returns the result parameters of a successful payment to a customer
+func (s *Server) b2cParams(amount, msisdn string) func(string, time.Time) []parameter {
+	return func(id string, at time.Time) []parameter {
+		s.record(id, receipt{amount: amount, msisdn: msisdn, completedAt: at})
+		value, _ := strconv.ParseFloat(amount, 64)
+		return []parameter{
+			{Key: "TransactionAmount", Value: value},
+			{Key: "TransactionReceipt", Value: id},
+			{Key: "B2CRecipientIsRegisteredCustomer", Value: "Y"},
+			{Key: "B2CChargesPaidAccountAvailableFunds", Value: -4510.00},
+			{Key: "ReceiverPartyPublicName", Value: msisdn + " - John Doe"},
+			{Key: "TransactionCompletedDateTime", Value: at.Format("02.01.2006 15:04:05")},
+			{Key: "B2CUtilityAccountAvailableFunds", Value: 10116.00},
+			{Key: "B2CWorkingAccountAvailableFunds", Value: 900000.00},
+		}
+	}
+}
+
+// b2bParams returns the result parameters of a successful payment to an organization
+func (s *Server) b2bParams(amount, partyB, account string) func(string, time.Time) []parameter {
+	return func(id string, at time.Time) []parameter {
+		s.record(id, receipt{amount: amount, shortCode: partyB, account: account, completedAt: at})
+		value, _ := strconv.ParseFloat(amount, 64)
+		completed, _ := strconv.ParseInt(at.Format(numericTime), 10, 64)
+		return []parameter{
+			{Key: "DebitAccountBalance", Value: "{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}"},
+			{Key: "Amount", Value: value},
+			{Key: "DebitPartyAffectedAccountBalance", Value: "Working Account|KES|346568.83|6186.83|340382.00|0.00"},
+			{Key: "TransCompletedTime", Value: completed},
+			{Key: "DebitPartyCharges", Value: ""},
+			{Key: "ReceiverPartyPublicName", Value: partyB + " - Biller Company"},
+			{Key: "Currency", Value: "KES"},
+			{Key: "InitiatorAccountCurrentBalance", Value: "{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}"},
+		}
+	}
+}
+
+func (s *Server) b2c(req daraja.RequestB2C, sc Scenario) (any, error) {
+	return s.result(resultRequest{
+		originatorConversationID: req.OriginatorConversationID,
+		resultURL:                req.ResultURL,
+		queueTimeOutURL:          req.QueueTimeOutURL,
+		params:                   s.b2cParams(req.Amount, req.PartyB),
+	}, sc)
+}
+
+func (s *Server) b2Pochi(req daraja.RequestB2Pochi, sc Scenario) (any, error) {
+	return s.result(resultRequest{
+		originatorConversationID: req.OriginatorConversationID,
+		resultURL:                req.ResultURL,
+		queueTimeOutURL:          req.QueueTimeOutURL,
+		params:                   s.b2cParams(req.Amount, req.PartyB),
+	}, sc)
+}
+
+func (s *Server) b2b(req daraja.RequestB2B, sc Scenario) (any, error) {
+	return s.result(resultRequest{
+		resultURL:       req.ResultURL,
+		queueTimeOutURL: req.QueueTimeOutURL,
+		references:      []parameter{{Key: "BillReferenceNumber", Value: req.AccountReference}},
+		params:          s.b2bParams(req.Amount, req.PartyB, req.AccountReference),
+	}, sc)
+}
+
+func (s *Server) b2cTopUp(req daraja.RequestB2CTopUp, sc Scenario) (any, error) {
+	return s.result(resultRequest{
+		resultURL:       req.ResultURL,
+		queueTimeOutURL: req.QueueTimeOutURL,
+		references:      []parameter{{Key: "BillReferenceNumber", Value: req.AccountReference}},
+		params:          s.b2bParams(req.Amount, req.PartyB, req.AccountReference),
+	}, sc)
+}
+
+func (s *Server) taxRemittance(req daraja.RequestTaxRemittance, sc Scenario) (any, error) {
+	return s.result(resultRequest{
+		resultURL:       req.ResultURL,
+		queueTimeOutURL: req.QueueTimeOutURL,
+		references:      []parameter{{Key: "BillReferenceNumber", Value: req.AccountReference}},
+		params:          s.b2bParams(req.Amount, req.PartyB, req.AccountReference),
+	}, sc)
+}
+
+func (s *Server) reversal(req daraja.RequestReversal, sc Scenario) (any, error) {
+	return s.result(resultRequest{
+		resultURL:       req.ResultURL,
+		queueTimeOutURL: req.QueueTimeOutURL,
+		params: func(id string, at time.Time) []parameter {
+			value, _ := strconv.ParseFloat(req.Amount, 64)
+			completed, _ := strconv.ParseInt(at.Format(numericTime), 10, 64)
+			return []parameter{
+				{Key: "DebitAccountBalance", Value: "Utility Account|KES|51661.00|51661.00|0.00|0.00"},
+				{Key: "Amount", Value: value},
+				{Key: "TransCompletedTime", Value: completed},
+				{Key: "OriginalTransactionID", Value: req.TransactionID},
+				{Key: "Charge", Value: 0.00},
+				{Key: "CreditPartyPublicName", Value: "254708374149 - John Doe"},
+				{Key: "DebitPartyPublicName", Value: req.ReceiverParty + " - Test Company"},
+			}
+		},
+	}, sc)
+}
+
+func (s *Server) balance(req daraja.RequestBalance, sc Scenario) (any, error) {
+	return s.result(resultRequest{
+		resultURL:       req.ResultURL,
+		queueTimeOutURL: req.QueueTimeOutURL,
+		params: func(id string, at time.Time) []parameter {
+			completed, _ := strconv.ParseInt(at.Format(numericTime), 10, 64)
+			return []parameter{
+				{Key: "AccountBalance", Value: "Working Account|KES|700000.00|700000.00|0.00|0.00&" +
+					"Float Account|KES|0.00|0.00|0.00|0.00&" +
+					"Utility Account|KES|228037.00|228037.00|0.00|0.00&" +
+					"Charges Paid Account|KES|-1540.00|-1540.00|0.00|0.00&" +
+					"Organization Settlement Account|KES|0.00|0.00|0.00|0.00"},
+				{Key: "BOCompletedTime", Value: completed},
+			}
+		},
+	}, sc)
+}
+
+func (s *Server) transactionStatus(req daraja.RequestTransactionStatus, sc Scenario) (any, error) {
+	var receiptNo string
+	if req.TransactionID != nil {
+		receiptNo = *req.TransactionID
+	}
+
+	return s.result(resultRequest{
+		resultURL:       req.ResultURL,
+		queueTimeOutURL: req.QueueTimeOutURL,
+		params: func(id string, at time.Time) []parameter {
+			s.mu.Lock()
+			r, ok := s.receipts[receiptNo]
+			s.mu.Unlock()
+			if !ok {
+				r = receipt{completedAt: at}
+			}
+
+			completed, _ := strconv.ParseInt(r.completedAt.Format(numericTime), 10, 64)
+			params := []parameter{
+				{Key: "DebitPartyName", Value: r.msisdn + " - John Doe"},
+				{Key: "CreditPartyName", Value: req.PartyA + " - Test Company"},
+				{Key: "OriginatorConversationID", Value: s.requestID()},
+				{Key: "InitiatedTime", Value: completed},
+				{Key: "DebitAccountType", Value: "Utility Account"},
+				{Key: "ReasonType", Value: "Pay Bill Online"},
+				{Key: "TransactionStatus", Value: "Completed"},
+				{Key: "FinalisedTime", Value: completed},
+				{Key: "ConversationID", Value: s.conversationID()},
+				{Key: "ReceiptNo", Value: receiptNo},
+			}
+			if ok {
+				value, _ := strconv.ParseFloat(r.amount, 64)
+				params = append(params, parameter{Key: "Amount", Value: value})
+			}
+			return params
+		},
+	}, sc)
+}
+
+func (s *Server) b2bExpressCheckout(req daraja.RequestB2BExpressCheckout, sc Scenario) (any, error) {
+	callback := map[string]any{
+		"resultCode":       strconv.Itoa(int(sc.ResultCode)),
+		"resultDesc":       sc.ResultDesc,
+		"requestId":        req.RequestRefID,
+		"amount":           req.Amount,
+		"paymentReference": req.PaymentRef,
+	}
+	if sc.ResultCode == daraja.ResultCodeSuccess {
+		id := s.receiptNumber()
+		callback["resultType"] = "0"
+		callback["conversationID"] = s.conversationID()
+		callback["transactionId"] = id
+		callback["status"] = "SUCCESS"
+		s.record(id, receipt{amount: req.Amount, shortCode: req.ReceiverShortCode, account: req.PaymentRef, completedAt: time.Now()})
+	}
+
+	s.deliver(req.CallbackURL, sc, callback, nil)
+	return daraja.ResponseB2BExpressCheckout{Code: "0", Status: "USSD Initiated Successfully"}, nil
+}
+
+func (s *Server) standingOrder(req daraja.RequestStandingOrder, sc Scenario) (any, error) {
+	var res daraja.ResponseStandingOrder
+	res.ResponseHeader.ResponseRefID = s.requestID()
+	res.ResponseHeader.ResponseCode = "200"
+	res.ResponseHeader.ResponseDescription = "Request accepted for processing"
+	res.ResponseHeader.ResultDesc = "The service request is processed successfully."
+	res.ResponseBody.ResponseCode = "200"
+	res.ResponseBody.ResponseDescription = "Request accepted for processing"
+
+	code := strconv.Itoa(int(sc.ResultCode))
+	data := []map[string]string{{"Name": "responseCode", "Value": code}}
+	if sc.ResultCode == daraja.ResultCodeSuccess {
+		data = append(data,
+			map[string]string{"Name": "TransactionID", "Value": s.receiptNumber()},
+			map[string]string{"Name": "Status", "Value": "OKAY"},
+		)
+	}
+	msisdn := req.PartyA
+	if len(msisdn) > 6 {
+		msisdn = msisdn[:3] + "******" + msisdn[len(msisdn)-3:]
+	}
+	data = append(data, map[string]string{"Name": "Msisdn", "Value": msisdn})
+
+	s.deliver(req.CallBackURL, sc, map[string]any{
+		"ResponseHeader": map[string]string{
+			"responseRefID":       s.requestID(),
+			"requestRefID":        res.ResponseHeader.ResponseRefID,
+			"responseCode":        code,
+			"responseDescription": sc.ResultDesc,
+		},
+		"ResponseBody": map[string]any{"ResponseData": data},
+	}, nil)
+	return res, nil
+}
+
+func (s *Server) orgInfo(req daraja.RequestOrgInfoQuery, sc Scenario) (any, error) {
+	return daraja.ResponseOrgInfoQuery{
+		ConversationID:        s.conversationID(),
+		ResponseCode:          daraja.SuccessSubmission,
+		ResponseMessage:       "Success",
+		OrganizationName:      "Test Company",
+		StoreName:             "Test Company",
+		OrganizationShortCode: req.Identifier,
+		ChargeProfileID:       "1",
+	}, nil
+}
+
+func (s *Server) pullRegister(req daraja.RequestPullRegister, sc Scenario) (any, error) {
+	return daraja.ResponsePullRegister{
+		ResponseRefID:       s.requestID(),
+		ResponseStatus:      "1001",
+		ShortCode:           req.ShortCode,
+		ResponseDescription: "ShortCode already Registered",
+	}, nil
+}
+
+// pullQuery returns the transactions completed by customers paying req.ShortCode within the dates
+func (s *Server) pullQuery(req daraja.RequestPullQuery, sc Scenario) (any, error) {
+	start, _ := time.ParseInLocation(daraja.PullDateFormat, req.StartDate, time.Local)
+	end, _ := time.ParseInLocation(daraja.PullDateFormat, req.EndDate, time.Local)
+
+	var transactions []daraja.PullTransaction
+	s.mu.Lock()
+	for id, r := range s.receipts {
+		if r.shortCode != req.ShortCode || r.msisdn == "" || r.completedAt.Before(start) || r.completedAt.After(end) {
+			continue
+		}
+		transactions = append(transactions, daraja.PullTransaction{
+			TransID:          id,
+			TransTime:        r.completedAt.UTC().Format(time.RFC3339),
+			TransAmount:      r.amount,
+			TransactionType:  "c2b-pay-bill-debit",
+			BillRefNumber:    r.account,
+			MSISDN:           r.msisdn,
+			Sender:           "UTILITY",
+			OrganizationName: "Test Company",
+		})
+	}
+	s.mu.Unlock()
+
+	return daraja.ResponsePullQuery{
+		ResponseRefID:   s.requestID(),
+		ResponseCode:    "1000",
+		ResponseMessage: "Success",
+		Response:        [][]daraja.PullTransaction{transactions},
+	}, nil
+}
+
+func (s *Server) dynamicQR(req daraja.RequestDynamicQR, sc Scenario) (any, error) {
+	size, _ := strconv.Atoi(req.Size)
+	size = min(max(size, 1), 300)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, size, size))); err != nil {
+		return nil, err
+	}
+	return daraja.ResponseDynamicQR{
+		ResponseCode:        "00",
+		RequestID:           s.requestID(),
+		ResponseDescription: "The service request is processed successfully.",
+		QRCode:              base64.StdEncoding.EncodeToString(img.Bytes()),
+	}, nil
+}
+
+func (s *Server) billManagerOptIn(req daraja.RequestBillManagerOptIn, sc Scenario) (any, error) {
+	return daraja.ResponseBillManagerOptIn{
+		AppKey:  fmt.Sprintf("AG_%s_%016x", time.Now().Format("20060102"), s.id()),
+		ResMsg:  "Success",
+		ResCode: "200",
+	}, nil
+}
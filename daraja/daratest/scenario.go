@@ -0,0 +1,78 @@
+package daratest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SirWaithaka/payments/daraja"
+)
+
+// Scenario scripts how the server handles a request of an operation
+type Scenario struct {
+	//Status is the http status code of Error, it defaults to http.StatusInternalServerError
+	Status int
+
+	//Error is responded synchronously instead of accepting the request, and no webhook is sent
+	Error *daraja.ErrorResponse
+
+	//ResultCode and ResultDesc are sent in the webhook of an accepted request
+	ResultCode daraja.ResultCode
+	ResultDesc string
+
+	//Timeout accepts the request but never sends its webhook, as when a customer does
+	//not respond to an stk push or daraja loses the result
+	Timeout bool
+
+	//Delay is how long the server waits before sending the webhook
+	Delay time.Duration
+}
+
+// Scenarios of accepted requests, distinguished by the result sent in the webhook
+var (
+	Success = Scenario{
+		ResultCode: daraja.ResultCodeSuccess,
+		ResultDesc: "The service request is processed successfully.",
+	}
+	InsufficientBalance = Scenario{
+		ResultCode: daraja.ResultCodeInsufficientBalance,
+		ResultDesc: "The balance is insufficient for the transaction.",
+	}
+	Cancelled = Scenario{
+		ResultCode: daraja.ResultCodeCancelledRequest,
+		ResultDesc: "Request cancelled by user",
+	}
+	UserUnreachable = Scenario{
+		ResultCode: daraja.ResultCodeUserUnreachable,
+		ResultDesc: "DS timeout user cannot be reached",
+	}
+	InvalidInitiator = Scenario{
+		ResultCode: daraja.ResultCodeInvalidInitiatorInformation,
+		ResultDesc: "The initiator information is invalid.",
+	}
+	Timeout = Scenario{Timeout: true}
+)
+
+// Scenarios of requests rejected synchronously with an ErrorResponse
+var (
+	SubscriberLocked = Scenario{
+		Status: http.StatusInternalServerError,
+		Error: &daraja.ErrorResponse{
+			ErrorCode:    daraja.SubscriberLock,
+			ErrorMessage: "Unable to lock subscriber, a transaction is already in process for the current subscriber",
+		},
+	}
+	ServiceUnavailable = Scenario{
+		Status: http.StatusServiceUnavailable,
+		Error: &daraja.ErrorResponse{
+			ErrorCode:    daraja.ServiceTemporarilyUnavailable,
+			ErrorMessage: "Service is currently unreachable. Please try again later.",
+		},
+	}
+	QuotaViolation = Scenario{
+		Status: http.StatusTooManyRequests,
+		Error: &daraja.ErrorResponse{
+			ErrorCode:    daraja.QuotaViolation,
+			ErrorMessage: "Quota Violation",
+		},
+	}
+)
@@ -0,0 +1,310 @@
+// Package daratest provides an in-process simulator of the daraja API for tests.
+//
+// A Server implements the endpoints of the daraja package with realistic synchronous
+// responses and error codes, and delivers the webhooks of accepted requests to their
+// ResultURL or CallBackURL. Requests must carry a token issued by the server for
+// ConsumerKey and ConsumerSecret, and are validated like the daraja validator hooks.
+// Each request takes the next Scenario scripted for its operation, which makes results
+// such as insufficient balance, cancelled stk pushes and timeouts reproducible without
+// network access.
+package daratest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/payments/daraja"
+)
+
+// Credentials accepted by the authentication endpoint
+const (
+	ConsumerKey    = "daratest_key"
+	ConsumerSecret = "daratest_secret"
+)
+
+// TokenTTL is how long the access tokens issued by the server are valid
+const TokenTTL = 3599 * time.Second
+
+// failure is a synchronous error response
+type failure struct {
+	status  int
+	code    daraja.ResponseCode
+	message string
+}
+
+func (f failure) Error() string {
+	return fmt.Sprintf("<%s> %s", f.code, f.message)
+}
+
+// badRequest is the response daraja sends for a request with an invalid field
+func badRequest(field string) failure {
+	return failure{http.StatusBadRequest, daraja.InvalidReceiverIdentifierType, "Bad Request - Invalid " + field}
+}
+
+// Server is an in-process daraja API. Create one with NewServer and close it with Close.
+type Server struct {
+	//URL is the base url of the server, use it as the daraja.Config Endpoint
+	URL string
+
+	server  *httptest.Server
+	webhook *http.Client
+
+	mu        sync.Mutex
+	seq       int
+	tokens    map[string]time.Time
+	scripts   map[string][]Scenario
+	checkouts map[string]*checkout
+	receipts  map[string]receipt
+	webhooks  sync.WaitGroup
+	errs      []error
+}
+
+// NewServer starts a Server
+func NewServer() *Server {
+	s := &Server{
+		webhook:   &http.Client{Timeout: 5 * time.Second},
+		tokens:    make(map[string]time.Time),
+		scripts:   make(map[string][]Scenario),
+		checkouts: make(map[string]*checkout),
+		receipts:  make(map[string]receipt),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+daraja.EndpointAuthentication, s.authenticate)
+	s.routes(mux)
+
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close waits for pending webhooks and shuts down the server
+func (s *Server) Close() {
+	s.webhooks.Wait()
+	s.server.Close()
+}
+
+// Client returns a daraja.Client for the server, authenticated with ConsumerKey and ConsumerSecret
+func (s *Server) Client() daraja.Client {
+	client := daraja.New(daraja.Config{Endpoint: s.URL})
+	client.Hooks.Build.PushBackHook(daraja.Authenticate(client.AuthenticationRequest(ConsumerKey, ConsumerSecret)))
+	return client
+}
+
+// Script queues scenarios for an operation, e.g. daraja.OperationB2C. Each request of the
+// operation takes the next scenario in the queue, and Success once the queue is empty.
+func (s *Server) Script(operation string, scenarios ...Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[operation] = append(s.scripts[operation], scenarios...)
+}
+
+// ExpireTokens expires the access tokens issued so far, later requests with them are
+// rejected with daraja.InvalidAccessToken
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.tokens)
+}
+
+// Wait blocks until the pending webhooks are sent, and returns the errors of the ones
+// that could not be delivered
+func (s *Server) Wait() error {
+	s.webhooks.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := errors.Join(s.errs...)
+	s.errs = nil
+	return err
+}
+
+func (s *Server) next(operation string) Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queue := s.scripts[operation]
+	if len(queue) == 0 {
+		return Success
+	}
+	s.scripts[operation] = queue[1:]
+	return queue[0]
+}
+
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("grant_type") != "client_credentials" {
+		s.fail(w, failure{http.StatusBadRequest, daraja.InvalidGrantType, "Invalid grant type passed"})
+		return
+	}
+	key, secret, ok := r.BasicAuth()
+	if !ok || key != ConsumerKey || secret != ConsumerSecret {
+		s.fail(w, failure{http.StatusBadRequest, daraja.InvalidAuthType, "Invalid Authentication passed"})
+		return
+	}
+
+	s.mu.Lock()
+	s.seq++
+	token := fmt.Sprintf("daratest%024d", s.seq)
+	s.tokens[token] = time.Now().Add(TokenTTL)
+	s.mu.Unlock()
+
+	s.reply(w, daraja.ResponseAuthorization{AccessToken: token, ExpiresIn: fmt.Sprint(TokenTTL.Seconds())})
+}
+
+func (s *Server) authorize(r *http.Request) error {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return failure{http.StatusBadRequest, daraja.InvalidAuthHeader, "Invalid Authentication passed"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if expiry, ok := s.tokens[token]; !ok || time.Now().After(expiry) {
+		return failure{http.StatusUnauthorized, daraja.InvalidAccessToken, "Invalid Access Token"}
+	}
+	return nil
+}
+
+// handle returns a handler of an operation. The handler authenticates the request,
+// decodes and validates the payload, applies the next scenario of the operation and
+// replies with the response of fn.
+func handle[T any](s *Server, operation string, fn func(req T, sc Scenario) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.authorize(r); err != nil {
+			s.fail(w, err)
+			return
+		}
+
+		var req T
+		if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.fail(w, failure{http.StatusBadRequest, daraja.InvalidRequestPayload, "Invalid request payload"})
+			return
+		}
+		if v, ok := any(req).(interface{ Validate() error }); ok {
+			var field *daraja.FieldError
+			if err := v.Validate(); errors.As(err, &field) {
+				s.fail(w, badRequest(field.Field))
+				return
+			}
+		}
+
+		sc := s.next(operation)
+		if sc.Error != nil {
+			status := sc.Status
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			s.fail(w, failure{status, sc.Error.ErrorCode, sc.Error.ErrorMessage})
+			return
+		}
+
+		res, err := fn(req, sc)
+		if err != nil {
+			s.fail(w, err)
+			return
+		}
+		s.reply(w, res)
+	}
+}
+
+func (s *Server) reply(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = jsoniter.NewEncoder(w).Encode(body)
+}
+
+func (s *Server) fail(w http.ResponseWriter, err error) {
+	var f failure
+	if !errors.As(err, &f) {
+		f = failure{http.StatusInternalServerError, daraja.InternalServerError, err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.status)
+	_ = jsoniter.NewEncoder(w).Encode(daraja.ErrorResponse{
+		RequestID:    s.requestID(),
+		ErrorCode:    f.code,
+		ErrorMessage: f.message,
+	})
+}
+
+// deliver posts body to url after the delay of the scenario, unless the scenario times out.
+// The done func, if any, is called just before the webhook is sent.
+func (s *Server) deliver(url string, sc Scenario, body any, done func()) {
+	if sc.Timeout {
+		return
+	}
+
+	s.webhooks.Add(1)
+	go func() {
+		defer s.webhooks.Done()
+		time.Sleep(sc.Delay)
+		if done != nil {
+			done()
+		}
+
+		if err := s.post(url, body); err != nil {
+			s.mu.Lock()
+			s.errs = append(s.errs, fmt.Errorf("webhook %s: %w", url, err))
+			s.mu.Unlock()
+		}
+	}()
+}
+
+func (s *Server) post(url string, body any) error {
+	b, err := jsoniter.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.webhook.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// ids
+
+func (s *Server) id() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return s.seq
+}
+
+// requestID returns an id in the format of daraja request, merchant request and
+// originator conversation ids e.g. 29115-34620561-1
+func (s *Server) requestID() string {
+	return fmt.Sprintf("%d-%d-1", 29115, 34620561+s.id())
+}
+
+// conversationID returns an id in the format of daraja conversation ids e.g. AG_20191219_00005797af5d7d75f652
+func (s *Server) conversationID() string {
+	return fmt.Sprintf("AG_%s_%020x", time.Now().Format("20060102"), s.id())
+}
+
+// checkoutID returns an id in the format of daraja checkout request ids e.g. ws_CO_191220191020363925
+func (s *Server) checkoutID() string {
+	return fmt.Sprintf("ws_CO_%s%06d", time.Now().Format("02012006150405"), s.id())
+}
+
+// receiptNumber returns an M-PESA receipt number e.g. NLJ7RT61SV
+func (s *Server) receiptNumber() string {
+	return fmt.Sprintf("TST%07X", s.id())
+}
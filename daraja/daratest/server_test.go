@@ -0,0 +1,316 @@
+package daratest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/daraja/daratest"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/types"
+)
+
+// webhooks starts a server that collects the webhooks posted to it
+func webhooks(t *testing.T) (string, <-chan []byte) {
+	received := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := new(jsoniter.RawMessage)
+		assert.NoError(t, jsoniter.NewDecoder(r.Body).Decode(body))
+		received <- *body
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, received
+}
+
+func receive[T any](t *testing.T, received <-chan []byte) T {
+	t.Helper()
+
+	var webhook T
+	select {
+	case body := <-received:
+		assert.NoError(t, jsoniter.Unmarshal(body, &webhook))
+	case <-time.After(time.Second):
+		t.Fatal("webhook not received")
+	}
+	return webhook
+}
+
+func c2bExpress(url string) daraja.RequestC2BExpress {
+	return daraja.RequestC2BExpress{
+		BusinessShortCode: "174379",
+		Password:          daraja.PasswordEncode("174379", "passkey", daraja.NewTimestamp().String()),
+		Timestamp:         daraja.NewTimestamp(),
+		TransactionType:   daraja.TypeCustomerPayBillOnline,
+		Amount:            "10",
+		PartyA:            "254712345678",
+		PartyB:            "174379",
+		PhoneNumber:       "254712345678",
+		CallBackURL:       url,
+		AccountReference:  "F0000020",
+		TransactionDesc:   "Deposit",
+	}
+}
+
+func b2c(url string) daraja.RequestB2C {
+	return daraja.RequestB2C{
+		OriginatorConversationID: "fake_id",
+		InitiatorName:            "fake_name",
+		SecurityCredential:       "fake_credential",
+		CommandID:                daraja.CommandBusinessPayment,
+		Amount:                   "150",
+		PartyA:                   "600000",
+		PartyB:                   "254712345678",
+		Remarks:                  "test payment",
+		QueueTimeOutURL:          url,
+		ResultURL:                url,
+	}
+}
+
+func TestServer_C2BExpress(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	url, received := webhooks(t)
+
+	t.Run("test that a successful payment is called back", func(t *testing.T) {
+		res, err := client.C2BExpress(t.Context(), c2bExpress(url))
+		assert.NoError(t, err)
+		assert.Equal(t, daraja.SuccessSubmission, res.ResponseCode)
+
+		webhook := receive[daraja.WebhookRequestC2BExpress](t, received)
+		assert.Equal(t, daraja.ResultCodeSuccess, webhook.Body.StkCallback.ResultCode)
+		assert.Equal(t, res.CheckoutRequestID, webhook.Body.StkCallback.CheckoutRequestID)
+		m, err := webhook.Money()
+		assert.NoError(t, err)
+		assert.Equal(t, money.FromUnits(10, money.KES), m)
+
+		query, err := client.C2BQuery(t.Context(), daraja.RequestC2BExpressQuery{
+			BusinessShortCode: "174379", Password: "fake_password", Timestamp: daraja.NewTimestamp().String(),
+			CheckoutRequestID: res.CheckoutRequestID,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "0", query.ResultCode)
+	})
+
+	t.Run("test that a cancelled payment is called back", func(t *testing.T) {
+		server.Script(daraja.OperationC2BExpress, daratest.Cancelled)
+
+		_, err := client.C2BExpress(t.Context(), c2bExpress(url))
+		assert.NoError(t, err)
+
+		webhook := receive[daraja.WebhookRequestC2BExpress](t, received)
+		assert.Equal(t, daraja.ResultCodeCancelledRequest, webhook.Body.StkCallback.ResultCode)
+		assert.Nil(t, webhook.Body.StkCallback.CallbackMetadata)
+	})
+
+	t.Run("test that a timed out payment is still processing", func(t *testing.T) {
+		server.Script(daraja.OperationC2BExpress, daratest.Timeout)
+
+		res, err := client.C2BExpress(t.Context(), c2bExpress(url))
+		assert.NoError(t, err)
+		assert.NoError(t, server.Wait())
+		assert.Empty(t, received)
+
+		_, err = client.C2BQuery(t.Context(), daraja.RequestC2BExpressQuery{
+			BusinessShortCode: "174379", Password: "fake_password", Timestamp: daraja.NewTimestamp().String(),
+			CheckoutRequestID: res.CheckoutRequestID,
+		})
+		assert.ErrorContains(t, err, daraja.SubscriberLock.String())
+	})
+}
+
+func TestServer_B2C(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	url, received := webhooks(t)
+
+	t.Run("test that a successful payment has result parameters", func(t *testing.T) {
+		res, err := client.B2C(t.Context(), b2c(url))
+		assert.NoError(t, err)
+		assert.Equal(t, "fake_id", res.OriginatorConversationID)
+
+		webhook := receive[daraja.WebhookRequestB2C](t, received)
+		assert.Equal(t, daraja.ResultCodeSuccess, webhook.Result.ResultCode)
+		assert.Equal(t, res.ConversationID, webhook.Result.ConversationID)
+		m, err := webhook.Money()
+		assert.NoError(t, err)
+		assert.Equal(t, money.FromUnits(150, money.KES), m)
+
+		// the receipt can be looked up
+		_, err = client.TransactionStatus(t.Context(), daraja.RequestTransactionStatus{
+			Initiator:          "fake_initiator",
+			SecurityCredential: "fake_credential",
+			CommandID:          daraja.CommandTransactionStatus,
+			TransactionID:      types.Pointer(webhook.Result.TransactionID),
+			PartyA:             "600000",
+			IdentifierType:     daraja.IdentifierOrgShortCode,
+			ResultURL:          url,
+			QueueTimeOutURL:    url,
+			Remarks:            "status",
+		})
+		assert.NoError(t, err)
+
+		status := receive[daraja.WebhookRequestTransactionStatus](t, received)
+		m, err = status.Money()
+		assert.NoError(t, err)
+		assert.Equal(t, money.FromUnits(150, money.KES), m)
+	})
+
+	t.Run("test that scripted scenarios are applied in order", func(t *testing.T) {
+		server.Script(daraja.OperationB2C, daratest.InsufficientBalance, daratest.SubscriberLocked)
+
+		_, err := client.B2C(t.Context(), b2c(url))
+		assert.NoError(t, err)
+		webhook := receive[daraja.WebhookRequestB2C](t, received)
+		assert.Equal(t, daraja.ResultCodeInsufficientBalance, webhook.Result.ResultCode)
+		assert.Nil(t, webhook.Result.ResultParameters)
+
+		_, err = client.B2C(t.Context(), b2c(url))
+		assert.ErrorContains(t, err, daraja.SubscriberLock.String())
+
+		// the queue is empty
+		_, err = client.B2C(t.Context(), b2c(url))
+		assert.NoError(t, err)
+		webhook = receive[daraja.WebhookRequestB2C](t, received)
+		assert.Equal(t, daraja.ResultCodeSuccess, webhook.Result.ResultCode)
+	})
+
+	t.Run("test that a delayed result is sent late", func(t *testing.T) {
+		delayed := daratest.Success
+		delayed.Delay = 50 * time.Millisecond
+		server.Script(daraja.OperationB2C, delayed)
+
+		_, err := client.B2C(t.Context(), b2c(url))
+		assert.NoError(t, err)
+		assert.Empty(t, received)
+		assert.NoError(t, server.Wait())
+		receive[daraja.WebhookRequestB2C](t, received)
+	})
+}
+
+func TestServer_B2B(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	url, received := webhooks(t)
+
+	// b2b payments and top ups share an endpoint
+	server.Script(daraja.OperationB2CTopUp, daratest.InsufficientBalance)
+
+	_, err := client.B2B(t.Context(), daraja.RequestB2B{
+		Initiator: "fake_initiator", SecurityCredential: "fake_credential", CommandID: daraja.CommandBusinessPayBill,
+		SenderIdentifierType: daraja.IdentifierOrgShortCode, RecieverIdentifierType: daraja.IdentifierOrgShortCode,
+		Amount: "10", PartyA: "600000", PartyB: "600001", AccountReference: "fake_ref", Remarks: "test payment",
+		QueueTimeOutURL: url, ResultURL: url,
+	})
+	assert.NoError(t, err)
+	webhook := receive[daraja.WebhookRequestB2B](t, received)
+	assert.Equal(t, daraja.ResultCodeSuccess, webhook.Result.ResultCode)
+	m, err := webhook.Money()
+	assert.NoError(t, err)
+	assert.Equal(t, money.FromUnits(10, money.KES), m)
+
+	_, err = client.B2CTopUp(t.Context(), daraja.RequestB2CTopUp{
+		Initiator: "fake_initiator", SecurityCredential: "fake_credential", CommandID: daraja.CommandBusinessPayToBulk,
+		SenderIdentifierType: daraja.IdentifierOrgShortCode, RecieverIdentifierType: daraja.IdentifierOrgShortCode,
+		Amount: "1000", PartyA: "600979", PartyB: "600000", AccountReference: "353353", Remarks: "float top up",
+		QueueTimeOutURL: url, ResultURL: url,
+	})
+	assert.NoError(t, err)
+	topUp := receive[daraja.WebhookRequestB2CTopUp](t, received)
+	assert.Equal(t, daraja.ResultCodeInsufficientBalance, topUp.Result.ResultCode)
+}
+
+func TestServer_Balance(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	url, received := webhooks(t)
+
+	_, err := server.Client().Balance(t.Context(), daraja.RequestBalance{
+		Initiator: "fake_initiator", SecurityCredential: "fake_credential", CommandID: daraja.CommandAccountBalance,
+		PartyA: "600000", IdentifierType: daraja.IdentifierOrgShortCode, Remarks: "balance",
+		QueueTimeOutURL: url, ResultURL: url,
+	})
+	assert.NoError(t, err)
+
+	webhook := receive[daraja.WebhookRequestBalance](t, received)
+	if assert.NotNil(t, webhook.Result.ResultParameters) {
+		assert.Equal(t, "AccountBalance", webhook.Result.ResultParameters.ResultParameter[0].Key)
+		assert.Contains(t, webhook.Result.ResultParameters.ResultParameter[0].Value, "Utility Account|KES|228037.00")
+	}
+}
+
+func TestServer_PullQuery(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	url, received := webhooks(t)
+
+	start := time.Now().Add(-time.Minute)
+	_, err := client.C2BExpress(t.Context(), c2bExpress(url))
+	assert.NoError(t, err)
+	receive[daraja.WebhookRequestC2BExpress](t, received)
+
+	res, err := client.PullQuery(t.Context(), daraja.RequestPullQuery{
+		ShortCode:   "174379",
+		StartDate:   start.Format(daraja.PullDateFormat),
+		EndDate:     time.Now().Add(time.Minute).Format(daraja.PullDateFormat),
+		OffSetValue: "0",
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Transactions(), 1) {
+		assert.Equal(t, "254712345678", res.Transactions()[0].MSISDN)
+		assert.Equal(t, "F0000020", res.Transactions()[0].BillRefNumber)
+	}
+}
+
+func TestServer_Authentication(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	url, _ := webhooks(t)
+
+	t.Run("test that invalid credentials are rejected", func(t *testing.T) {
+		client := daraja.New(daraja.Config{Endpoint: server.URL})
+		client.Hooks.Build.PushBackHook(daraja.Authenticate(client.AuthenticationRequest("key", "secret")))
+
+		_, err := client.B2C(t.Context(), b2c(url))
+		assert.ErrorContains(t, err, daraja.InvalidAuthType.String())
+	})
+
+	t.Run("test that requests without a token are rejected", func(t *testing.T) {
+		client := daraja.New(daraja.Config{Endpoint: server.URL})
+
+		_, err := client.B2C(t.Context(), b2c(url))
+		assert.ErrorContains(t, err, daraja.InvalidAuthHeader.String())
+	})
+
+	t.Run("test that expired tokens are rejected", func(t *testing.T) {
+		client := server.Client()
+		_, err := client.B2C(t.Context(), b2c(url))
+		assert.NoError(t, err)
+
+		server.ExpireTokens()
+		_, err = client.B2C(t.Context(), b2c(url))
+		assert.ErrorContains(t, err, daraja.InvalidAccessToken.String())
+	})
+}
+
+func TestServer_Validation(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	url, _ := webhooks(t)
+
+	// send the request without the client validator hooks
+	req := b2c(url)
+	req.Amount = "10.50"
+	_, err := server.Client().B2C(t.Context(), req)
+	assert.ErrorContains(t, err, "Bad Request - Invalid Amount")
+	assert.ErrorContains(t, err, daraja.InvalidReceiverIdentifierType.String())
+}
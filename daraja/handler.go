@@ -0,0 +1,37 @@
+package daraja
+
+import (
+	"context"
+	"net/http"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// BillManagerHandler returns an http.Handler that receives Bill Manager payment notifications
+// sent to the CallbackURL of RequestBillManagerOptIn. Each notification is parsed into a
+// WebhookRequestBillManagerPayment and passed to fn. The notification is acknowledged when
+// fn returns nil, otherwise the handler responds with http.StatusInternalServerError.
+func BillManagerHandler(fn func(ctx context.Context, payment WebhookRequestBillManagerPayment) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var payment WebhookRequestBillManagerPayment
+		if err := jsoniter.NewDecoder(r.Body).Decode(&payment); err != nil {
+			http.Error(w, "invalid payment notification", http.StatusBadRequest)
+			return
+		}
+
+		if err := fn(r.Context(), payment); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = jsoniter.NewEncoder(w).Encode(WebhookResponseBillManager{ResMsg: "Success", ResCode: "200"})
+	})
+}
@@ -0,0 +1,64 @@
+package daraja_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/money"
+)
+
+func TestBillManagerHandler(t *testing.T) {
+	notification := `{"transactionId":"RJB53MYR1N","paidAmount":"5000","msisdn":"254710119383",
+"dateCreated":"2019-09-15","accountReference":"LGHJIO789","shortCode":"718003"}`
+
+	t.Run("test that a notification is parsed and acknowledged", func(t *testing.T) {
+		var received daraja.WebhookRequestBillManagerPayment
+		server := httptest.NewServer(daraja.BillManagerHandler(func(ctx context.Context, payment daraja.WebhookRequestBillManagerPayment) error {
+			received = payment
+			return nil
+		}))
+		defer server.Close()
+
+		res, err := http.Post(server.URL, "application/json", strings.NewReader(notification))
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		body := new(strings.Builder)
+		_, _ = io.Copy(body, res.Body)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.JSONEq(t, `{"resmsg":"Success","rescode":"200"}`, body.String())
+
+		assert.Equal(t, "RJB53MYR1N", received.TransactionID)
+		assert.Equal(t, "254710119383", received.MSISDN)
+		assert.Equal(t, "LGHJIO789", received.AccountReference)
+		m, err := received.Money()
+		assert.NoError(t, err)
+		assert.Equal(t, money.FromUnits(5000, money.KES), m)
+	})
+
+	t.Run("test that failures are not acknowledged", func(t *testing.T) {
+		handler := daraja.BillManagerHandler(func(ctx context.Context, payment daraja.WebhookRequestBillManagerPayment) error {
+			return errors.New("database unavailable")
+		})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(notification)))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{")))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
@@ -1,6 +1,7 @@
 package daraja
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -8,6 +9,8 @@ import (
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/internal/exchange"
 )
 
 // HTTPClient creates an instance of http.Client configured
@@ -30,6 +33,10 @@ func (r errResponse) Error() string {
 	return fmt.Sprintf("<%s> %s", r.ErrorCode, r.ErrorMessage)
 }
 
+func (r errResponse) ResultCode() string {
+	return r.ErrorCode.String()
+}
+
 // ResponseDecoder parse the http.Response body into the property
 // gorequest.gorequest.Data, if the status code is successful
 // Otherwise for failed requests, it will parse the error response
@@ -41,7 +48,7 @@ var ResponseDecoder = gorequest.Hook{
 		if r.Response.StatusCode != http.StatusOK {
 			response := &errResponse{}
 			if err := jsoniter.NewDecoder(r.Response.Body).Decode(response); err != nil {
-				r.Error = err
+				r.Error = &exchange.DecodeError{StatusCode: r.Response.StatusCode, Err: err}
 				return
 			}
 			r.Error = response
@@ -81,3 +88,63 @@ func Authenticate(reqFn AuthenticationRequestFunc) gorequest.Hook {
 
 		}}
 }
+
+// validator is a build hook that validates request payloads of type T
+func validator[T interface{ Validate() error }](name string) gorequest.Hook {
+	return gorequest.Hook{
+		Name: name,
+		Fn: func(r *gorequest.Request) {
+			// get and cast request payload
+			payload, ok := r.Params.(T)
+			if !ok {
+				r.Error = errors.New("invalid payload")
+				return
+			}
+
+			if err := payload.Validate(); err != nil {
+				r.Error = err
+			}
+		}}
+}
+
+// Validation build hooks for each request model. Errors returned by the hooks are
+// joined FieldError values that report the offending field names.
+var (
+	C2BExpressValidator         = validator[RequestC2BExpress]("daraja.C2BExpressValidator")
+	B2CValidator                = validator[RequestB2C]("daraja.B2CValidator")
+	B2BValidator                = validator[RequestB2B]("daraja.B2BValidator")
+	B2BExpressCheckoutValidator = validator[RequestB2BExpressCheckout]("daraja.B2BExpressCheckoutValidator")
+	B2CTopUpValidator           = validator[RequestB2CTopUp]("daraja.B2CTopUpValidator")
+	B2PochiValidator            = validator[RequestB2Pochi]("daraja.B2PochiValidator")
+	TaxRemittanceValidator      = validator[RequestTaxRemittance]("daraja.TaxRemittanceValidator")
+	StandingOrderValidator      = validator[RequestStandingOrder]("daraja.StandingOrderValidator")
+	TransactionStatusValidator  = validator[RequestTransactionStatus]("daraja.TransactionStatusValidator")
+	ReversalValidator           = validator[RequestReversal]("daraja.ReversalValidator")
+	BalanceValidator            = validator[RequestBalance]("daraja.BalanceValidator")
+	PullRegisterValidator       = validator[RequestPullRegister]("daraja.PullRegisterValidator")
+	PullQueryValidator          = validator[RequestPullQuery]("daraja.PullQueryValidator")
+	DynamicQRValidator          = validator[RequestDynamicQR]("daraja.DynamicQRValidator")
+
+	BillManagerOptInValidator             = validator[RequestBillManagerOptIn]("daraja.BillManagerOptInValidator")
+	BillManagerInvoiceValidator           = validator[RequestBillManagerInvoice]("daraja.BillManagerInvoiceValidator")
+	BillManagerBulkInvoiceValidator       = validator[RequestBillManagerBulkInvoice]("daraja.BillManagerBulkInvoiceValidator")
+	BillManagerCancelInvoiceValidator     = validator[RequestBillManagerCancelInvoice]("daraja.BillManagerCancelInvoiceValidator")
+	BillManagerCancelBulkInvoiceValidator = validator[RequestBillManagerCancelBulkInvoice]("daraja.BillManagerCancelBulkInvoiceValidator")
+	BillManagerReconciliationValidator    = validator[RequestBillManagerReconciliation]("daraja.BillManagerReconciliationValidator")
+)
+
+// RequestValidator is a build hook that validates any request payload that implements
+// a Validate method, and ignores the rest. Unlike the model specific validators, it can be
+// added to Client.Hooks to validate requests of all operations.
+var RequestValidator = gorequest.Hook{
+	Name: "daraja.RequestValidator",
+	Fn: func(r *gorequest.Request) {
+		payload, ok := r.Params.(interface{ Validate() error })
+		if !ok {
+			return
+		}
+
+		if err := payload.Validate(); err != nil {
+			r.Error = err
+		}
+	}}
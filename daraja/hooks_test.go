@@ -136,3 +136,83 @@ func TestResponseDecoder(t *testing.T) {
 		}
 	})
 }
+
+func TestValidators(t *testing.T) {
+	tcs := []struct {
+		name   string
+		hook   gorequest.Hook
+		params any
+	}{
+		{"C2BExpressValidator", C2BExpressValidator, validC2BExpress()},
+		{"B2CValidator", B2CValidator, validB2C()},
+		{"B2BValidator", B2BValidator, validB2B()},
+		{"B2BExpressCheckoutValidator", B2BExpressCheckoutValidator, validB2BExpressCheckout()},
+		{"B2CTopUpValidator", B2CTopUpValidator, validB2CTopUp()},
+		{"B2PochiValidator", B2PochiValidator, validB2Pochi()},
+		{"TaxRemittanceValidator", TaxRemittanceValidator, validTaxRemittance()},
+		{"StandingOrderValidator", StandingOrderValidator, validStandingOrder()},
+		{"TransactionStatusValidator", TransactionStatusValidator, validTransactionStatus()},
+		{"ReversalValidator", ReversalValidator, validReversal()},
+		{"BalanceValidator", BalanceValidator, validBalance()},
+		{"PullRegisterValidator", PullRegisterValidator, validPullRegister()},
+		{"PullQueryValidator", PullQueryValidator, validPullQuery()},
+		{"DynamicQRValidator", DynamicQRValidator, validDynamicQR()},
+		{"BillManagerOptInValidator", BillManagerOptInValidator, validBillManagerOptIn()},
+		{"BillManagerInvoiceValidator", BillManagerInvoiceValidator, validBillManagerInvoice()},
+		{"BillManagerBulkInvoiceValidator", BillManagerBulkInvoiceValidator, RequestBillManagerBulkInvoice{validBillManagerInvoice()}},
+		{"BillManagerCancelInvoiceValidator", BillManagerCancelInvoiceValidator, RequestBillManagerCancelInvoice{ExternalReference: "113"}},
+		{"BillManagerCancelBulkInvoiceValidator", BillManagerCancelBulkInvoiceValidator, RequestBillManagerCancelBulkInvoice{{ExternalReference: "113"}}},
+		{"BillManagerReconciliationValidator", BillManagerReconciliationValidator, validBillManagerReconciliation()},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			// valid payload passes
+			req := &gorequest.Request{Params: tc.params}
+			tc.hook.Fn(req)
+			assert.NoError(t, req.Error)
+
+			// payload of another type is rejected
+			req = &gorequest.Request{Params: RequestOrgInfoQuery{}}
+			tc.hook.Fn(req)
+			assert.EqualError(t, req.Error, "invalid payload")
+		})
+	}
+}
+
+func TestRequestValidator(t *testing.T) {
+
+	t.Run("test that it stops invalid requests before they are sent", func(t *testing.T) {
+		calls := 0
+
+		// create a test server
+		mux := http.NewServeMux()
+		mux.HandleFunc(EndpointB2cPayment, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"ResponseMessage":"Success","ResponseCode":"0"}`))
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		client := New(Config{Endpoint: server.URL})
+		client.Hooks.Build.PushFrontHook(RequestValidator)
+
+		payload := validB2C()
+		payload.PartyB = "0712345678"
+		_, err := client.B2C(t.Context(), payload)
+		assert.ErrorIs(t, err, ErrInvalidMSISDN)
+		assert.ErrorContains(t, err, "PartyB")
+		assert.Equal(t, 0, calls)
+
+		_, err = client.B2C(t.Context(), validB2C())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("test that it ignores payloads without validation", func(t *testing.T) {
+		req := &gorequest.Request{Params: RequestOrgInfoQuery{}}
+		RequestValidator.Fn(req)
+		assert.NoError(t, req.Error)
+	})
+}
@@ -1,12 +1,26 @@
 package daraja
 
 import (
+	"bytes"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/payments/money"
 )
 
+// ErrInvalidQRCode is returned when the QR code of a ResponseDynamicQR is not a base64 encoded PNG
+var ErrInvalidQRCode = errors.New("invalid qr code")
+
+// ErrInvalidAccountBalance is returned when the AccountBalance result parameter cannot be parsed
+var ErrInvalidAccountBalance = errors.New("invalid account balance")
+
 // ENUMS
 
 // ResultCode represents the asynchronous result notification from the Daraja API
@@ -48,6 +62,9 @@ const (
 	CommandPromotionPayment    Command = "PromotionPayment"
 	CommandTransactionReversal Command = "TransactionReversal"
 	CommandTransactionStatus   Command = "TransactionStatusQuery"
+	CommandBusinessPayToBulk   Command = "BusinessPayToBulk"
+	CommandBusinessPayToPochi  Command = "BusinessPayToPochi"
+	CommandPayTaxToKRA         Command = "PayTaxToKRA"
 )
 
 type IdentifierType string
@@ -71,6 +88,34 @@ type TransactionType string
 const (
 	TypeCustomerPayBillOnline  TransactionType = "CustomerPayBillOnline"
 	TypeCustomerBuyGoodsOnline TransactionType = "CustomerBuyGoodsOnline"
+
+	TypeStandingOrderPayBill  TransactionType = "Standing Order Customer Pay Bill"
+	TypeStandingOrderBuyGoods TransactionType = "Standing Order Customer Pay Marchant" // sic
+)
+
+// QRTransactionCode is the type of transaction a dynamic QR code is for
+type QRTransactionCode string
+
+const (
+	QRBuyGoods       QRTransactionCode = "BG" // Pay Merchant (Buy Goods)
+	QRWithdrawAgent  QRTransactionCode = "WA" // Withdraw Cash at Agent Till
+	QRPayBill        QRTransactionCode = "PB" // Paybill or Business number
+	QRSendMoney      QRTransactionCode = "SM" // Send Money (Mobile number)
+	QRSendToBusiness QRTransactionCode = "SB" // Sent to Business. Business number CPI in MSISDN format
+)
+
+// Frequency is how often a standing order is paid
+type Frequency string
+
+const (
+	FrequencyOneOff     Frequency = "1"
+	FrequencyDaily      Frequency = "2"
+	FrequencyWeekly     Frequency = "3"
+	FrequencyMonthly    Frequency = "4"
+	FrequencyBiMonthly  Frequency = "5"
+	FrequencyQuarterly  Frequency = "6"
+	FrequencyHalfYearly Frequency = "7"
+	FrequencyYearly     Frequency = "8"
 )
 
 //go:generate stringer -type=ResponseCode -linecomment -output=models_string.go
@@ -432,6 +477,153 @@ type RequestB2B struct {
 	ResultURL string `json:"ResultURL"`
 }
 
+// RequestB2CTopUp loads funds from the MMF/working account of a business to the utility
+// account of a B2C shortcode, e.g. to top up the float of a merchant
+type RequestB2CTopUp struct {
+	//This is the credential/username used to authenticate the request
+	Initiator string `json:"Initiator"`
+
+	//The encrypted password of the M-Pesa API operator
+	SecurityCredential string `json:"SecurityCredential"`
+
+	//Takes only the 'BusinessPayToBulk' Command
+	CommandID Command `json:"CommandID"`
+
+	//The type of shortcode from which money is deducted. Only "4" is allowed
+	SenderIdentifierType IdentifierType `json:"SenderIdentifierType"`
+
+	//The type of shortcode to which money is credited. Only "4" is allowed
+	RecieverIdentifierType IdentifierType `json:"RecieverIdentifierType"`
+
+	//The transaction amount
+	Amount string `json:"Amount"`
+
+	//Your shortcode. The shortcode from which money will be deducted
+	PartyA string `json:"PartyA"`
+
+	//The B2C shortcode whose utility account is credited
+	PartyB string `json:"PartyB"`
+
+	//The account number to be associated with the payment
+	AccountReference string `json:"AccountReference"`
+
+	//The consumer’s mobile number on behalf of whom you are paying (optional)
+	Requester *string `json:"Requester,omitempty"`
+
+	//Any additional information to be associated with the transaction
+	Remarks string `json:"Remarks"`
+
+	//A URL that will be used to notify your system in case the request times out before processing
+	QueueTimeOutURL string `json:"QueueTimeOutURL"`
+
+	//A URL that will be used to send transaction results after processing
+	ResultURL string `json:"ResultURL"`
+}
+
+// RequestB2Pochi pays a customer's Pochi la Biashara wallet from a B2C shortcode
+type RequestB2Pochi struct {
+	//This is a unique string you specify for every API request you simulate
+	OriginatorConversationID string `json:"OriginatorConversationID"`
+
+	//This is an API user created by the Business Administrator of the M-PESA Bulk
+	//disbursement account that is active and authorized to initiate B2C transactions via API
+	InitiatorName string `json:"InitiatorName"`
+
+	//This is the value obtained after encrypting the API initiator password
+	SecurityCredential string `json:"SecurityCredential"`
+
+	//Takes only the 'BusinessPayToPochi' Command
+	CommandID Command `json:"CommandID"`
+
+	//The amount of money being sent to the customer
+	Amount string `json:"Amount"`
+
+	//This is the B2C organization shortcode from which the money is sent from
+	PartyA string `json:"PartyA"`
+
+	//This is the mobile number of the Pochi la Biashara wallet to receive the amount.
+	//The number should have the country code (254) without the plus sign
+	PartyB string `json:"PartyB"`
+
+	//Any additional information to be associated with the transaction.
+	//String up to 100 characters
+	Remarks string `json:"Remarks"`
+
+	//This is the URL that will be used to send notification incase the payment
+	//request is timed out while awaiting processing in the queue
+	QueueTimeOutURL string `json:"QueueTimeOutURL"`
+
+	//This is the URL that will be used to send notification upon processing of the payment request
+	ResultURL string `json:"ResultURL"`
+
+	//Any additional information to be associated with the transaction
+	Occasion string `json:"Occasion"`
+}
+
+// RequestTaxRemittance remits tax to the Kenya Revenue Authority (KRA) from a shortcode
+type RequestTaxRemittance struct {
+	//This is the credential/username used to authenticate the request
+	Initiator string `json:"Initiator"`
+
+	//The encrypted password of the M-Pesa API operator
+	SecurityCredential string `json:"SecurityCredential"`
+
+	//Takes only the 'PayTaxToKRA' Command
+	CommandID Command `json:"CommandID"`
+
+	//The type of shortcode from which money is deducted. Only "4" is allowed
+	SenderIdentifierType IdentifierType `json:"SenderIdentifierType"`
+
+	//The type of shortcode to which money is credited. Only "4" is allowed
+	RecieverIdentifierType IdentifierType `json:"RecieverIdentifierType"`
+
+	//The amount of tax to remit
+	Amount string `json:"Amount"`
+
+	//Your shortcode. The shortcode from which money will be deducted
+	PartyA string `json:"PartyA"`
+
+	//The KRA shortcode, which is KRAShortCode
+	PartyB string `json:"PartyB"`
+
+	//The payment registration number (PRN) issued by KRA for the tax being paid
+	AccountReference string `json:"AccountReference"`
+
+	//Any additional information to be associated with the transaction
+	Remarks string `json:"Remarks"`
+
+	//A URL that will be used to notify your system in case the request times out before processing
+	QueueTimeOutURL string `json:"QueueTimeOutURL"`
+
+	//A URL that will be used to send transaction results after processing
+	ResultURL string `json:"ResultURL"`
+}
+
+// RequestB2BExpressCheckout initiates a USSD push to the till operator of a merchant, to
+// pay a vendor shortcode from the merchant's till
+type RequestB2BExpressCheckout struct {
+	//The till number of the merchant, whose operator receives the USSD prompt
+	PrimaryShortCode string `json:"primaryShortCode"`
+
+	//The paybill number of the vendor receiving the payment
+	ReceiverShortCode string `json:"receiverShortCode"`
+
+	//The amount to pay. Only whole numbers are supported
+	Amount string `json:"amount"`
+
+	//The reference of the payment, shown to the merchant in the USSD prompt
+	PaymentRef string `json:"paymentRef"`
+
+	//The URL that will receive the result of the payment
+	CallbackURL string `json:"callbackUrl"`
+
+	//The name of the vendor, shown to the merchant in the USSD prompt
+	PartnerName string `json:"partnerName"`
+
+	//A unique identifier of the request, returned as requestId in the callback
+	RequestRefID string `json:"RequestRefID"`
+}
+
 type RequestTransactionStatus struct {
 	//This is the credential/username used to authenticate the request
 	Initiator string `json:"Initiator"`
@@ -506,6 +698,206 @@ type RequestOrgInfoQuery struct {
 	Identifier string `json:"Identifier"`
 }
 
+type RequestDynamicQR struct {
+	//Name of the Company/M-Pesa Merchant Name
+	MerchantName string `json:"MerchantName"`
+
+	//Transaction Reference
+	RefNo string `json:"RefNo"`
+
+	//The total amount for the sale/transaction, in whole shillings
+	Amount int64 `json:"Amount"`
+
+	//Transaction Type
+	TrxCode QRTransactionCode `json:"TrxCode"`
+
+	//Credit Party Identifier. Can be a Mobile Number, Business Number, Agent
+	//Till, Paybill or Business number, or Merchant Buy Goods
+	CPI string `json:"CPI"`
+
+	//Size of the QR code image in pixels. QR code image will always be a square image
+	Size string `json:"Size"`
+}
+
+// StandingOrderDateFormat is the format of RequestStandingOrder StartDate and EndDate
+const StandingOrderDateFormat = "20060102"
+
+// RequestStandingOrder creates an M-PESA Ratiba standing order, which debits a customer
+// with the given Frequency from StartDate to EndDate. The customer approves the standing
+// order with an STK push prompt.
+type RequestStandingOrder struct {
+	//A unique name of the standing order for the customer
+	StandingOrderName string `json:"StandingOrderName"`
+
+	//The date of the first payment, in the format of StandingOrderDateFormat
+	StartDate string `json:"StartDate"`
+
+	//The date of the last payment, in the format of StandingOrderDateFormat
+	EndDate string `json:"EndDate"`
+
+	//The organization's shortcode (Paybill or Buygoods) receiving the payments
+	BusinessShortCode string `json:"BusinessShortCode"`
+
+	//"Standing Order Customer Pay Bill" for PayBill Numbers and
+	//"Standing Order Customer Pay Marchant" for Till Numbers
+	TransactionType TransactionType `json:"TransactionType"`
+
+	//"4" for PayBill Numbers and "2" for Till Numbers
+	ReceiverPartyIdentifierType IdentifierType `json:"ReceiverPartyIdentifierType"`
+
+	//The amount of each payment. Only whole numbers are supported
+	Amount string `json:"Amount"`
+
+	//The phone number of the customer paying, in the format 2547XXXXXXXX
+	PartyA string `json:"PartyA"`
+
+	//The URL that receives the result of creating the standing order
+	CallBackURL string `json:"CallBackURL"`
+
+	//The account number of the payments for Pay Bill standing orders. Maximum of 12 characters
+	AccountReference string `json:"AccountReference"`
+
+	//Any additional information to be associated with the standing order. Maximum of 13 characters
+	TransactionDesc string `json:"TransactionDesc"`
+
+	//How often the customer is debited
+	Frequency Frequency `json:"Frequency"`
+}
+
+// PullDateFormat is the format of RequestPullQuery StartDate and EndDate
+const PullDateFormat = "2006-01-02 15:04:05"
+
+type RequestPullRegister struct {
+	//The organization shortcode whose transactions will be pulled
+	ShortCode string `json:"ShortCode"`
+
+	//Takes only the 'Pull' request type
+	RequestType string `json:"RequestType"`
+
+	//The Safaricom number of the organization that will receive notifications of the registration
+	NominatedNumber string `json:"NominatedNumber"`
+
+	//The URL that will receive notifications of the organization's transactions
+	CallBackURL string `json:"CallBackURL"`
+}
+
+type RequestPullQuery struct {
+	//The organization shortcode registered for pull transactions
+	ShortCode string `json:"ShortCode"`
+
+	//The start of the period to query, in the format of PullDateFormat.
+	//Transactions of at most the last 48 hours can be pulled
+	StartDate string `json:"StartDate"`
+
+	//The end of the period to query, in the format of PullDateFormat
+	EndDate string `json:"EndDate"`
+
+	//The number of transactions to skip, used to page through the results of a period.
+	//"0" returns the first page
+	OffSetValue string `json:"OffSetValue"`
+}
+
+// BillManagerDateFormat is the format of dates in Bill Manager invoices and reconciliations
+const BillManagerDateFormat = "2006-01-02"
+
+// RequestBillManagerOptIn onboards a shortcode to Bill Manager. Payment notifications
+// of invoices are sent to CallbackURL.
+type RequestBillManagerOptIn struct {
+	//The organization's shortcode (Paybill or Buygoods) used to invoice customers
+	ShortCode string `json:"shortcode"`
+
+	//The official email address of the organization, shown on invoices
+	Email string `json:"email"`
+
+	//The official phone number of the organization, shown on invoices
+	OfficialContact string `json:"officialContact"`
+
+	//"1" to send reminders to customers before invoices are due, "0" otherwise
+	SendReminders string `json:"sendReminders"`
+
+	//An optional image of the organization's logo, shown on invoices
+	Logo string `json:"logo,omitempty"`
+
+	//The URL that receives payment notifications of invoices
+	CallbackURL string `json:"callbackurl"`
+}
+
+// BillManagerInvoiceItem is a line item of an invoice
+type BillManagerInvoiceItem struct {
+	ItemName string `json:"itemName"`
+	Amount   string `json:"amount"`
+}
+
+// RequestBillManagerInvoice sends an invoice to a customer through Bill Manager
+type RequestBillManagerInvoice struct {
+	//A unique reference of the invoice within the organization
+	ExternalReference string `json:"externalReference"`
+
+	//The full name of the customer being invoiced
+	BilledFullName string `json:"billedFullName"`
+
+	//The phone number of the customer being invoiced, in the format 07XXXXXXXX
+	BilledPhoneNumber string `json:"billedPhoneNumber"`
+
+	//The period the invoice is for, e.g. "August 2021"
+	BilledPeriod string `json:"billedPeriod"`
+
+	//A descriptive name of the invoice, e.g. "School Fees"
+	InvoiceName string `json:"invoiceName"`
+
+	//The date the invoice is due, in the format of BillManagerDateFormat
+	DueDate string `json:"dueDate"`
+
+	//The account number the customer pays the invoice to
+	AccountReference string `json:"accountReference"`
+
+	//The total amount of the invoice. Only whole numbers are supported
+	Amount string `json:"amount"`
+
+	//Optional line items of the invoice
+	InvoiceItems []BillManagerInvoiceItem `json:"invoiceItems,omitempty"`
+}
+
+// RequestBillManagerBulkInvoice sends several invoices in one request
+type RequestBillManagerBulkInvoice []RequestBillManagerInvoice
+
+// RequestBillManagerCancelInvoice cancels an invoice that has not been paid
+type RequestBillManagerCancelInvoice struct {
+	//The ExternalReference of the invoice
+	ExternalReference string `json:"externalReference"`
+}
+
+// RequestBillManagerCancelBulkInvoice cancels several invoices in one request
+type RequestBillManagerCancelBulkInvoice []RequestBillManagerCancelInvoice
+
+// RequestBillManagerReconciliation acknowledges an invoice payment received through
+// a WebhookRequestBillManagerPayment, and sends the customer an e-receipt
+type RequestBillManagerReconciliation struct {
+	//The date of the payment, in the format of BillManagerDateFormat
+	PaymentDate string `json:"paymentDate"`
+
+	//The amount paid
+	PaidAmount string `json:"paidAmount"`
+
+	//The account number of the invoice paid
+	AccountReference string `json:"accountReference"`
+
+	//The M-PESA receipt of the payment
+	TransactionID string `json:"transactionId"`
+
+	//The phone number of the customer that paid
+	PhoneNumber string `json:"phoneNumber"`
+
+	//The full name of the customer that paid
+	FullName string `json:"fullName"`
+
+	//The InvoiceName of the invoice paid
+	InvoiceName string `json:"invoiceName"`
+
+	//The ExternalReference of the invoice paid
+	ExternalReference string `json:"externalReference"`
+}
+
 // RESPONSE MODELS
 
 type ResponseAuthorization struct {
@@ -531,6 +923,17 @@ type ResponseDefault struct {
 
 type ResponseB2C ResponseDefault
 type ResponseB2B ResponseDefault
+type ResponseB2CTopUp ResponseDefault
+type ResponseB2Pochi ResponseDefault
+type ResponseTaxRemittance ResponseDefault
+type ResponseB2BExpressCheckout struct {
+	//"0" means the USSD prompt was sent to the merchant
+	Code string `json:"code"`
+
+	//This is the description of the request submission status
+	Status string `json:"status"`
+}
+
 type ResponseBalance = ResponseDefault
 type ResponseTransactionStatus = ResponseDefault
 type ResponseReversal ResponseDefault
@@ -594,6 +997,216 @@ type ResponseOrgInfoQuery struct {
 	ChargeProfileID       string `json:"ChargeProfileID"`
 }
 
+type ResponseDynamicQR struct {
+	//"00" means the QR code was generated
+	ResponseCode string `json:"ResponseCode"`
+
+	//This is a global unique identifier of the request
+	RequestID string `json:"RequestID"`
+
+	ResponseDescription string `json:"ResponseDescription"`
+
+	//The QR code image as a base64 encoded PNG, use Image or WritePNG to read it
+	QRCode string `json:"QRCode"`
+}
+
+// pngSignature is the header of every PNG image
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Image returns the decoded QR code PNG image
+func (r ResponseDynamicQR) Image() ([]byte, error) {
+	image, err := base64.StdEncoding.DecodeString(r.QRCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQRCode, err)
+	}
+	if !bytes.HasPrefix(image, pngSignature) {
+		return nil, fmt.Errorf("%w: not a png image", ErrInvalidQRCode)
+	}
+	return image, nil
+}
+
+// WritePNG writes the decoded QR code PNG image to w
+func (r ResponseDynamicQR) WritePNG(w io.Writer) error {
+	image, err := r.Image()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(image)
+	return err
+}
+
+type ResponseStandingOrder struct {
+	ResponseHeader struct {
+		//This is a global unique identifier of the request
+		ResponseRefID string `json:"responseRefID"`
+
+		//"200" means the request was accepted for processing
+		ResponseCode        string `json:"responseCode"`
+		ResponseDescription string `json:"responseDescription"`
+		ResultDesc          string `json:"ResultDesc"`
+	} `json:"ResponseHeader"`
+	ResponseBody struct {
+		ResponseCode        string `json:"responseCode"`
+		ResponseDescription string `json:"responseDescription"`
+	} `json:"ResponseBody"`
+}
+
+// ResponseBillManager is the response of Bill Manager operations. A ResCode of "200"
+// means the request was successful.
+type ResponseBillManager struct {
+	//A description of the status of the operation
+	StatusMessage string `json:"Status_Message"`
+
+	ResMsg  string `json:"resmsg"`
+	ResCode string `json:"rescode"`
+}
+
+type ResponseBillManagerOptIn struct {
+	//The key identifying the organization's Bill Manager account
+	AppKey string `json:"app_key"`
+
+	ResMsg  string `json:"resmsg"`
+	ResCode string `json:"rescode"`
+}
+
+type ResponseBillManagerInvoice = ResponseBillManager
+type ResponseBillManagerCancelInvoice = ResponseBillManager
+type ResponseBillManagerReconciliation = ResponseBillManager
+
+type ResponsePullRegister struct {
+	//This is a global unique identifier for the registration request
+	ResponseRefID string `json:"ResponseRefID"`
+
+	//"1001" means the shortcode was registered or is already registered
+	ResponseStatus string `json:"ResponseStatus"`
+
+	ShortCode string `json:"ShortCode"`
+
+	//This is the description of the registration status
+	ResponseDescription string `json:"ResponseDescription"`
+}
+
+// UnmarshalJSON decodes the response, including the "Response Status" and "Response Description"
+// keys with spaces that some environments return
+func (r *ResponsePullRegister) UnmarshalJSON(b []byte) error {
+	type response ResponsePullRegister
+	var v struct {
+		response
+		Status      string `json:"Response Status"`
+		Description string `json:"Response Description"`
+	}
+	if err := jsoniter.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*r = ResponsePullRegister(v.response)
+	if r.ResponseStatus == "" {
+		r.ResponseStatus = v.Status
+	}
+	if r.ResponseDescription == "" {
+		r.ResponseDescription = v.Description
+	}
+	return nil
+}
+
+type ResponsePullQuery struct {
+	//This is a global unique identifier for the query request
+	ResponseRefID string `json:"ResponseRefID"`
+
+	//"1000" means a successful query
+	ResponseCode string `json:"ResponseCode"`
+
+	ResponseMessage string `json:"ResponseMessage"`
+
+	//The transactions of the period, use Transactions to read them
+	Response [][]PullTransaction `json:"Response"`
+}
+
+// Transactions returns the transactions of the query response
+func (r ResponsePullQuery) Transactions() []PullTransaction {
+	var transactions []PullTransaction
+	for _, page := range r.Response {
+		transactions = append(transactions, page...)
+	}
+	return transactions
+}
+
+// PullTransaction is a transaction returned by the pull transactions api. Its fields are
+// named as those of WebhookRequestDirectC2B, so that pulled transactions can be processed
+// as missed c2b confirmations.
+type PullTransaction struct {
+	//This is the unique M-Pesa transaction ID
+	TransID string `json:"transactionId"`
+
+	//This is the time of the transaction in the format YYYY-MM-DDTHH:MM:SSZ
+	TransTime string `json:"trxDate"`
+
+	//This is the amount transacted
+	TransAmount string `json:"amount"`
+
+	//The transaction type e.g. "c2b-pay-bill-debit" or "c2b-buy-goods-debit"
+	TransactionType string `json:"transactiontype"`
+
+	//This is the account number for which the customer made the payment
+	BillRefNumber string `json:"billreference"`
+
+	//This is the mobile number of the customer making the payment
+	MSISDN string `json:"msisdn"`
+
+	//The account the transaction was made from e.g. "UTILITY"
+	Sender string `json:"sender"`
+
+	//The name of the organization that received the transaction
+	OrganizationName string `json:"organizationname"`
+}
+
+// UnmarshalJSON decodes the transaction. The msisdn and amount are returned as either
+// json numbers or strings.
+func (t *PullTransaction) UnmarshalJSON(b []byte) error {
+	type transaction PullTransaction
+	var v struct {
+		transaction
+		MSISDN      jsoniter.Number `json:"msisdn"`
+		TransAmount jsoniter.Number `json:"amount"`
+	}
+	if err := jsoniter.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*t = PullTransaction(v.transaction)
+	t.MSISDN, t.TransAmount = string(v.MSISDN), string(v.TransAmount)
+	return nil
+}
+
+// Time returns TransTime as time.Time
+func (t PullTransaction) Time() (time.Time, error) {
+	return time.Parse(time.RFC3339, t.TransTime)
+}
+
+// Confirmation returns the transaction as a c2b confirmation, for processing pulled
+// transactions with the handlers of missed confirmations. TransTime is converted to the
+// YYYYMMDDHHMMSS format of confirmations when it can be parsed.
+func (t PullTransaction) Confirmation() WebhookRequestC2BConfirmation {
+	confirmation := WebhookRequestC2BConfirmation{
+		TransactionType: t.TransactionType,
+		TransID:         t.TransID,
+		TransTime:       t.TransTime,
+		TransAmount:     t.TransAmount,
+		BillRefNumber:   t.BillRefNumber,
+		MSISDN:          t.MSISDN,
+	}
+	switch t.TransactionType {
+	case "c2b-pay-bill-debit":
+		confirmation.TransactionType = "Pay Bill"
+	case "c2b-buy-goods-debit":
+		confirmation.TransactionType = "Buy Goods"
+	}
+	if at, err := t.Time(); err == nil {
+		confirmation.TransTime = at.Format(timeFormat)
+	}
+	return confirmation
+}
+
 // WEBHOOK REQUEST MODELS
 
 type WebhookRequestDirectC2B struct {
@@ -824,6 +1437,98 @@ type WebhookRequestB2B struct {
 	} `json:"Result"`
 }
 
+// WebhookRequestB2CTopUp is the result of a b2c account top-up, which is a b2b result
+type WebhookRequestB2CTopUp = WebhookRequestB2B
+
+// WebhookRequestB2Pochi is the result of a b2pochi payment, which is a b2c result
+type WebhookRequestB2Pochi = WebhookRequestB2C
+
+// WebhookRequestTaxRemittance is the result of a tax remittance, which is a b2b result
+type WebhookRequestTaxRemittance = WebhookRequestB2B
+
+// WebhookRequestB2BExpressCheckout is the result of a b2b express checkout request
+//
+// # Example results
+//
+// SUCCESSFUL PAYMENT
+//
+//	{
+//	 "resultCode": "0",
+//	 "resultDesc": "The service request is processed successfully.",
+//	 "amount": "71.0",
+//	 "requestId": "404e1aec-19e0-4ce3-973d-bd92e94c8021",
+//	 "resultType": "0",
+//	 "conversationID": "AG_20230426_2010434680d9f5a73766",
+//	 "transactionId": "RDQ01NFT1Q",
+//	 "status": "SUCCESS"
+//	}
+//
+// CANCELLED PAYMENT
+//
+//	{
+//	 "resultCode": "4001",
+//	 "resultDesc": "User cancelled transaction",
+//	 "requestId": "c2a9ba32-9e11-4b90-892c-7bc54944609a",
+//	 "amount": "71.0",
+//	 "paymentReference": "MAndbubry3hi"
+//	}
+type WebhookRequestB2BExpressCheckout struct {
+	//"0" means success, and any other code means the payment failed or was cancelled
+	ResultCode string `json:"resultCode"`
+	ResultDesc string `json:"resultDesc"`
+	ResultType string `json:"resultType"`
+
+	//The amount of the payment
+	Amount string `json:"amount"`
+
+	//This is the RequestRefID of the request
+	RequestID string `json:"requestId"`
+
+	//This is a global unique identifier of the payment returned by M-PESA
+	ConversationID string `json:"conversationID"`
+
+	//This is a unique M-PESA transaction ID of the payment
+	TransactionID string `json:"transactionId"`
+
+	//"SUCCESS" for successful payments
+	Status string `json:"status"`
+
+	//The paymentRef of the request, sent for failed payments
+	PaymentReference string `json:"paymentReference"`
+}
+
+// B2B returns the result as a b2b result, with RequestID as the OriginatorConversationID
+// and Amount as the "Amount" result parameter, so that b2b result handlers can process it
+func (w WebhookRequestB2BExpressCheckout) B2B() WebhookRequestB2B {
+	var b2b WebhookRequestB2B
+	b2b.Result.ResultDesc = w.ResultDesc
+	b2b.Result.OriginatorConversationID = w.RequestID
+	b2b.Result.ConversationID = w.ConversationID
+	b2b.Result.TransactionID = w.TransactionID
+	if code, err := strconv.Atoi(w.ResultCode); err == nil {
+		b2b.Result.ResultCode = ResultCode(code)
+	} else {
+		b2b.Result.ResultCode = ResultCodeInternalError
+	}
+	if resultType, err := strconv.Atoi(w.ResultType); err == nil {
+		b2b.Result.ResultType = resultType
+	}
+
+	if w.Amount != "" {
+		b2b.Result.ResultParameters = &struct {
+			ResultParameter []struct {
+				Key   string      `json:"Key"`
+				Value interface{} `json:"Value,omitempty"`
+			} `json:"ResultParameter"`
+		}{}
+		b2b.Result.ResultParameters.ResultParameter = append(b2b.Result.ResultParameters.ResultParameter, struct {
+			Key   string      `json:"Key"`
+			Value interface{} `json:"Value,omitempty"`
+		}{Key: "Amount", Value: w.Amount})
+	}
+	return b2b
+}
+
 // WebhookRequestBalance model represent result after fetching shortcode balance
 //
 // For B2C shortcodes
@@ -833,8 +1538,106 @@ type WebhookRequestB2B struct {
 // Charges Paid - This account deducts charges incurred depending on the business tariff you are in
 type WebhookRequestBalance WebhookRequestDefault
 
+// WebhookRequestStandingOrder is the result of creating a standing order
+//
+// # Example results
+//
+//	{
+//	 "ResponseHeader": {
+//	   "responseRefID": "0acb2a5a-ba0c-4d8e-a56a-2b0c1c1f2e3d",
+//	   "requestRefID": "c8a9e2d5-b5a2-4d41-9d3e-0a3c1b7b6e1f",
+//	   "responseCode": "0",
+//	   "responseDescription": "The service request is processed successfully."
+//	 },
+//	 "ResponseBody": {
+//	   "ResponseData": [
+//	     {"Name": "TransactionID", "Value": "SC8F2IQMH5"},
+//	     {"Name": "responseCode", "Value": "0"},
+//	     {"Name": "Status", "Value": "OKAY"},
+//	     {"Name": "Msisdn", "Value": "254******867"}
+//	   ]
+//	 }
+//	}
+type WebhookRequestStandingOrder struct {
+	ResponseHeader struct {
+		ResponseRefID string `json:"responseRefID"`
+		RequestRefID  string `json:"requestRefID"`
+
+		//"0" means the standing order was created, any other code means it failed
+		ResponseCode        string `json:"responseCode"`
+		ResponseDescription string `json:"responseDescription"`
+	} `json:"ResponseHeader"`
+	ResponseBody struct {
+		//Possible values for Name are "TransactionID", "responseCode", "Status" and "Msisdn"
+		ResponseData []struct {
+			Name  string `json:"Name"`
+			Value string `json:"Value"`
+		} `json:"ResponseData"`
+	} `json:"ResponseBody"`
+}
+
+// Value returns the value of the ResponseData item with name
+func (w WebhookRequestStandingOrder) Value(name string) (string, bool) {
+	for _, item := range w.ResponseBody.ResponseData {
+		if item.Name == name {
+			return item.Value, true
+		}
+	}
+	return "", false
+}
+
+// Success reports whether the standing order was created
+func (w WebhookRequestStandingOrder) Success() bool {
+	return w.ResponseHeader.ResponseCode == "0"
+}
+
+// TransactionID returns the "TransactionID" item of the ResponseData
+func (w WebhookRequestStandingOrder) TransactionID() string {
+	id, _ := w.Value("TransactionID")
+	return id
+}
+
+// WebhookRequestBillManagerPayment is a payment notification of an invoice sent to the
+// CallbackURL of RequestBillManagerOptIn
+//
+// # Example
+//
+//	{
+//	 "transactionId": "RJB53MYR1N",
+//	 "paidAmount": "5000",
+//	 "msisdn": "254710119383",
+//	 "dateCreated": "2019-09-15",
+//	 "accountReference": "LGHJIO789",
+//	 "shortCode": "718003"
+//	}
+type WebhookRequestBillManagerPayment struct {
+	//The M-PESA receipt of the payment
+	TransactionID string `json:"transactionId"`
+
+	//The amount paid
+	PaidAmount string `json:"paidAmount"`
+
+	//The phone number of the customer that paid
+	MSISDN string `json:"msisdn"`
+
+	//The date of the payment, in the format of BillManagerDateFormat
+	DateCreated string `json:"dateCreated"`
+
+	//The account number of the invoice paid
+	AccountReference string `json:"accountReference"`
+
+	//The organization's shortcode that received the payment
+	ShortCode string `json:"shortCode"`
+}
+
 // RESPONSE MODELS TO CALLBACK REQUESTS
 
+// WebhookResponseBillManager acknowledges a WebhookRequestBillManagerPayment
+type WebhookResponseBillManager struct {
+	ResMsg  string `json:"resmsg"`
+	ResCode string `json:"rescode"`
+}
+
 type WebhookResponseValidation struct {
 	//A code indicating whether to complete the transaction. 0(Zero) always means complete.
 	//Other values mean canceling the transaction, which also determines the customer notification SMS type
@@ -847,3 +1650,271 @@ type WebhookResponseValidation struct {
 	//If a value is set, it would be passed back in a confirmation callback
 	ThirdPartyTransID *string `json:"ThirdPartyTransID,omitempty"`
 }
+
+// MONEY ACCESSORS
+
+// parseResultAmount converts an amount value from a webhook result parameter, which
+// is decoded as either a JSON number or a string, into money.Money
+func parseResultAmount(value interface{}) (money.Money, error) {
+	switch v := value.(type) {
+	case float64:
+		return money.FromFloat(v, money.KES)
+	case string:
+		return money.Parse(v, money.KES)
+	default:
+		return money.Money{}, fmt.Errorf("%w: %v", money.ErrInvalidAmount, value)
+	}
+}
+
+// formatAmount converts m into the whole shillings string format expected by daraja
+func formatAmount(m money.Money) (string, error) {
+	if m.Currency() != money.KES {
+		return "", fmt.Errorf("%w: %s", money.ErrCurrencyMismatch, m.Currency())
+	}
+	text, err := money.Whole(m).MarshalText()
+	return string(text), err
+}
+
+// Money returns Amount as money.Money
+func (r RequestC2BExpress) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestC2BExpress) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestReversal) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestReversal) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestB2C) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestB2C) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestB2B) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestB2B) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestB2CTopUp) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestB2CTopUp) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestB2Pochi) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestB2Pochi) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestTaxRemittance) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestTaxRemittance) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestStandingOrder) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestStandingOrder) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestBillManagerInvoice) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestBillManagerInvoice) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestB2BExpressCheckout) Money() (money.Money, error) {
+	return money.ParseUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestB2BExpressCheckout) SetMoney(m money.Money) (err error) {
+	r.Amount, err = formatAmount(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r WebhookRequestB2BExpressCheckout) Money() (money.Money, error) {
+	return money.Parse(r.Amount, money.KES)
+}
+
+// Money returns Amount as money.Money
+func (r RequestDynamicQR) Money() money.Money {
+	return money.FromUnits(r.Amount, money.KES)
+}
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestDynamicQR) SetMoney(m money.Money) error {
+	if m.Currency() != money.KES {
+		return fmt.Errorf("%w: %s", money.ErrCurrencyMismatch, m.Currency())
+	}
+	units, err := m.Units()
+	if err != nil {
+		return err
+	}
+	r.Amount = units
+	return nil
+}
+
+// Money returns TransAmount as money.Money
+func (r WebhookRequestDirectC2B) Money() (money.Money, error) {
+	return money.Parse(r.TransAmount, money.KES)
+}
+
+// Money returns PaidAmount as money.Money
+func (r WebhookRequestBillManagerPayment) Money() (money.Money, error) {
+	return money.Parse(r.PaidAmount, money.KES)
+}
+
+// Money returns TransAmount as money.Money
+func (t PullTransaction) Money() (money.Money, error) {
+	return money.Parse(t.TransAmount, money.KES)
+}
+
+// Money returns the "Amount" item of the callback metadata as money.Money.
+// The metadata is only sent for successful transactions.
+func (r WebhookRequestC2BExpress) Money() (money.Money, error) {
+	if r.Body.StkCallback.CallbackMetadata != nil {
+		for _, item := range r.Body.StkCallback.CallbackMetadata.Item {
+			if item.Name == "Amount" {
+				return parseResultAmount(item.Value)
+			}
+		}
+	}
+	return money.Money{}, fmt.Errorf("%w: missing Amount", money.ErrInvalidAmount)
+}
+
+// resultMoney returns the value of the result parameter with the given key as money.Money
+func resultMoney(r WebhookRequestDefault, key string) (money.Money, error) {
+	if r.Result.ResultParameters != nil {
+		for _, param := range r.Result.ResultParameters.ResultParameter {
+			if param.Key == key {
+				return parseResultAmount(param.Value)
+			}
+		}
+	}
+	return money.Money{}, fmt.Errorf("%w: missing %s", money.ErrInvalidAmount, key)
+}
+
+// Money returns the "TransactionAmount" result parameter as money.Money
+func (r WebhookRequestB2C) Money() (money.Money, error) {
+	return resultMoney(WebhookRequestDefault(r), "TransactionAmount")
+}
+
+// Money returns the "Amount" result parameter as money.Money
+func (r WebhookRequestC2BReversal) Money() (money.Money, error) {
+	return resultMoney(WebhookRequestDefault(r), "Amount")
+}
+
+// Money returns the "Amount" result parameter as money.Money
+func (r WebhookRequestTransactionStatus) Money() (money.Money, error) {
+	return resultMoney(WebhookRequestDefault(r), "Amount")
+}
+
+// Money returns the "Amount" result parameter as money.Money
+func (r WebhookRequestB2B) Money() (money.Money, error) {
+	// the result parameters are the same as other webhooks, only the reference data differs
+	var result WebhookRequestDefault
+	result.Result.ResultParameters = r.Result.ResultParameters
+	return resultMoney(result, "Amount")
+}
+
+// AccountBalance is the balance of one of the accounts of a shortcode
+type AccountBalance struct {
+	// Account is the name of the account e.g. "Working Account"
+	Account string
+	// Available is the available balance of the account
+	Available money.Money
+}
+
+// ParseAccountBalance parses the AccountBalance result parameter of a balance query, the
+// balances of the accounts of a shortcode separated by "&" e.g.
+// "Working Account|KES|700000.00|700000.00|0.00|0.00&Utility Account|KES|228037.00|228037.00|0.00|0.00".
+// The fields of each balance are the account name, currency and available balance followed
+// by the current, reserved and uncleared balances.
+func ParseAccountBalance(value string) ([]AccountBalance, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidAccountBalance)
+	}
+
+	var balances []AccountBalance
+	for _, balance := range strings.Split(value, "&") {
+		fields := strings.Split(strings.TrimSpace(balance), "|")
+		if len(fields) < 3 || fields[0] == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidAccountBalance, balance)
+		}
+
+		available, err := money.Parse(fields[2], money.Currency(fields[1]))
+		if err != nil {
+			return nil, errors.Join(ErrInvalidAccountBalance, err)
+		}
+		balances = append(balances, AccountBalance{Account: fields[0], Available: available})
+	}
+	return balances, nil
+}
+
+// Balances returns the "AccountBalance" result parameter parsed with ParseAccountBalance
+func (r WebhookRequestBalance) Balances() ([]AccountBalance, error) {
+	if r.Result.ResultParameters != nil {
+		for _, param := range r.Result.ResultParameters.ResultParameter {
+			if param.Key == "AccountBalance" {
+				value, _ := param.Value.(string)
+				return ParseAccountBalance(value)
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: missing AccountBalance", ErrInvalidAccountBalance)
+}
@@ -2,12 +2,15 @@ package daraja
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"strings"
 	"testing"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/money"
 )
 
 func TestResponseCode_MarshalJSON(t *testing.T) {
@@ -126,3 +129,153 @@ func TestToResponseCode(t *testing.T) {
 	result := ToResponseCode(InvalidGrantType.String())
 	assert.Equal(t, InvalidGrantType, result)
 }
+
+func TestRequestB2C_SetMoney(t *testing.T) {
+	var req RequestB2C
+
+	err := req.SetMoney(money.FromUnits(1540, money.KES))
+	assert.NoError(t, err)
+	assert.Equal(t, "1540", req.Amount)
+
+	m, err := req.Money()
+	assert.NoError(t, err)
+	assert.Equal(t, money.FromUnits(1540, money.KES), m)
+
+	// daraja only accepts whole shillings
+	err = req.SetMoney(money.New(10050, money.KES))
+	assert.ErrorIs(t, err, money.ErrFractionalAmount)
+
+	err = req.SetMoney(money.FromUnits(10, money.USD))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestWebhookRequestB2C_Money(t *testing.T) {
+	body := `{"Result":{"ResultType":0,"ResultCode":0,"ResultDesc":"The service request is processed successfully.",
+"OriginatorConversationID":"10571-7910404-1","ConversationID":"AG_20191219_00004e48cf7e3533f581",
+"TransactionID":"NLJ41HAY6Q","ResultParameters":{"ResultParameter":[
+{"Key":"TransactionAmount","Value":10.01},{"Key":"TransactionReceipt","Value":"NLJ41HAY6Q"}]}}}`
+
+	var webhook WebhookRequestB2C
+	assert.NoError(t, jsoniter.Unmarshal([]byte(body), &webhook))
+
+	m, err := webhook.Money()
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(1001, money.KES), m)
+
+	// missing parameter
+	_, err = WebhookRequestB2C{}.Money()
+	assert.ErrorIs(t, err, money.ErrInvalidAmount)
+}
+
+func TestWebhookRequestB2BExpressCheckout_B2B(t *testing.T) {
+	t.Run("test that a successful result is converted", func(t *testing.T) {
+		body := `{"resultCode":"0","resultDesc":"The service request is processed successfully.","amount":"71.0",
+"requestId":"404e1aec-19e0-4ce3-973d-bd92e94c8021","resultType":"0","conversationID":"AG_20230426_2010434680d9f5a73766",
+"transactionId":"RDQ01NFT1Q","status":"SUCCESS"}`
+
+		var webhook WebhookRequestB2BExpressCheckout
+		assert.NoError(t, jsoniter.Unmarshal([]byte(body), &webhook))
+
+		b2b := webhook.B2B()
+		assert.Equal(t, ResultCodeSuccess, b2b.Result.ResultCode)
+		assert.Equal(t, "404e1aec-19e0-4ce3-973d-bd92e94c8021", b2b.Result.OriginatorConversationID)
+		assert.Equal(t, "AG_20230426_2010434680d9f5a73766", b2b.Result.ConversationID)
+		assert.Equal(t, "RDQ01NFT1Q", b2b.Result.TransactionID)
+
+		m, err := b2b.Money()
+		assert.NoError(t, err)
+		assert.Equal(t, money.FromUnits(71, money.KES), m)
+	})
+
+	t.Run("test that a cancelled result is converted", func(t *testing.T) {
+		body := `{"resultCode":"4001","resultDesc":"User cancelled transaction",
+"requestId":"c2a9ba32-9e11-4b90-892c-7bc54944609a","amount":"71.0","paymentReference":"MAndbubry3hi"}`
+
+		var webhook WebhookRequestB2BExpressCheckout
+		assert.NoError(t, jsoniter.Unmarshal([]byte(body), &webhook))
+
+		b2b := webhook.B2B()
+		assert.Equal(t, ResultCode(4001), b2b.Result.ResultCode)
+		assert.Equal(t, "User cancelled transaction", b2b.Result.ResultDesc)
+		assert.Empty(t, b2b.Result.TransactionID)
+	})
+}
+
+func TestResponseDynamicQR_Image(t *testing.T) {
+	_, err := ResponseDynamicQR{QRCode: "not base64"}.Image()
+	assert.ErrorIs(t, err, ErrInvalidQRCode)
+
+	_, err = ResponseDynamicQR{QRCode: base64.StdEncoding.EncodeToString([]byte("GIF89a"))}.Image()
+	assert.ErrorIs(t, err, ErrInvalidQRCode)
+
+	var buf bytes.Buffer
+	err = ResponseDynamicQR{}.WritePNG(&buf)
+	assert.ErrorIs(t, err, ErrInvalidQRCode)
+	assert.Zero(t, buf.Len())
+}
+
+func TestRequestDynamicQR_SetMoney(t *testing.T) {
+	var req RequestDynamicQR
+	assert.NoError(t, req.SetMoney(money.FromUnits(250, money.KES)))
+	assert.Equal(t, int64(250), req.Amount)
+	assert.Equal(t, money.FromUnits(250, money.KES), req.Money())
+
+	assert.ErrorIs(t, req.SetMoney(money.New(25050, money.KES)), money.ErrFractionalAmount)
+}
+
+func TestWebhookRequestB2CTopUp_Money(t *testing.T) {
+	body := `{"Result":{"ResultType":0,"ResultCode":0,"ResultDesc":"The service request is processed successfully",
+"OriginatorConversationID":"626f6ddf-ab37-4650-b882-b1de92ec9aa4","ConversationID":"12345677dfdf89099B3",
+"TransactionID":"QKA81LK5CY","ResultParameters":{"ResultParameter":[{"Key":"DebitAccountBalance","Value":"{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}"},
+{"Key":"Amount","Value":"190.00"},{"Key":"Currency","Value":"KES"}]}}}`
+
+	var webhook WebhookRequestB2CTopUp
+	assert.NoError(t, jsoniter.Unmarshal([]byte(body), &webhook))
+
+	m, err := webhook.Money()
+	assert.NoError(t, err)
+	assert.Equal(t, money.FromUnits(190, money.KES), m)
+}
+
+func TestWebhookRequestB2Pochi_Money(t *testing.T) {
+	body := `{"Result":{"ResultType":0,"ResultCode":0,"ResultDesc":"The service request is processed successfully.",
+"OriginatorConversationID":"10571-7910404-1","ConversationID":"AG_20191219_00004e48cf7e3533f581","TransactionID":"NLJ41HAY6Q",
+"ResultParameters":{"ResultParameter":[{"Key":"TransactionAmount","Value":10},{"Key":"TransactionReceipt","Value":"NLJ41HAY6Q"}]}}}`
+
+	var webhook WebhookRequestB2Pochi
+	assert.NoError(t, jsoniter.Unmarshal([]byte(body), &webhook))
+
+	m, err := webhook.Money()
+	assert.NoError(t, err)
+	assert.Equal(t, money.FromUnits(10, money.KES), m)
+}
+
+func TestWebhookRequestBalance_Balances(t *testing.T) {
+	body := `{"Result":{"ResultType":0,"ResultCode":0,"ResultDesc":"The service request is processed successfully.",
+"OriginatorConversationID":"16917-22577599-3","ConversationID":"AG_20200206_00005e091a8ec6b9eac5","TransactionID":"OA90000000",
+"ResultParameters":{"ResultParameter":[{"Key":"AccountBalance","Value":"Working Account|KES|700000.00|700000.00|0.00|0.00&Float Account|KES|0.00|0.00|0.00|0.00&Utility Account|KES|228037.00|228037.00|0.00|0.00&Charges Paid Account|KES|-1540.00|-1540.00|0.00|0.00&Organization Settlement Account|KES|0.00|0.00|0.00|0.00"},
+{"Key":"BOCompletedTime","Value":20200109125710}]}}}`
+
+	var webhook WebhookRequestBalance
+	assert.NoError(t, jsoniter.Unmarshal([]byte(body), &webhook))
+
+	balances, err := webhook.Balances()
+	assert.NoError(t, err)
+	assert.Equal(t, []AccountBalance{
+		{Account: "Working Account", Available: money.FromUnits(700000, money.KES)},
+		{Account: "Float Account", Available: money.New(0, money.KES)},
+		{Account: "Utility Account", Available: money.FromUnits(228037, money.KES)},
+		{Account: "Charges Paid Account", Available: money.FromUnits(-1540, money.KES)},
+		{Account: "Organization Settlement Account", Available: money.New(0, money.KES)},
+	}, balances)
+
+	// missing parameter
+	_, err = WebhookRequestBalance{}.Balances()
+	assert.ErrorIs(t, err, ErrInvalidAccountBalance)
+
+	// malformed balances
+	for _, value := range []string{"", "Working Account|KES", "Working Account|KES|abc|0.00|0.00|0.00"} {
+		_, err = ParseAccountBalance(value)
+		assert.ErrorIs(t, err, ErrInvalidAccountBalance, value)
+	}
+}
@@ -0,0 +1,560 @@
+package daraja
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/phone"
+)
+
+var (
+	ErrRequiredField       = errors.New("is required")
+	ErrInvalidAmount       = errors.New("must be a positive whole number")
+	ErrInvalidMSISDN       = errors.New("must be a valid msisdn in the format 2547XXXXXXXX or 2541XXXXXXXX")
+	ErrInvalidURL          = errors.New("must be an absolute http(s) url")
+	ErrInvalidCommand      = errors.New("invalid command id")
+	ErrInvalidIdentifier   = errors.New("invalid identifier type for command id")
+	ErrInvalidKRAShortCode = errors.New("must be the KRA shortcode " + KRAShortCode)
+	ErrInvalidPRN          = errors.New("must be a KRA payment registration number")
+	ErrInvalidEmail        = errors.New("must be a valid email address")
+	ErrInvalidPhoneNumber  = errors.New("must be a valid phone number")
+)
+
+// FieldError describes a validation failure of a single field in a request model
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	reAmount = regexp.MustCompile(`^[1-9][0-9]*$`)
+	reOffset = regexp.MustCompile(`^[0-9]+$`)
+	// KRA payment registration numbers are numeric
+	rePRN = regexp.MustCompile(`^[0-9]{1,20}$`)
+)
+
+// fieldErrors accumulates errors of a request model's fields
+type fieldErrors []error
+
+func (fe *fieldErrors) add(field string, err error) {
+	*fe = append(*fe, &FieldError{Field: field, Err: err})
+}
+
+func (fe *fieldErrors) required(field, value string) bool {
+	if value == "" {
+		fe.add(field, ErrRequiredField)
+		return false
+	}
+	return true
+}
+
+func (fe *fieldErrors) amount(field, value string) {
+	if fe.required(field, value) && !reAmount.MatchString(value) {
+		fe.add(field, ErrInvalidAmount)
+	}
+}
+
+func (fe *fieldErrors) msisdn(field, value string) {
+	if !fe.required(field, value) {
+		return
+	}
+	// daraja only accepts numbers in the msisdn format
+	if n, err := phone.Parse(value); err != nil || n.For(phone.ProviderDaraja) != value {
+		fe.add(field, ErrInvalidMSISDN)
+	}
+}
+
+// phoneNumber checks that value is a phone number in any format
+func (fe *fieldErrors) phoneNumber(field, value string) {
+	if !fe.required(field, value) {
+		return
+	}
+	if _, err := phone.Parse(value); err != nil {
+		fe.add(field, ErrInvalidPhoneNumber)
+	}
+}
+
+func (fe *fieldErrors) url(field, value string) {
+	if !fe.required(field, value) {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || !u.IsAbs() || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		fe.add(field, ErrInvalidURL)
+	}
+}
+
+func (fe *fieldErrors) maxLength(field, value string, n int) {
+	if utf8.RuneCountInString(value) > n {
+		fe.add(field, fmt.Errorf("must be at most %d characters", n))
+	}
+}
+
+func (fe *fieldErrors) command(field string, value Command, allowed ...Command) bool {
+	if !fe.required(field, string(value)) {
+		return false
+	}
+	if !slices.Contains(allowed, value) {
+		fe.add(field, fmt.Errorf("%w: %s", ErrInvalidCommand, value))
+		return false
+	}
+	return true
+}
+
+// identifier checks that value is one of allowed, context is the command or transaction
+// type of the request that the error is reported for
+func (fe *fieldErrors) identifier(field string, value IdentifierType, context string, allowed ...IdentifierType) {
+	if !fe.required(field, string(value)) {
+		return
+	}
+	if !slices.Contains(allowed, value) {
+		fe.add(field, fmt.Errorf("%w %s: %s", ErrInvalidIdentifier, context, value))
+	}
+}
+
+// date parses value in the given layout, the returned time is zero if value is invalid
+func (fe *fieldErrors) date(field, value, layout string) time.Time {
+	if !fe.required(field, value) {
+		return time.Time{}
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		fe.add(field, fmt.Errorf("must be in the format %s", layout))
+	}
+	return t
+}
+
+// nest adds the field errors of a nested model with their fields prefixed
+func (fe *fieldErrors) nest(prefix string, err error) {
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var field *FieldError
+		if errors.As(err, &field) {
+			fe.add(prefix+field.Field, field.Err)
+		}
+	}
+}
+
+func (fe *fieldErrors) err() error {
+	return errors.Join(*fe...)
+}
+
+// Validate checks that required fields are present, Amount is a whole number, PartyA and
+// PhoneNumber are valid msisdns and AccountReference and TransactionDesc are within limits
+func (r RequestC2BExpress) Validate() error {
+	var errs fieldErrors
+	errs.required("BusinessShortCode", r.BusinessShortCode)
+	errs.required("Password", r.Password)
+	if r.Timestamp.t.IsZero() {
+		errs.add("Timestamp", ErrRequiredField)
+	}
+	if errs.required("TransactionType", string(r.TransactionType)) &&
+		r.TransactionType != TypeCustomerPayBillOnline && r.TransactionType != TypeCustomerBuyGoodsOnline {
+		errs.add("TransactionType", fmt.Errorf("invalid transaction type: %s", r.TransactionType))
+	}
+	errs.amount("Amount", r.Amount)
+	errs.msisdn("PartyA", r.PartyA)
+	errs.required("PartyB", r.PartyB)
+	errs.msisdn("PhoneNumber", r.PhoneNumber)
+	errs.url("CallBackURL", r.CallBackURL)
+	if r.TransactionType == TypeCustomerPayBillOnline {
+		errs.required("AccountReference", r.AccountReference)
+	}
+	errs.maxLength("AccountReference", r.AccountReference, 12)
+	errs.maxLength("TransactionDesc", r.TransactionDesc, 13)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, Amount is a whole number, PartyB
+// is a valid msisdn and CommandID is one of the b2c commands
+func (r RequestB2C) Validate() error {
+	var errs fieldErrors
+	errs.required("OriginatorConversationID", r.OriginatorConversationID)
+	errs.required("InitiatorName", r.InitiatorName)
+	errs.required("SecurityCredential", r.SecurityCredential)
+	errs.command("CommandID", r.CommandID, CommandBusinessPayment, CommandSalaryPayment, CommandPromotionPayment)
+	errs.amount("Amount", r.Amount)
+	errs.required("PartyA", r.PartyA)
+	errs.msisdn("PartyB", r.PartyB)
+	errs.required("Remarks", r.Remarks)
+	errs.maxLength("Remarks", r.Remarks, 100)
+	errs.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	errs.url("ResultURL", r.ResultURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, Amount is a whole number and the
+// identifier types are compatible with CommandID
+func (r RequestB2B) Validate() error {
+	var errs fieldErrors
+	errs.required("Initiator", r.Initiator)
+	errs.required("SecurityCredential", r.SecurityCredential)
+	if errs.command("CommandID", r.CommandID, CommandBusinessPayBill, CommandBusinessBuyGoods) {
+		errs.identifier("SenderIdentifierType", r.SenderIdentifierType, string(r.CommandID), IdentifierOrgShortCode)
+		switch r.CommandID {
+		case CommandBusinessPayBill:
+			errs.identifier("RecieverIdentifierType", r.RecieverIdentifierType, string(r.CommandID), IdentifierOrgShortCode)
+		case CommandBusinessBuyGoods:
+			errs.identifier("RecieverIdentifierType", r.RecieverIdentifierType, string(r.CommandID), IdentifierTillNumber, IdentifierOrgShortCode)
+		}
+	}
+	errs.amount("Amount", r.Amount)
+	errs.required("PartyA", r.PartyA)
+	errs.required("PartyB", r.PartyB)
+	if r.CommandID == CommandBusinessPayBill {
+		errs.required("AccountReference", r.AccountReference)
+	}
+	errs.maxLength("AccountReference", r.AccountReference, 13)
+	if r.Requester != nil {
+		errs.msisdn("Requester", *r.Requester)
+	}
+	errs.required("Remarks", r.Remarks)
+	errs.maxLength("Remarks", r.Remarks, 100)
+	errs.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	errs.url("ResultURL", r.ResultURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, Amount is a whole number and both
+// identifier types are shortcodes
+func (r RequestB2CTopUp) Validate() error {
+	var errs fieldErrors
+	errs.required("Initiator", r.Initiator)
+	errs.required("SecurityCredential", r.SecurityCredential)
+	if errs.command("CommandID", r.CommandID, CommandBusinessPayToBulk) {
+		errs.identifier("SenderIdentifierType", r.SenderIdentifierType, string(r.CommandID), IdentifierOrgShortCode)
+		errs.identifier("RecieverIdentifierType", r.RecieverIdentifierType, string(r.CommandID), IdentifierOrgShortCode)
+	}
+	errs.amount("Amount", r.Amount)
+	errs.required("PartyA", r.PartyA)
+	errs.required("PartyB", r.PartyB)
+	errs.required("AccountReference", r.AccountReference)
+	errs.maxLength("AccountReference", r.AccountReference, 13)
+	if r.Requester != nil {
+		errs.msisdn("Requester", *r.Requester)
+	}
+	errs.required("Remarks", r.Remarks)
+	errs.maxLength("Remarks", r.Remarks, 100)
+	errs.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	errs.url("ResultURL", r.ResultURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, Amount is a whole number and PartyB
+// is a valid msisdn
+func (r RequestB2Pochi) Validate() error {
+	var errs fieldErrors
+	errs.required("OriginatorConversationID", r.OriginatorConversationID)
+	errs.required("InitiatorName", r.InitiatorName)
+	errs.required("SecurityCredential", r.SecurityCredential)
+	errs.command("CommandID", r.CommandID, CommandBusinessPayToPochi)
+	errs.amount("Amount", r.Amount)
+	errs.required("PartyA", r.PartyA)
+	errs.msisdn("PartyB", r.PartyB)
+	errs.required("Remarks", r.Remarks)
+	errs.maxLength("Remarks", r.Remarks, 100)
+	errs.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	errs.url("ResultURL", r.ResultURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, Amount is a whole number, PartyB is
+// the KRA shortcode and AccountReference is a payment registration number
+func (r RequestTaxRemittance) Validate() error {
+	var errs fieldErrors
+	errs.required("Initiator", r.Initiator)
+	errs.required("SecurityCredential", r.SecurityCredential)
+	if errs.command("CommandID", r.CommandID, CommandPayTaxToKRA) {
+		errs.identifier("SenderIdentifierType", r.SenderIdentifierType, string(r.CommandID), IdentifierOrgShortCode)
+		errs.identifier("RecieverIdentifierType", r.RecieverIdentifierType, string(r.CommandID), IdentifierOrgShortCode)
+	}
+	errs.amount("Amount", r.Amount)
+	errs.required("PartyA", r.PartyA)
+	if errs.required("PartyB", r.PartyB) && r.PartyB != KRAShortCode {
+		errs.add("PartyB", ErrInvalidKRAShortCode)
+	}
+	if errs.required("AccountReference", r.AccountReference) && !rePRN.MatchString(r.AccountReference) {
+		errs.add("AccountReference", ErrInvalidPRN)
+	}
+	errs.required("Remarks", r.Remarks)
+	errs.maxLength("Remarks", r.Remarks, 100)
+	errs.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	errs.url("ResultURL", r.ResultURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, Amount is a whole number and
+// CallbackURL is a valid url
+func (r RequestB2BExpressCheckout) Validate() error {
+	var errs fieldErrors
+	errs.required("primaryShortCode", r.PrimaryShortCode)
+	errs.required("receiverShortCode", r.ReceiverShortCode)
+	errs.amount("amount", r.Amount)
+	errs.required("paymentRef", r.PaymentRef)
+	errs.url("callbackUrl", r.CallbackURL)
+	errs.required("partnerName", r.PartnerName)
+	errs.required("RequestRefID", r.RequestRefID)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, that one of TransactionID or
+// OriginatorConversationID is set and that IdentifierType is compatible with CommandID
+func (r RequestTransactionStatus) Validate() error {
+	var errs fieldErrors
+	errs.required("Initiator", r.Initiator)
+	errs.required("SecurityCredential", r.SecurityCredential)
+	if errs.command("CommandID", r.CommandID, CommandTransactionStatus) {
+		errs.identifier("IdentifierType", r.IdentifierType, string(r.CommandID), IdentifierMSISDN, IdentifierTillNumber, IdentifierOrgShortCode)
+	}
+	if (r.TransactionID == nil || *r.TransactionID == "") && (r.OriginatorConversationID == nil || *r.OriginatorConversationID == "") {
+		errs.add("TransactionID", errors.New("is required when OriginatorConversationID is not set"))
+	}
+	if r.IdentifierType == IdentifierMSISDN {
+		errs.msisdn("PartyA", r.PartyA)
+	} else {
+		errs.required("PartyA", r.PartyA)
+	}
+	errs.required("Remarks", r.Remarks)
+	errs.maxLength("Remarks", r.Remarks, 100)
+	errs.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	errs.url("ResultURL", r.ResultURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, Amount is a whole number when set and
+// that ReceiverIdentifierType is compatible with CommandID
+func (r RequestReversal) Validate() error {
+	var errs fieldErrors
+	errs.required("Initiator", r.Initiator)
+	errs.required("SecurityCredential", r.SecurityCredential)
+	if errs.command("CommandID", r.CommandID, CommandTransactionReversal) {
+		errs.identifier("RecieverIdentifierType", r.ReceiverIdentifierType, string(r.CommandID), IdentifierOrgShortCode, IdentifierOrgOperatorUsername)
+	}
+	errs.required("TransactionID", r.TransactionID)
+	if r.Amount != "" {
+		errs.amount("Amount", r.Amount)
+	}
+	errs.required("ReceiverParty", r.ReceiverParty)
+	errs.required("Remarks", r.Remarks)
+	errs.maxLength("Remarks", r.Remarks, 100)
+	errs.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	errs.url("ResultURL", r.ResultURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present and that IdentifierType is
+// compatible with CommandID
+func (r RequestBalance) Validate() error {
+	var errs fieldErrors
+	errs.required("Initiator", r.Initiator)
+	errs.required("SecurityCredential", r.SecurityCredential)
+	if errs.command("CommandID", r.CommandID, CommandAccountBalance) {
+		errs.identifier("IdentifierType", r.IdentifierType, string(r.CommandID), IdentifierTillNumber, IdentifierOrgShortCode)
+	}
+	errs.required("PartyA", r.PartyA)
+	errs.required("Remarks", r.Remarks)
+	errs.maxLength("Remarks", r.Remarks, 100)
+	errs.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	errs.url("ResultURL", r.ResultURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, RequestType is "Pull" and
+// CallBackURL is a valid url
+func (r RequestPullRegister) Validate() error {
+	var errs fieldErrors
+	errs.required("ShortCode", r.ShortCode)
+	if errs.required("RequestType", r.RequestType) && r.RequestType != "Pull" {
+		errs.add("RequestType", fmt.Errorf("invalid request type: %s", r.RequestType))
+	}
+	errs.required("NominatedNumber", r.NominatedNumber)
+	errs.url("CallBackURL", r.CallBackURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, that StartDate and EndDate are in
+// the format of PullDateFormat with StartDate before EndDate, and OffSetValue is a number
+func (r RequestPullQuery) Validate() error {
+	var errs fieldErrors
+	errs.required("ShortCode", r.ShortCode)
+
+	start := errs.date("StartDate", r.StartDate, PullDateFormat)
+	end := errs.date("EndDate", r.EndDate, PullDateFormat)
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		errs.add("EndDate", errors.New("must be after StartDate"))
+	}
+
+	if errs.required("OffSetValue", r.OffSetValue) && !reOffset.MatchString(r.OffSetValue) {
+		errs.add("OffSetValue", errors.New("must be a whole number"))
+	}
+	return errs.err()
+}
+
+// Validate checks that required fields are present, Amount is positive, TrxCode is one
+// of the QR transaction codes and Size is a number of pixels
+func (r RequestDynamicQR) Validate() error {
+	var errs fieldErrors
+	errs.required("MerchantName", r.MerchantName)
+	errs.required("RefNo", r.RefNo)
+	if r.Amount <= 0 {
+		errs.add("Amount", ErrInvalidAmount)
+	}
+	switch r.TrxCode {
+	case QRBuyGoods, QRWithdrawAgent, QRPayBill, QRSendMoney, QRSendToBusiness:
+	case "":
+		errs.add("TrxCode", ErrRequiredField)
+	default:
+		errs.add("TrxCode", fmt.Errorf("invalid transaction code: %s", r.TrxCode))
+	}
+	errs.required("CPI", r.CPI)
+	if errs.required("Size", r.Size) && !reAmount.MatchString(r.Size) {
+		errs.add("Size", errors.New("must be a number of pixels"))
+	}
+	return errs.err()
+}
+
+var frequencies = []Frequency{
+	FrequencyOneOff, FrequencyDaily, FrequencyWeekly, FrequencyMonthly,
+	FrequencyBiMonthly, FrequencyQuarterly, FrequencyHalfYearly, FrequencyYearly,
+}
+
+// Validate checks that required fields are present, the dates are in the format of
+// StandingOrderDateFormat with StartDate before EndDate, Frequency is known, PartyA is a
+// valid msisdn and ReceiverPartyIdentifierType matches TransactionType
+func (r RequestStandingOrder) Validate() error {
+	var errs fieldErrors
+	errs.required("StandingOrderName", r.StandingOrderName)
+
+	start := errs.date("StartDate", r.StartDate, StandingOrderDateFormat)
+	end := errs.date("EndDate", r.EndDate, StandingOrderDateFormat)
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		errs.add("EndDate", errors.New("must not be before StartDate"))
+	}
+
+	errs.required("BusinessShortCode", r.BusinessShortCode)
+	switch r.TransactionType {
+	case TypeStandingOrderPayBill:
+		errs.identifier("ReceiverPartyIdentifierType", r.ReceiverPartyIdentifierType, string(r.TransactionType), IdentifierOrgShortCode)
+		errs.required("AccountReference", r.AccountReference)
+	case TypeStandingOrderBuyGoods:
+		errs.identifier("ReceiverPartyIdentifierType", r.ReceiverPartyIdentifierType, string(r.TransactionType), IdentifierTillNumber)
+	case "":
+		errs.add("TransactionType", ErrRequiredField)
+	default:
+		errs.add("TransactionType", fmt.Errorf("invalid transaction type: %s", r.TransactionType))
+	}
+	errs.amount("Amount", r.Amount)
+	errs.msisdn("PartyA", r.PartyA)
+	errs.url("CallBackURL", r.CallBackURL)
+	errs.maxLength("AccountReference", r.AccountReference, 12)
+	errs.maxLength("TransactionDesc", r.TransactionDesc, 13)
+	if errs.required("Frequency", string(r.Frequency)) && !slices.Contains(frequencies, r.Frequency) {
+		errs.add("Frequency", fmt.Errorf("invalid frequency: %s", r.Frequency))
+	}
+	return errs.err()
+}
+
+// Validate checks that required fields are present, Email and CallbackURL are valid and
+// SendReminders is "0" or "1"
+func (r RequestBillManagerOptIn) Validate() error {
+	var errs fieldErrors
+	errs.required("shortcode", r.ShortCode)
+	if errs.required("email", r.Email) {
+		if _, err := mail.ParseAddress(r.Email); err != nil {
+			errs.add("email", ErrInvalidEmail)
+		}
+	}
+	errs.phoneNumber("officialContact", r.OfficialContact)
+	if r.SendReminders != "0" && r.SendReminders != "1" {
+		errs.add("sendReminders", errors.New(`must be "0" or "1"`))
+	}
+	errs.url("callbackurl", r.CallbackURL)
+	return errs.err()
+}
+
+// Validate checks that required fields are present, BilledPhoneNumber is a valid phone
+// number, DueDate is in the format of BillManagerDateFormat and amounts are whole numbers
+func (r RequestBillManagerInvoice) Validate() error {
+	var errs fieldErrors
+	errs.required("externalReference", r.ExternalReference)
+	errs.required("billedFullName", r.BilledFullName)
+	errs.phoneNumber("billedPhoneNumber", r.BilledPhoneNumber)
+	errs.required("billedPeriod", r.BilledPeriod)
+	errs.required("invoiceName", r.InvoiceName)
+	errs.date("dueDate", r.DueDate, BillManagerDateFormat)
+	errs.required("accountReference", r.AccountReference)
+	errs.amount("amount", r.Amount)
+	for i, item := range r.InvoiceItems {
+		errs.required(fmt.Sprintf("invoiceItems[%d].itemName", i), item.ItemName)
+		errs.amount(fmt.Sprintf("invoiceItems[%d].amount", i), item.Amount)
+	}
+	return errs.err()
+}
+
+// Validate checks that there is at least one invoice and that each invoice is valid
+func (r RequestBillManagerBulkInvoice) Validate() error {
+	var errs fieldErrors
+	if len(r) == 0 {
+		errs.add("invoices", ErrRequiredField)
+	}
+	for i, invoice := range r {
+		errs.nest(fmt.Sprintf("[%d].", i), invoice.Validate())
+	}
+	return errs.err()
+}
+
+// Validate checks that ExternalReference is present
+func (r RequestBillManagerCancelInvoice) Validate() error {
+	var errs fieldErrors
+	errs.required("externalReference", r.ExternalReference)
+	return errs.err()
+}
+
+// Validate checks that there is at least one invoice and that each invoice is valid
+func (r RequestBillManagerCancelBulkInvoice) Validate() error {
+	var errs fieldErrors
+	if len(r) == 0 {
+		errs.add("invoices", ErrRequiredField)
+	}
+	for i, invoice := range r {
+		errs.nest(fmt.Sprintf("[%d].", i), invoice.Validate())
+	}
+	return errs.err()
+}
+
+// Validate checks that required fields are present, PaymentDate is in the format of
+// BillManagerDateFormat, PaidAmount is an amount and PhoneNumber is a valid phone number
+func (r RequestBillManagerReconciliation) Validate() error {
+	var errs fieldErrors
+	errs.date("paymentDate", r.PaymentDate, BillManagerDateFormat)
+	if errs.required("paidAmount", r.PaidAmount) {
+		if m, err := money.Parse(r.PaidAmount, money.KES); err != nil || m.IsZero() || m.IsNegative() {
+			errs.add("paidAmount", errors.New("must be a positive amount"))
+		}
+	}
+	errs.required("accountReference", r.AccountReference)
+	errs.required("transactionId", r.TransactionID)
+	errs.phoneNumber("phoneNumber", r.PhoneNumber)
+	errs.required("fullName", r.FullName)
+	errs.required("invoiceName", r.InvoiceName)
+	errs.required("externalReference", r.ExternalReference)
+	return errs.err()
+}
@@ -0,0 +1,644 @@
+package daraja
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/types"
+)
+
+func validC2BExpress() RequestC2BExpress {
+	return RequestC2BExpress{
+		BusinessShortCode: "174379",
+		Password:          "fake_password",
+		Timestamp:         NewTimestamp(),
+		TransactionType:   TypeCustomerPayBillOnline,
+		Amount:            "10",
+		PartyA:            "254712345678",
+		PartyB:            "174379",
+		PhoneNumber:       "254712345678",
+		CallBackURL:       "https://foo.bar/callback",
+		AccountReference:  "F0000020",
+		TransactionDesc:   "Deposit",
+	}
+}
+
+func validB2C() RequestB2C {
+	return RequestB2C{
+		OriginatorConversationID: "fake_id",
+		InitiatorName:            "fake_name",
+		SecurityCredential:       "fake_credential",
+		CommandID:                CommandBusinessPayment,
+		Amount:                   "10",
+		PartyA:                   "600000",
+		PartyB:                   "254712345678",
+		Remarks:                  "test payment",
+		QueueTimeOutURL:          "https://foo.bar/timeout",
+		ResultURL:                "https://foo.bar/result",
+	}
+}
+
+func validB2B() RequestB2B {
+	return RequestB2B{
+		Initiator:              "fake_initiator",
+		SecurityCredential:     "fake_credential",
+		CommandID:              CommandBusinessPayBill,
+		SenderIdentifierType:   IdentifierOrgShortCode,
+		RecieverIdentifierType: IdentifierOrgShortCode,
+		Amount:                 "10",
+		PartyA:                 "600000",
+		PartyB:                 "600001",
+		AccountReference:       "fake_ref",
+		Remarks:                "test payment",
+		QueueTimeOutURL:        "https://foo.bar/timeout",
+		ResultURL:              "https://foo.bar/result",
+	}
+}
+
+func validB2CTopUp() RequestB2CTopUp {
+	return RequestB2CTopUp{
+		Initiator:              "fake_initiator",
+		SecurityCredential:     "fake_credential",
+		CommandID:              CommandBusinessPayToBulk,
+		SenderIdentifierType:   IdentifierOrgShortCode,
+		RecieverIdentifierType: IdentifierOrgShortCode,
+		Amount:                 "1000",
+		PartyA:                 "600979",
+		PartyB:                 "600000",
+		AccountReference:       "353353",
+		Remarks:                "float top up",
+		QueueTimeOutURL:        "https://foo.bar/timeout",
+		ResultURL:              "https://foo.bar/result",
+	}
+}
+
+func validB2Pochi() RequestB2Pochi {
+	return RequestB2Pochi{
+		OriginatorConversationID: "fake_id",
+		InitiatorName:            "fake_name",
+		SecurityCredential:       "fake_credential",
+		CommandID:                CommandBusinessPayToPochi,
+		Amount:                   "10",
+		PartyA:                   "600000",
+		PartyB:                   "254712345678",
+		Remarks:                  "test payment",
+		QueueTimeOutURL:          "https://foo.bar/timeout",
+		ResultURL:                "https://foo.bar/result",
+	}
+}
+
+func validTaxRemittance() RequestTaxRemittance {
+	return RequestTaxRemittance{
+		Initiator:              "fake_initiator",
+		SecurityCredential:     "fake_credential",
+		CommandID:              CommandPayTaxToKRA,
+		SenderIdentifierType:   IdentifierOrgShortCode,
+		RecieverIdentifierType: IdentifierOrgShortCode,
+		Amount:                 "239",
+		PartyA:                 "888880",
+		PartyB:                 KRAShortCode,
+		AccountReference:       "353353",
+		Remarks:                "withholding tax",
+		QueueTimeOutURL:        "https://foo.bar/timeout",
+		ResultURL:              "https://foo.bar/result",
+	}
+}
+
+func validStandingOrder() RequestStandingOrder {
+	return RequestStandingOrder{
+		StandingOrderName:           "Test Standing Order",
+		StartDate:                   "20240905",
+		EndDate:                     "20250905",
+		BusinessShortCode:           "174379",
+		TransactionType:             TypeStandingOrderPayBill,
+		ReceiverPartyIdentifierType: IdentifierOrgShortCode,
+		Amount:                      "4500",
+		PartyA:                      "254708374149",
+		CallBackURL:                 "https://foo.bar/ratiba",
+		AccountReference:            "Test",
+		TransactionDesc:             "Test",
+		Frequency:                   FrequencyMonthly,
+	}
+}
+
+func validBillManagerOptIn() RequestBillManagerOptIn {
+	return RequestBillManagerOptIn{
+		ShortCode:       "718003",
+		Email:           "billing@foo.bar",
+		OfficialContact: "0710000000",
+		SendReminders:   "1",
+		CallbackURL:     "https://foo.bar/billmanager",
+	}
+}
+
+func validBillManagerInvoice() RequestBillManagerInvoice {
+	return RequestBillManagerInvoice{
+		ExternalReference: "#9932340",
+		BilledFullName:    "John Doe",
+		BilledPhoneNumber: "0710000000",
+		BilledPeriod:      "August 2021",
+		InvoiceName:       "Jentrys",
+		DueDate:           "2021-10-12",
+		AccountReference:  "1ASD678H",
+		Amount:            "800",
+		InvoiceItems: []BillManagerInvoiceItem{
+			{ItemName: "food", Amount: "700"},
+			{ItemName: "water", Amount: "100"},
+		},
+	}
+}
+
+func validBillManagerReconciliation() RequestBillManagerReconciliation {
+	return RequestBillManagerReconciliation{
+		PaymentDate:       "2021-10-01",
+		PaidAmount:        "800",
+		AccountReference:  "1ASD678H",
+		TransactionID:     "PJB53MYR1N",
+		PhoneNumber:       "0710000000",
+		FullName:          "John Doe",
+		InvoiceName:       "Jentrys",
+		ExternalReference: "#9932340",
+	}
+}
+
+func validB2BExpressCheckout() RequestB2BExpressCheckout {
+	return RequestB2BExpressCheckout{
+		PrimaryShortCode:  "000001",
+		ReceiverShortCode: "000002",
+		Amount:            "100",
+		PaymentRef:        "INV-1",
+		CallbackURL:       "https://foo.bar/result",
+		PartnerName:       "Vendor",
+		RequestRefID:      "fake_id",
+	}
+}
+
+func validTransactionStatus() RequestTransactionStatus {
+	return RequestTransactionStatus{
+		Initiator:          "fake_initiator",
+		SecurityCredential: "fake_credential",
+		CommandID:          CommandTransactionStatus,
+		TransactionID:      types.Pointer("OEI2AK4Q16"),
+		PartyA:             "600000",
+		IdentifierType:     IdentifierOrgShortCode,
+		ResultURL:          "https://foo.bar/result",
+		QueueTimeOutURL:    "https://foo.bar/timeout",
+		Remarks:            "status",
+	}
+}
+
+func validReversal() RequestReversal {
+	return RequestReversal{
+		Initiator:              "fake_initiator",
+		SecurityCredential:     "fake_credential",
+		CommandID:              CommandTransactionReversal,
+		TransactionID:          "OEI2AK4Q16",
+		Amount:                 "10",
+		ReceiverParty:          "600000",
+		ReceiverIdentifierType: IdentifierOrgOperatorUsername,
+		ResultURL:              "https://foo.bar/result",
+		QueueTimeOutURL:        "https://foo.bar/timeout",
+		Remarks:                "reversal",
+	}
+}
+
+func validBalance() RequestBalance {
+	return RequestBalance{
+		Initiator:          "fake_initiator",
+		SecurityCredential: "fake_credential",
+		CommandID:          CommandAccountBalance,
+		PartyA:             "600000",
+		IdentifierType:     IdentifierOrgShortCode,
+		Remarks:            "balance",
+		QueueTimeOutURL:    "https://foo.bar/timeout",
+		ResultURL:          "https://foo.bar/result",
+	}
+}
+
+func validPullRegister() RequestPullRegister {
+	return RequestPullRegister{
+		ShortCode:       "600000",
+		RequestType:     "Pull",
+		NominatedNumber: "0722000000",
+		CallBackURL:     "https://foo.bar/pull",
+	}
+}
+
+func validPullQuery() RequestPullQuery {
+	return RequestPullQuery{
+		ShortCode:   "600000",
+		StartDate:   "2020-08-04 08:36:00",
+		EndDate:     "2020-08-04 10:10:00",
+		OffSetValue: "0",
+	}
+}
+
+func validDynamicQR() RequestDynamicQR {
+	return RequestDynamicQR{
+		MerchantName: "TEST SUPERMARKET",
+		RefNo:        "Invoice Test",
+		Amount:       1,
+		TrxCode:      QRBuyGoods,
+		CPI:          "373132",
+		Size:         "300",
+	}
+}
+
+// fieldNames returns the names of the fields that failed validation
+func fieldNames(err error) []string {
+	var names []string
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			var fe *FieldError
+			if errors.As(e, &fe) {
+				names = append(names, fe.Field)
+			}
+		}
+	}
+	return names
+}
+
+func TestRequestC2BExpress_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validC2BExpress().Validate())
+
+		// numbers in the 2541 range are msisdns too
+		req := validC2BExpress()
+		req.PartyA, req.PhoneNumber = "254112345678", "254112345678"
+		assert.NoError(t, req.Validate())
+	})
+
+	t.Run("test that required fields are reported", func(t *testing.T) {
+		err := RequestC2BExpress{}.Validate()
+		assert.ErrorIs(t, err, ErrRequiredField)
+		assert.Equal(t, []string{
+			"BusinessShortCode", "Password", "Timestamp", "TransactionType", "Amount",
+			"PartyA", "PartyB", "PhoneNumber", "CallBackURL",
+		}, fieldNames(err))
+	})
+
+	t.Run("test that field formats and limits are checked", func(t *testing.T) {
+		req := validC2BExpress()
+		req.Amount = "10.50"
+		req.PhoneNumber = "0712345678"
+		req.AccountReference = "ACCOUNT-REF-0001"
+		req.TransactionDesc = "Customer Deposit"
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidAmount)
+		assert.ErrorIs(t, err, ErrInvalidMSISDN)
+		assert.Equal(t, []string{"Amount", "PhoneNumber", "AccountReference", "TransactionDesc"}, fieldNames(err))
+	})
+}
+
+func TestRequestB2C_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validB2C().Validate())
+	})
+
+	t.Run("test that invalid fields are reported", func(t *testing.T) {
+		req := validB2C()
+		req.CommandID = CommandBusinessPayBill
+		req.Amount = "-10"
+		req.PartyB = "+254712345678"
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidCommand)
+		assert.Equal(t, []string{"CommandID", "Amount", "PartyB"}, fieldNames(err))
+	})
+}
+
+func TestRequestB2B_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validB2B().Validate())
+	})
+
+	t.Run("test that buy goods accepts a till number receiver", func(t *testing.T) {
+		req := validB2B()
+		req.CommandID = CommandBusinessBuyGoods
+		req.RecieverIdentifierType = IdentifierTillNumber
+		assert.NoError(t, req.Validate())
+	})
+
+	t.Run("test that identifier types must match the command", func(t *testing.T) {
+		req := validB2B()
+		req.RecieverIdentifierType = IdentifierTillNumber
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidIdentifier)
+		assert.Equal(t, []string{"RecieverIdentifierType"}, fieldNames(err))
+	})
+}
+
+func TestRequestB2CTopUp_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validB2CTopUp().Validate())
+	})
+
+	t.Run("test that command and identifier types are checked", func(t *testing.T) {
+		req := validB2CTopUp()
+		req.CommandID = CommandBusinessPayBill
+		assert.ErrorIs(t, req.Validate(), ErrInvalidCommand)
+
+		req = validB2CTopUp()
+		req.RecieverIdentifierType = IdentifierTillNumber
+		req.AccountReference = ""
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidIdentifier)
+		assert.Equal(t, []string{"RecieverIdentifierType", "AccountReference"}, fieldNames(err))
+	})
+}
+
+func TestRequestB2Pochi_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validB2Pochi().Validate())
+	})
+
+	t.Run("test that invalid fields are reported", func(t *testing.T) {
+		req := validB2Pochi()
+		req.CommandID = CommandBusinessPayment
+		req.PartyB = "0712345678"
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidCommand)
+		assert.ErrorIs(t, err, ErrInvalidMSISDN)
+		assert.Equal(t, []string{"CommandID", "PartyB"}, fieldNames(err))
+	})
+}
+
+func TestRequestTaxRemittance_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validTaxRemittance().Validate())
+	})
+
+	t.Run("test that the kra shortcode and prn are checked", func(t *testing.T) {
+		req := validTaxRemittance()
+		req.PartyB = "600000"
+		req.AccountReference = "PRN-353353"
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidKRAShortCode)
+		assert.ErrorIs(t, err, ErrInvalidPRN)
+		assert.Equal(t, []string{"PartyB", "AccountReference"}, fieldNames(err))
+	})
+
+	t.Run("test that command id is checked", func(t *testing.T) {
+		req := validTaxRemittance()
+		req.CommandID = CommandBusinessPayBill
+		assert.ErrorIs(t, req.Validate(), ErrInvalidCommand)
+	})
+}
+
+func TestRequestStandingOrder_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validStandingOrder().Validate())
+
+		req := validStandingOrder()
+		req.TransactionType = TypeStandingOrderBuyGoods
+		req.ReceiverPartyIdentifierType = IdentifierTillNumber
+		req.AccountReference = ""
+		assert.NoError(t, req.Validate())
+	})
+
+	t.Run("test that dates are checked", func(t *testing.T) {
+		req := validStandingOrder()
+		req.StartDate = "2024-09-05"
+		assert.Equal(t, []string{"StartDate"}, fieldNames(req.Validate()))
+
+		req = validStandingOrder()
+		req.StartDate, req.EndDate = req.EndDate, req.StartDate
+		assert.Equal(t, []string{"EndDate"}, fieldNames(req.Validate()))
+	})
+
+	t.Run("test that identifier type must match transaction type", func(t *testing.T) {
+		req := validStandingOrder()
+		req.ReceiverPartyIdentifierType = IdentifierTillNumber
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidIdentifier)
+		assert.Equal(t, []string{"ReceiverPartyIdentifierType"}, fieldNames(err))
+	})
+
+	t.Run("test that frequency and party a are checked", func(t *testing.T) {
+		req := validStandingOrder()
+		req.Frequency = "9"
+		req.PartyA = "0708374149"
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidMSISDN)
+		assert.Equal(t, []string{"PartyA", "Frequency"}, fieldNames(err))
+	})
+}
+
+func TestRequestBillManagerOptIn_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validBillManagerOptIn().Validate())
+	})
+
+	t.Run("test that contacts and reminders are checked", func(t *testing.T) {
+		req := validBillManagerOptIn()
+		req.Email = "billing"
+		req.OfficialContact = "07100"
+		req.SendReminders = "yes"
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidEmail)
+		assert.ErrorIs(t, err, ErrInvalidPhoneNumber)
+		assert.Equal(t, []string{"email", "officialContact", "sendReminders"}, fieldNames(err))
+	})
+}
+
+func TestRequestBillManagerInvoice_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validBillManagerInvoice().Validate())
+	})
+
+	t.Run("test that due date and amounts are checked", func(t *testing.T) {
+		req := validBillManagerInvoice()
+		req.DueDate = "12/10/2021"
+		req.Amount = "800.50"
+		req.InvoiceItems[1].ItemName = ""
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidAmount)
+		assert.Equal(t, []string{"dueDate", "amount", "invoiceItems[1].itemName"}, fieldNames(err))
+	})
+}
+
+func TestRequestBillManagerBulkInvoice_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, RequestBillManagerBulkInvoice{validBillManagerInvoice(), validBillManagerInvoice()}.Validate())
+	})
+
+	t.Run("test that an empty request fails", func(t *testing.T) {
+		err := RequestBillManagerBulkInvoice{}.Validate()
+		assert.ErrorIs(t, err, ErrRequiredField)
+		assert.Equal(t, []string{"invoices"}, fieldNames(err))
+	})
+
+	t.Run("test that field errors are reported per invoice", func(t *testing.T) {
+		invalid := validBillManagerInvoice()
+		invalid.ExternalReference = ""
+		invalid.BilledPhoneNumber = "07100"
+
+		err := RequestBillManagerBulkInvoice{validBillManagerInvoice(), invalid}.Validate()
+		assert.ErrorIs(t, err, ErrRequiredField)
+		assert.ErrorIs(t, err, ErrInvalidPhoneNumber)
+		assert.Equal(t, []string{"[1].externalReference", "[1].billedPhoneNumber"}, fieldNames(err))
+	})
+}
+
+func TestRequestBillManagerCancelBulkInvoice_Validate(t *testing.T) {
+	assert.NoError(t, RequestBillManagerCancelBulkInvoice{{ExternalReference: "113"}}.Validate())
+
+	err := RequestBillManagerCancelBulkInvoice{{ExternalReference: "113"}, {}}.Validate()
+	assert.Equal(t, []string{"[1].externalReference"}, fieldNames(err))
+}
+
+func TestRequestBillManagerReconciliation_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validBillManagerReconciliation().Validate())
+	})
+
+	t.Run("test that required fields are reported", func(t *testing.T) {
+		err := RequestBillManagerReconciliation{}.Validate()
+		assert.ErrorIs(t, err, ErrRequiredField)
+		assert.Equal(t, []string{
+			"paymentDate", "paidAmount", "accountReference", "transactionId",
+			"phoneNumber", "fullName", "invoiceName", "externalReference",
+		}, fieldNames(err))
+	})
+
+	t.Run("test that paid amount is checked", func(t *testing.T) {
+		req := validBillManagerReconciliation()
+		req.PaidAmount = "0"
+		assert.Equal(t, []string{"paidAmount"}, fieldNames(req.Validate()))
+	})
+}
+
+func TestRequestB2BExpressCheckout_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validB2BExpressCheckout().Validate())
+	})
+
+	t.Run("test that required fields are reported", func(t *testing.T) {
+		err := RequestB2BExpressCheckout{}.Validate()
+		assert.ErrorIs(t, err, ErrRequiredField)
+		assert.Equal(t, []string{
+			"primaryShortCode", "receiverShortCode", "amount", "paymentRef", "callbackUrl", "partnerName", "RequestRefID",
+		}, fieldNames(err))
+	})
+
+	t.Run("test that amount is checked", func(t *testing.T) {
+		req := validB2BExpressCheckout()
+		req.Amount = "71.5"
+		assert.ErrorIs(t, req.Validate(), ErrInvalidAmount)
+	})
+}
+
+func TestRequestTransactionStatus_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validTransactionStatus().Validate())
+	})
+
+	t.Run("test that a transaction identifier is required", func(t *testing.T) {
+		req := validTransactionStatus()
+		req.TransactionID = nil
+		assert.Equal(t, []string{"TransactionID"}, fieldNames(req.Validate()))
+
+		req.OriginatorConversationID = types.Pointer("fake_id")
+		assert.NoError(t, req.Validate())
+	})
+
+	t.Run("test that msisdn party is checked", func(t *testing.T) {
+		req := validTransactionStatus()
+		req.IdentifierType = IdentifierMSISDN
+		assert.ErrorIs(t, req.Validate(), ErrInvalidMSISDN)
+	})
+}
+
+func TestRequestReversal_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validReversal().Validate())
+	})
+
+	t.Run("test that identifier type must match the command", func(t *testing.T) {
+		req := validReversal()
+		req.ReceiverIdentifierType = IdentifierMSISDN
+		assert.Equal(t, []string{"RecieverIdentifierType"}, fieldNames(req.Validate()))
+	})
+}
+
+func TestRequestBalance_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validBalance().Validate())
+	})
+
+	t.Run("test that command id is checked", func(t *testing.T) {
+		req := validBalance()
+		req.CommandID = CommandTransactionStatus
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidCommand)
+		assert.EqualError(t, err, "CommandID: invalid command id: TransactionStatusQuery")
+	})
+}
+
+func TestRequestPullRegister_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validPullRegister().Validate())
+	})
+
+	t.Run("test that invalid fields are reported", func(t *testing.T) {
+		req := validPullRegister()
+		req.RequestType = "Push"
+		req.CallBackURL = "/pull"
+
+		err := req.Validate()
+		assert.ErrorIs(t, err, ErrInvalidURL)
+		assert.Equal(t, []string{"RequestType", "CallBackURL"}, fieldNames(err))
+	})
+}
+
+func TestRequestPullQuery_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validPullQuery().Validate())
+	})
+
+	t.Run("test that required fields are reported", func(t *testing.T) {
+		err := RequestPullQuery{}.Validate()
+		assert.ErrorIs(t, err, ErrRequiredField)
+		assert.Equal(t, []string{"ShortCode", "StartDate", "EndDate", "OffSetValue"}, fieldNames(err))
+	})
+
+	t.Run("test that dates and offset are checked", func(t *testing.T) {
+		req := validPullQuery()
+		req.StartDate = "2020-08-04T08:36:00Z"
+		req.OffSetValue = "-1"
+		assert.Equal(t, []string{"StartDate", "OffSetValue"}, fieldNames(req.Validate()))
+
+		req = validPullQuery()
+		req.StartDate, req.EndDate = req.EndDate, req.StartDate
+		assert.Equal(t, []string{"EndDate"}, fieldNames(req.Validate()))
+	})
+}
+
+func TestRequestDynamicQR_Validate(t *testing.T) {
+	t.Run("test that a valid request passes", func(t *testing.T) {
+		assert.NoError(t, validDynamicQR().Validate())
+	})
+
+	t.Run("test that required fields are reported", func(t *testing.T) {
+		err := RequestDynamicQR{}.Validate()
+		assert.Equal(t, []string{"MerchantName", "RefNo", "Amount", "TrxCode", "CPI", "Size"}, fieldNames(err))
+	})
+
+	t.Run("test that transaction code and size are checked", func(t *testing.T) {
+		req := validDynamicQR()
+		req.TrxCode = "XX"
+		req.Size = "300px"
+		assert.Equal(t, []string{"TrxCode", "Size"}, fieldNames(req.Validate()))
+	})
+}
@@ -7,38 +7,36 @@ import (
 	"log"
 	"os"
 
+	"github.com/SirWaithaka/payments/config"
 	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/phone"
 )
 
-type ShortCodeConfig struct {
-	ShortCode         string // business pay bill or buy goods account
-	InitiatorName     string // daraja api initiator name
-	InitiatorPassword string // daraja api initiator password
-	Passphrase        string // (optional) passphrase for c2b transfers
-	ConsumerKey       string // daraja app consumer key
-	ConsumerSecret    string // daraja app consumer secret
-}
-
 // example of making a c2b request
-func makeC2bRequest(sCfg ShortCodeConfig) {
+func makeC2bRequest(sCfg config.Daraja) {
 	l := log.New(os.Stdout, "Daraja: ", log.LstdFlags|log.Llongfile)
 
-	// create an instance of daraja client
-	client := daraja.New(daraja.Config{Endpoint: daraja.SandboxUrl})
-	// configure authentication using request hooks
-	client.Hooks.Build.PushBackHook(daraja.Authenticate(client.AuthenticationRequest(sCfg.ConsumerKey, sCfg.ConsumerSecret)))
+	// create an instance of daraja client for the shortcode's environment, with
+	// authentication configured using request hooks
+	client := sCfg.Client(daraja.Config{})
+
+	// normalize the customer's phone number to the format daraja expects
+	number, err := phone.Parse("0720000000")
+	if err != nil {
+		l.Fatal(err)
+	}
 
 	// encode the shortcode passphrase
-	password := daraja.PasswordEncode(sCfg.ShortCode, sCfg.Passphrase, daraja.NewTimestamp().String())
+	timestamp := daraja.NewTimestamp()
 	req := daraja.RequestC2BExpress{
 		BusinessShortCode: sCfg.ShortCode,
-		Password:          password, // encoded passphrase for c2b
-		Timestamp:         daraja.NewTimestamp(),
+		Password:          sCfg.Password(timestamp), // encoded passphrase for c2b
+		Timestamp:         timestamp,
 		TransactionType:   daraja.OperationC2BExpress,
 		Amount:            "100",
-		PartyA:            "100100",
+		PartyA:            number.For(phone.ProviderDaraja),
 		PartyB:            sCfg.ShortCode,
-		PhoneNumber:       "0720000000",
+		PhoneNumber:       number.For(phone.ProviderDaraja),
 		CallBackURL:       "http://localhost:8000/daraja/c2b/callback",
 		AccountReference:  "F0000020",
 		TransactionDesc:   "Customer Deposit",
@@ -52,23 +50,22 @@ func makeC2bRequest(sCfg ShortCodeConfig) {
 	l.Println(res)
 }
 
-func makeB2cRequest(sCfg ShortCodeConfig) {
+func makeB2cRequest(sCfg config.Daraja) {
 	l := log.New(os.Stdout, "Daraja: ", log.LstdFlags|log.Llongfile)
 
-	// create an instance of daraja client
-	client := daraja.New(daraja.Config{Endpoint: daraja.SandboxUrl})
-	// configure authentication using request hooks
-	client.Hooks.Build.PushBackHook(daraja.Authenticate(client.AuthenticationRequest(sCfg.ConsumerKey, sCfg.ConsumerSecret)))
+	// create an instance of daraja client for the shortcode's environment, with
+	// authentication configured using request hooks
+	client := sCfg.Client(daraja.Config{})
 
 	// build security credential
-	credential, err := daraja.OpenSSLEncrypt(sCfg.InitiatorPassword, daraja.SandboxCertificate)
+	credential, err := sCfg.SecurityCredential()
 	if err != nil {
 		l.Fatal(err)
 	}
 
 	req := daraja.RequestB2C{
 		OriginatorConversationID: "1234567890",
-		InitiatorName:            "initiator_name",
+		InitiatorName:            sCfg.InitiatorName,
 		SecurityCredential:       credential,
 		CommandID:                daraja.CommandBusinessPayment,
 		Amount:                   "10",
@@ -89,14 +86,15 @@ func makeB2cRequest(sCfg ShortCodeConfig) {
 
 func main() {
 
-	// daraja shortcode config
-	sCfg := ShortCodeConfig{
-		ShortCode:         "000000",
-		InitiatorName:     "initiator_name",
-		InitiatorPassword: "initiator_password",
-		Passphrase:        "passphrase",
-		ConsumerKey:       "consumer_key",
-		ConsumerSecret:    "consumer_secret",
+	// load the shortcode credentials from environment variables e.g.
+	// PAYMENTS_DARAJA_000000_CONSUMER_KEY, or use config.LoadFile with a yaml or json file
+	cfg, err := config.LoadEnv("PAYMENTS")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sCfg, ok := cfg.Daraja["000000"]
+	if !ok {
+		log.Fatal("daraja shortcode 000000 is not configured")
 	}
 
 	makeC2bRequest(sCfg)
@@ -0,0 +1,111 @@
+package idempotency
+
+import (
+	"context"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+// do calls send with request at most once per key, with the Hash of hashed as the request
+// hash. hashed is a copy of request without the fields that change on every attempt.
+func do[Req, Res any](ctx context.Context, store Store, key, operation string, hashed, request Req,
+	send func(context.Context, Req) (Res, error)) (Res, error) {
+	hash, err := Hash(operation, hashed)
+	if err != nil {
+		var response Res
+		return response, err
+	}
+	return DoHash(ctx, store, key, hash, request, send)
+}
+
+// DarajaClient is the part of daraja.Client wrapped by Daraja
+type DarajaClient interface {
+	B2C(ctx context.Context, payload daraja.RequestB2C) (daraja.ResponseB2C, error)
+	B2B(ctx context.Context, payload daraja.RequestB2B) (daraja.ResponseB2B, error)
+}
+
+// Daraja sends daraja b2c and b2b requests at most once per idempotency key. The
+// SecurityCredential is left out of the request hash, since it is encrypted with random
+// padding and differs on every attempt.
+type Daraja struct {
+	Client DarajaClient
+	Store  Store
+}
+
+func (d Daraja) B2C(ctx context.Context, key string, payload daraja.RequestB2C) (daraja.ResponseB2C, error) {
+	hashed := payload
+	hashed.SecurityCredential = ""
+	return do(ctx, d.Store, key, "daraja."+daraja.OperationB2C, hashed, payload, d.Client.B2C)
+}
+
+func (d Daraja) B2B(ctx context.Context, key string, payload daraja.RequestB2B) (daraja.ResponseB2B, error) {
+	hashed := payload
+	hashed.SecurityCredential = ""
+	return do(ctx, d.Store, key, "daraja."+daraja.OperationB2B, hashed, payload, d.Client.B2B)
+}
+
+// QuikkClient is the part of quikk.Client wrapped by Quikk
+type QuikkClient interface {
+	Payout(ctx context.Context, input quikk.RequestPayout, ref string) (quikk.ResponseDefault, error)
+	Transfer(ctx context.Context, input quikk.RequestTransfer, ref string) (quikk.ResponseDefault, error)
+}
+
+// Quikk sends quikk payout and transfer requests at most once per idempotency key. The
+// PostedAt time is left out of the request hash, since it is set on every attempt.
+type Quikk struct {
+	Client QuikkClient
+	Store  Store
+}
+
+// quikkRequest is the hashed request of quikk operations, which includes the ref
+type quikkRequest[T any] struct {
+	Ref   string `json:"ref"`
+	Input T      `json:"input"`
+}
+
+func (q Quikk) Payout(ctx context.Context, key string, input quikk.RequestPayout, ref string) (quikk.ResponseDefault, error) {
+	request := quikkRequest[quikk.RequestPayout]{Ref: ref, Input: input}
+	hashed := request
+	hashed.Input.PostedAt = ""
+	return do(ctx, q.Store, key, "quikk."+quikk.OperationPayout, hashed, request,
+		func(ctx context.Context, r quikkRequest[quikk.RequestPayout]) (quikk.ResponseDefault, error) {
+			return q.Client.Payout(ctx, r.Input, r.Ref)
+		})
+}
+
+func (q Quikk) Transfer(ctx context.Context, key string, input quikk.RequestTransfer, ref string) (quikk.ResponseDefault, error) {
+	request := quikkRequest[quikk.RequestTransfer]{Ref: ref, Input: input}
+	hashed := request
+	hashed.Input.PostedAt = ""
+	return do(ctx, q.Store, key, "quikk."+quikk.OperationTransfer, hashed, request,
+		func(ctx context.Context, r quikkRequest[quikk.RequestTransfer]) (quikk.ResponseDefault, error) {
+			return q.Client.Transfer(ctx, r.Input, r.Ref)
+		})
+}
+
+// TandaClient is the part of tanda.Client wrapped by Tanda
+type TandaClient interface {
+	Payment(ctx context.Context, orgID string, payload tanda.RequestPayment) (tanda.ResponsePayment, error)
+}
+
+// Tanda sends tanda payment requests at most once per idempotency key
+type Tanda struct {
+	Client TandaClient
+	Store  Store
+}
+
+// tandaRequest is the hashed request of tanda payments, which includes the organization
+type tandaRequest struct {
+	OrgID   string               `json:"orgId"`
+	Payload tanda.RequestPayment `json:"payload"`
+}
+
+func (t Tanda) Payment(ctx context.Context, key, orgID string, payload tanda.RequestPayment) (tanda.ResponsePayment, error) {
+	request := tandaRequest{OrgID: orgID, Payload: payload}
+	return Do(ctx, t.Store, key, "tanda."+tanda.OperationPayment, request,
+		func(ctx context.Context, r tandaRequest) (tanda.ResponsePayment, error) {
+			return t.Client.Payment(ctx, r.OrgID, r.Payload)
+		})
+}
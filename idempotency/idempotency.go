@@ -0,0 +1,176 @@
+// Package idempotency stops the same logical payment from being sent twice. Each call
+// is made with a caller provided key, which is persisted in a Store together with a hash
+// of the request and the outcome of the call. Repeating a call with the same key returns
+// the stored outcome instead of sending the request again.
+//
+// The wrappers Daraja, Quikk and Tanda apply the layer to the operations that move money.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/payments/internal/exchange"
+)
+
+var (
+	ErrMissingKey = errors.New("idempotency key is required")
+	// ErrKeyReused is returned when a key is repeated with a different request
+	ErrKeyReused = errors.New("idempotency key was used with a different request")
+	// ErrInProgress is returned when a key is repeated before the first call has completed
+	ErrInProgress = errors.New("request with idempotency key is in progress")
+	// ErrOutcomeUnknown is returned when a key is repeated after a call that may or may
+	// not have reached the provider e.g. a timeout, or a call whose pending record outlived
+	// its lease e.g. after a crash. The payment should be checked with a transaction status
+	// query, and the key removed with Store.Delete before retrying.
+	ErrOutcomeUnknown = errors.New("outcome of request with idempotency key is unknown")
+)
+
+// DefaultLease is how long a call is expected to hold a pending record, unless the Store
+// is configured with another lease. It should be longer than the timeout of the call.
+const DefaultLease = 5 * time.Minute
+
+// Status is the status of a call made with an idempotency key
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusUnknown   Status = "unknown"
+)
+
+// Record is the stored outcome of a call made with an idempotency key
+type Record struct {
+	Key         string
+	RequestHash string
+	Status      Status
+	// Response is the json encoded response of a completed call
+	Response []byte
+	// Error is the error message of a failed call, or of a call with an unknown outcome
+	Error string
+	// LeaseUntil is when a pending record expires. A pending record whose lease has expired
+	// was left by a call that did not complete e.g. after a crash, so its outcome is unknown.
+	LeaseUntil time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// FailedError is returned when a key is repeated after a call that failed, it has the
+// message of the original error
+type FailedError struct {
+	Key     string
+	Message string
+}
+
+func (e *FailedError) Error() string {
+	return e.Message
+}
+
+// Hash returns the hex encoded sha256 hash of the json encoding of the operation and request
+func Hash(operation string, request any) (string, error) {
+	b, err := jsoniter.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(operation))
+	h.Write([]byte{0})
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Do calls send with request, unless a call with key was made before, in which case the
+// outcome of that call is returned. Calls with an error that leaves it unknown whether the
+// request reached the provider, such as a timeout, are stored with StatusUnknown and are
+// not retried automatically. Calls rejected because the provider is throttling requests
+// are not stored. Calls made while the key is pending return ErrInProgress, or
+// ErrOutcomeUnknown once the lease of the pending record has expired. The request is
+// hashed with Hash, use DoHash for requests with fields that change on every attempt.
+func Do[Req, Res any](ctx context.Context, store Store, key, operation string, request Req,
+	send func(context.Context, Req) (Res, error)) (Res, error) {
+	hash, err := Hash(operation, request)
+	if err != nil {
+		var response Res
+		return response, err
+	}
+	return DoHash(ctx, store, key, hash, request, send)
+}
+
+// DoHash is Do with a request hash computed by the caller, e.g. the Hash of a copy of the
+// request without the fields that change on every attempt
+func DoHash[Req, Res any](ctx context.Context, store Store, key, hash string, request Req,
+	send func(context.Context, Req) (Res, error)) (Res, error) {
+	var response Res
+	if key == "" {
+		return response, ErrMissingKey
+	}
+
+	record, created, err := store.Reserve(ctx, key, hash)
+	if err != nil {
+		return response, err
+	}
+	if !created {
+		return replay[Res](record, hash)
+	}
+
+	response, sendErr := send(ctx, request)
+
+	outcome := Record{Key: key, RequestHash: hash}
+	switch {
+	case sendErr == nil:
+		outcome.Status = StatusCompleted
+		if outcome.Response, err = jsoniter.Marshal(response); err != nil {
+			return response, err
+		}
+	case exchange.Ambiguous(sendErr):
+		outcome.Status, outcome.Error = StatusUnknown, sendErr.Error()
+	case exchange.Transient(sendErr):
+		// the provider throttled the request without processing it, so the key is
+		// released for the call to be made again
+		if err = store.Delete(context.WithoutCancel(ctx), key); err != nil {
+			return response, errors.Join(sendErr, err)
+		}
+		return response, sendErr
+	default:
+		outcome.Status, outcome.Error = StatusFailed, sendErr.Error()
+	}
+
+	// the outcome is saved even if ctx was cancelled while sending
+	if err = store.Complete(context.WithoutCancel(ctx), outcome); err != nil {
+		return response, errors.Join(sendErr, err)
+	}
+	return response, sendErr
+}
+
+// replay returns the outcome of a previous call
+func replay[Res any](record Record, hash string) (Res, error) {
+	var response Res
+	if record.RequestHash != hash {
+		return response, fmt.Errorf("%w: %s", ErrKeyReused, record.Key)
+	}
+
+	switch record.Status {
+	case StatusCompleted:
+		err := jsoniter.Unmarshal(record.Response, &response)
+		return response, err
+	case StatusFailed:
+		return response, &FailedError{Key: record.Key, Message: record.Error}
+	case StatusUnknown:
+		return response, fmt.Errorf("%w: %s: %s", ErrOutcomeUnknown, record.Key, record.Error)
+	case StatusPending:
+		if record.LeaseUntil.Before(time.Now()) {
+			return response, fmt.Errorf("%w: %s: pending since %s", ErrOutcomeUnknown, record.Key, record.CreatedAt.Format(time.RFC3339))
+		}
+		return response, fmt.Errorf("%w: %s", ErrInProgress, record.Key)
+	default:
+		return response, fmt.Errorf("%w: %s", ErrInProgress, record.Key)
+	}
+}
@@ -0,0 +1,338 @@
+package idempotency_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/idempotency"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+// darajaClient is a fake daraja client that counts the requests it receives
+type darajaClient struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (c *darajaClient) B2C(_ context.Context, payload daraja.RequestB2C) (daraja.ResponseB2C, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	if c.err != nil {
+		return daraja.ResponseB2C{}, c.err
+	}
+	return daraja.ResponseB2C{
+		ConversationID:           "AG_20191219_00005797af5d7d75f652",
+		OriginatorConversationID: payload.OriginatorConversationID,
+		ResponseCode:             daraja.SuccessSubmission,
+		ResponseDescription:      "Accept the service request successfully.",
+	}, nil
+}
+
+func (c *darajaClient) B2B(_ context.Context, _ daraja.RequestB2B) (daraja.ResponseB2B, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	return daraja.ResponseB2B{ResponseCode: daraja.SuccessSubmission}, c.err
+}
+
+var payload = daraja.RequestB2C{
+	OriginatorConversationID: "16740-34861180-1",
+	CommandID:                daraja.CommandBusinessPayment,
+	Amount:                   "100",
+	PartyA:                   "600000",
+	PartyB:                   "254712345678",
+}
+
+// testStore runs the idempotency tests against store
+func testStore(t *testing.T, store idempotency.Store) {
+	ctx := t.Context()
+
+	t.Run("test that repeated calls return the stored response", func(t *testing.T) {
+		client := &darajaClient{}
+		wrapped := idempotency.Daraja{Client: client, Store: store}
+
+		first, err := wrapped.B2C(ctx, "payout-1", payload)
+		assert.NoError(t, err)
+
+		second, err := wrapped.B2C(ctx, "payout-1", payload)
+		assert.NoError(t, err)
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, client.calls)
+
+		record, err := store.Get(ctx, "payout-1")
+		assert.NoError(t, err)
+		assert.Equal(t, idempotency.StatusCompleted, record.Status)
+		assert.False(t, record.CreatedAt.IsZero())
+	})
+
+	t.Run("test that a key cannot be reused for a different request", func(t *testing.T) {
+		client := &darajaClient{}
+		wrapped := idempotency.Daraja{Client: client, Store: store}
+
+		_, err := wrapped.B2C(ctx, "payout-2", payload)
+		assert.NoError(t, err)
+
+		other := payload
+		other.Amount = "200"
+		_, err = wrapped.B2C(ctx, "payout-2", other)
+		assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+
+		// the security credential is encrypted differently on every attempt
+		retry := payload
+		retry.SecurityCredential = "Safaricom999!*!"
+		_, err = wrapped.B2C(ctx, "payout-2", retry)
+		assert.NoError(t, err)
+
+		// the same key for another operation is a different request
+		_, err = wrapped.B2B(ctx, "payout-2", daraja.RequestB2B{Amount: "100"})
+		assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+		assert.Equal(t, 1, client.calls)
+	})
+
+	t.Run("test that failed calls are replayed", func(t *testing.T) {
+		client := &darajaClient{err: errors.New("<400.002.02> Bad Request - Invalid PartyB")}
+		wrapped := idempotency.Daraja{Client: client, Store: store}
+
+		_, err := wrapped.B2C(ctx, "payout-3", payload)
+		assert.EqualError(t, err, "<400.002.02> Bad Request - Invalid PartyB")
+
+		_, err = wrapped.B2C(ctx, "payout-3", payload)
+		var failed *idempotency.FailedError
+		assert.ErrorAs(t, err, &failed)
+		assert.Equal(t, "<400.002.02> Bad Request - Invalid PartyB", failed.Message)
+		assert.Equal(t, 1, client.calls)
+	})
+
+	t.Run("test that timeouts are not retried", func(t *testing.T) {
+		client := &darajaClient{err: &url.Error{Op: "Post", URL: "https://sandbox.safaricom.co.ke", Err: context.DeadlineExceeded}}
+		wrapped := idempotency.Daraja{Client: client, Store: store}
+
+		_, err := wrapped.B2C(ctx, "payout-4", payload)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		client.err = nil
+		_, err = wrapped.B2C(ctx, "payout-4", payload)
+		assert.ErrorIs(t, err, idempotency.ErrOutcomeUnknown)
+		assert.Equal(t, 1, client.calls)
+
+		// the key can be deleted once the payment is confirmed to have failed
+		assert.NoError(t, store.Delete(ctx, "payout-4"))
+		_, err = wrapped.B2C(ctx, "payout-4", payload)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("test that pending keys are not sent again", func(t *testing.T) {
+		hash, err := idempotency.Hash("daraja."+daraja.OperationB2C, payload)
+		assert.NoError(t, err)
+		_, created, err := store.Reserve(ctx, "payout-5", hash)
+		assert.NoError(t, err)
+		assert.True(t, created)
+
+		client := &darajaClient{}
+		_, err = idempotency.Daraja{Client: client, Store: store}.B2C(ctx, "payout-5", payload)
+		assert.ErrorIs(t, err, idempotency.ErrInProgress)
+		assert.Equal(t, 0, client.calls)
+	})
+
+	t.Run("test that concurrent calls send once", func(t *testing.T) {
+		client := &darajaClient{}
+		wrapped := idempotency.Daraja{Client: client, Store: store}
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = wrapped.B2C(ctx, "payout-6", payload)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, client.calls)
+	})
+
+	t.Run("test that a key is required", func(t *testing.T) {
+		_, err := idempotency.Daraja{Client: &darajaClient{}, Store: store}.B2C(ctx, "", payload)
+		assert.ErrorIs(t, err, idempotency.ErrMissingKey)
+	})
+
+	t.Run("test that completing an unknown key fails", func(t *testing.T) {
+		err := store.Complete(ctx, idempotency.Record{Key: "missing", Status: idempotency.StatusCompleted})
+		assert.ErrorIs(t, err, idempotency.ErrNotFound)
+	})
+}
+
+// testLease runs the lease tests against store, whose lease must be shorter than 50ms
+func testLease(t *testing.T, store idempotency.Store) {
+	ctx := t.Context()
+	hash, err := idempotency.Hash("daraja."+daraja.OperationB2C, payload)
+	assert.NoError(t, err)
+
+	pending, created, err := store.Reserve(ctx, "payout-1", hash)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.True(t, pending.LeaseUntil.After(pending.CreatedAt))
+
+	client := &darajaClient{}
+	wrapped := idempotency.Daraja{Client: client, Store: store}
+	_, err = wrapped.B2C(ctx, "payout-1", payload)
+	assert.ErrorIs(t, err, idempotency.ErrInProgress)
+
+	// a pending record whose lease expired, e.g. after a crash, may have been sent
+	time.Sleep(50 * time.Millisecond)
+	_, created, err = store.Reserve(ctx, "payout-1", hash)
+	assert.NoError(t, err)
+	assert.False(t, created)
+
+	_, err = wrapped.B2C(ctx, "payout-1", payload)
+	assert.ErrorIs(t, err, idempotency.ErrOutcomeUnknown)
+	assert.Equal(t, 0, client.calls)
+
+	// the key can be deleted once the payment is confirmed to have failed
+	assert.NoError(t, store.Delete(ctx, "payout-1"))
+	_, err = wrapped.B2C(ctx, "payout-1", payload)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.calls)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, idempotency.NewMemoryStore())
+
+	t.Run("test that expired pending keys are unknown", func(t *testing.T) {
+		store := idempotency.NewMemoryStore()
+		store.Lease = 10 * time.Millisecond
+		testLease(t, store)
+	})
+}
+
+func TestDaraja_ProviderErrors(t *testing.T) {
+	// create a mock daraja server that fails the first request of each recipient
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointB2cPayment, func(w http.ResponseWriter, r *http.Request) {
+		var payload daraja.RequestB2C
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+
+		mu.Lock()
+		calls[payload.PartyB]++
+		first := calls[payload.PartyB] == 1
+		mu.Unlock()
+
+		switch {
+		case first && payload.PartyB == "254712345678":
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"requestId":"10101","errorCode":"500.003.02","errorMessage":"Spike Arrest Violation"}`))
+		case first:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`<html><body>502 Bad Gateway</body></html>`))
+		default:
+			_, _ = w.Write([]byte(`{"ConversationID":"AG_20191219_00005797af5d7d75f652","OriginatorConversationID":"16740-34861180-1","ResponseCode":"0","ResponseDescription":"Accept the service request successfully."}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	store := idempotency.NewMemoryStore()
+	wrapped := idempotency.Daraja{Client: daraja.New(daraja.Config{Endpoint: server.URL}), Store: store}
+
+	t.Run("test that throttled calls are not stored", func(t *testing.T) {
+		_, err := wrapped.B2C(t.Context(), "payout-1", payload)
+		assert.EqualError(t, err, "<500.003.02> Spike Arrest Violation")
+
+		_, err = store.Get(t.Context(), "payout-1")
+		assert.ErrorIs(t, err, idempotency.ErrNotFound)
+
+		res, err := wrapped.B2C(t.Context(), "payout-1", payload)
+		assert.NoError(t, err)
+		assert.Equal(t, "AG_20191219_00005797af5d7d75f652", res.ConversationID)
+	})
+
+	t.Run("test that server errors without a response are unknown", func(t *testing.T) {
+		other := payload
+		other.PartyB = "254700000000"
+		_, err := wrapped.B2C(t.Context(), "payout-2", other)
+		assert.Error(t, err)
+
+		_, err = wrapped.B2C(t.Context(), "payout-2", other)
+		assert.ErrorIs(t, err, idempotency.ErrOutcomeUnknown)
+		assert.Equal(t, 1, calls[other.PartyB])
+	})
+}
+
+type quikkClient struct{ calls int }
+
+func (c *quikkClient) Payout(_ context.Context, input quikk.RequestPayout, ref string) (quikk.ResponseDefault, error) {
+	c.calls++
+	var res quikk.ResponseDefault
+	err := jsoniter.Unmarshal([]byte(`{"data":{"id":"`+ref+`","type":"payout"}}`), &res)
+	return res, err
+}
+
+func (c *quikkClient) Transfer(_ context.Context, input quikk.RequestTransfer, ref string) (quikk.ResponseDefault, error) {
+	c.calls++
+	return quikk.ResponseDefault{}, nil
+}
+
+func TestQuikk(t *testing.T) {
+	client := &quikkClient{}
+	wrapped := idempotency.Quikk{Client: client, Store: idempotency.NewMemoryStore()}
+	input := quikk.RequestPayout{Amount: 100, RecipientNo: "254712345678", ShortCode: "174379"}
+
+	res, err := wrapped.Payout(t.Context(), "key", input, "ref-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ref-1", res.Data.ID)
+
+	res, err = wrapped.Payout(t.Context(), "key", input, "ref-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ref-1", res.Data.ID)
+	assert.Equal(t, 1, client.calls)
+
+	// the posted time is set on every attempt
+	input.PostedAt = "2025-08-16T10:00:00Z"
+	_, err = wrapped.Payout(t.Context(), "key", input, "ref-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.calls)
+
+	// the ref is part of the request
+	_, err = wrapped.Payout(t.Context(), "key", input, "ref-2")
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+}
+
+type tandaClient struct{ calls int }
+
+func (c *tandaClient) Payment(_ context.Context, _ string, payload tanda.RequestPayment) (tanda.ResponsePayment, error) {
+	c.calls++
+	return tanda.ResponsePayment{}, nil
+}
+
+func TestTanda(t *testing.T) {
+	client := &tandaClient{}
+	wrapped := idempotency.Tanda{Client: client, Store: idempotency.NewMemoryStore()}
+	payment := tanda.RequestPayment{CommandID: tanda.CommandMerchantToCustomerMobileMoneyPayment, Reference: "REF00000001"}
+
+	_, err := wrapped.Payment(t.Context(), "key", "org", payment)
+	assert.NoError(t, err)
+	_, err = wrapped.Payment(t.Context(), "key", "org", payment)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.calls)
+
+	_, err = wrapped.Payment(t.Context(), "key", "other-org", payment)
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+}
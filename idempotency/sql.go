@@ -0,0 +1,143 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Dialect is the placeholder style of a sql database
+type Dialect int
+
+const (
+	// DialectQuestion uses ? placeholders, as in mysql and sqlite
+	DialectQuestion Dialect = iota
+	// DialectDollar uses $1 placeholders, as in postgres
+	DialectDollar
+)
+
+// SQLStore is a Store backed by a sql database. Records are kept in a single table,
+// which can be created with Migrate. Times are stored as unix nanoseconds so that the
+// store does not depend on the time support of the driver.
+type SQLStore struct {
+	// Lease is how long pending records are held before their outcome is unknown, it
+	// defaults to DefaultLease
+	Lease time.Duration
+
+	db      *sql.DB
+	table   string
+	dialect Dialect
+}
+
+// NewSQLStore creates a SQLStore that keeps records in table. The table defaults to
+// idempotency_keys.
+func NewSQLStore(db *sql.DB, table string, dialect Dialect) *SQLStore {
+	if table == "" {
+		table = "idempotency_keys"
+	}
+	return &SQLStore{db: db, table: table, dialect: dialect}
+}
+
+// query replaces the ? placeholders of q for the dialect of the store
+func (s *SQLStore) query(q string) string {
+	q = strings.ReplaceAll(q, "{table}", s.table)
+	if s.dialect != DialectDollar {
+		return q
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Migrate creates the table of the store if it does not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.query(`CREATE TABLE IF NOT EXISTS {table} (
+	idempotency_key VARCHAR(255) NOT NULL PRIMARY KEY,
+	request_hash VARCHAR(64) NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	response TEXT,
+	error TEXT,
+	lease_until BIGINT NOT NULL,
+	created_at BIGINT NOT NULL,
+	updated_at BIGINT NOT NULL
+)`))
+	return err
+}
+
+func (s *SQLStore) Reserve(ctx context.Context, key, requestHash string) (Record, bool, error) {
+	now := time.Now().UTC()
+	leaseUntil := now.Add(lease(s.Lease))
+	_, err := s.db.ExecContext(ctx,
+		s.query(`INSERT INTO {table} (idempotency_key, request_hash, status, lease_until, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`),
+		key, requestHash, string(StatusPending), leaseUntil.UnixNano(), now.UnixNano(), now.UnixNano())
+	if err == nil {
+		return Record{Key: key, RequestHash: requestHash, Status: StatusPending, LeaseUntil: leaseUntil, CreatedAt: now, UpdatedAt: now}, true, nil
+	}
+
+	// the insert fails with a driver specific error if the key exists,
+	// so check for the key before returning the error
+	record, getErr := s.Get(ctx, key)
+	if getErr != nil {
+		return Record{}, false, errors.Join(err, getErr)
+	}
+	return record, false, nil
+}
+
+func (s *SQLStore) Complete(ctx context.Context, record Record) error {
+	result, err := s.db.ExecContext(ctx,
+		s.query(`UPDATE {table} SET status = ?, response = ?, error = ?, updated_at = ? WHERE idempotency_key = ?`),
+		string(record.Status), string(record.Response), record.Error, time.Now().UTC().UnixNano(), record.Key)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) Get(ctx context.Context, key string) (Record, error) {
+	row := s.db.QueryRowContext(ctx,
+		s.query(`SELECT idempotency_key, request_hash, status, response, error, lease_until, created_at, updated_at FROM {table} WHERE idempotency_key = ?`),
+		key)
+
+	var record Record
+	var status string
+	var response, message sql.NullString
+	var leaseUntil, createdAt, updatedAt int64
+	err := row.Scan(&record.Key, &record.RequestHash, &status, &response, &message, &leaseUntil, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, ErrNotFound
+	}
+	if err != nil {
+		return Record{}, err
+	}
+
+	record.Status = Status(status)
+	if response.String != "" {
+		record.Response = []byte(response.String)
+	}
+	record.Error = message.String
+	record.LeaseUntil = time.Unix(0, leaseUntil).UTC()
+	record.CreatedAt = time.Unix(0, createdAt).UTC()
+	record.UpdatedAt = time.Unix(0, updatedAt).UTC()
+	return record, nil
+}
+
+func (s *SQLStore) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.query(`DELETE FROM {table} WHERE idempotency_key = ?`), key)
+	return err
+}
@@ -0,0 +1,227 @@
+package idempotency_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/idempotency"
+)
+
+// memDB is a minimal database/sql driver for the statements of SQLStore. Tables are maps
+// of rows keyed by their first column, which is the primary key.
+type memDB struct {
+	mu     sync.Mutex
+	tables map[string]*memTable
+}
+
+type memTable struct {
+	columns []string
+	rows    map[any]map[string]driver.Value
+}
+
+func (db *memDB) Connect(context.Context) (driver.Conn, error) { return memConn{db}, nil }
+func (db *memDB) Driver() driver.Driver                        { return nil }
+
+type memConn struct{ db *memDB }
+
+func (c memConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c memConn) Close() error                        { return nil }
+func (c memConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+var (
+	createStmt = regexp.MustCompile(`(?s)^CREATE TABLE IF NOT EXISTS (\w+) \((.*)\)$`)
+	insertStmt = regexp.MustCompile(`^INSERT INTO (\w+) \((.*)\) VALUES`)
+	updateStmt = regexp.MustCompile(`^UPDATE (\w+) SET (.*) WHERE (.*)$`)
+	selectStmt = regexp.MustCompile(`^SELECT (.*) FROM (\w+) WHERE (.*)$`)
+	deleteStmt = regexp.MustCompile(`^DELETE FROM (\w+) WHERE (.*)$`)
+	assignment = regexp.MustCompile(`(\w+) = (\?|\$\d+)`)
+)
+
+// names returns the column names of a list of assignments or conditions e.g. a = ?, b = ?
+func names(s string) []string {
+	var columns []string
+	for _, m := range assignment.FindAllStringSubmatch(s, -1) {
+		columns = append(columns, m[1])
+	}
+	return columns
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	v := make([]driver.Value, len(args))
+	for i, arg := range args {
+		v[i] = arg.Value
+	}
+	return v
+}
+
+// match returns the rows of table whose columns equal args
+func (t *memTable) match(columns []string, args []driver.Value) []map[string]driver.Value {
+	var rows []map[string]driver.Value
+	for _, row := range t.rows {
+		ok := true
+		for i, column := range columns {
+			ok = ok && row[column] == args[i]
+		}
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (c memConn) table(name string) (*memTable, error) {
+	table, ok := c.db.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("no such table: %s", name)
+	}
+	return table, nil
+}
+
+func (c memConn) ExecContext(_ context.Context, query string, named []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	args := values(named)
+	switch {
+	case createStmt.MatchString(query):
+		m := createStmt.FindStringSubmatch(query)
+		if _, ok := c.db.tables[m[1]]; ok {
+			return driver.RowsAffected(0), nil
+		}
+		table := &memTable{rows: make(map[any]map[string]driver.Value)}
+		for _, line := range strings.Split(m[2], ",\n") {
+			table.columns = append(table.columns, strings.Fields(line)[0])
+		}
+		c.db.tables[m[1]] = table
+		return driver.RowsAffected(0), nil
+
+	case insertStmt.MatchString(query):
+		m := insertStmt.FindStringSubmatch(query)
+		table, err := c.table(m[1])
+		if err != nil {
+			return nil, err
+		}
+		columns := strings.Split(m[2], ", ")
+		if _, ok := table.rows[args[0]]; ok {
+			return nil, errors.New("UNIQUE constraint failed")
+		}
+		row := make(map[string]driver.Value)
+		for i, column := range columns {
+			row[column] = args[i]
+		}
+		table.rows[args[0]] = row
+		return driver.RowsAffected(1), nil
+
+	case updateStmt.MatchString(query):
+		m := updateStmt.FindStringSubmatch(query)
+		table, err := c.table(m[1])
+		if err != nil {
+			return nil, err
+		}
+		set := names(m[2])
+		rows := table.match(names(m[3]), args[len(set):])
+		for _, row := range rows {
+			for i, column := range set {
+				row[column] = args[i]
+			}
+		}
+		return driver.RowsAffected(len(rows)), nil
+
+	case deleteStmt.MatchString(query):
+		m := deleteStmt.FindStringSubmatch(query)
+		table, err := c.table(m[1])
+		if err != nil {
+			return nil, err
+		}
+		rows := table.match(names(m[2]), args)
+		for _, row := range rows {
+			delete(table.rows, row[table.columns[0]])
+		}
+		return driver.RowsAffected(len(rows)), nil
+	}
+	return nil, fmt.Errorf("unsupported statement: %s", query)
+}
+
+func (c memConn) QueryContext(_ context.Context, query string, named []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	m := selectStmt.FindStringSubmatch(query)
+	if m == nil {
+		return nil, fmt.Errorf("unsupported query: %s", query)
+	}
+	table, err := c.table(m[2])
+	if err != nil {
+		return nil, err
+	}
+
+	rows := &memRows{columns: strings.Split(m[1], ", ")}
+	for _, row := range table.match(names(m[3]), values(named)) {
+		record := make([]driver.Value, len(rows.columns))
+		for i, column := range rows.columns {
+			record[i] = row[column]
+		}
+		rows.records = append(rows.records, record)
+	}
+	return rows, nil
+}
+
+type memRows struct {
+	columns []string
+	records [][]driver.Value
+}
+
+func (r *memRows) Columns() []string { return r.columns }
+func (r *memRows) Close() error      { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.records) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.records[0])
+	r.records = r.records[1:]
+	return nil
+}
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db := sql.OpenDB(&memDB{tables: make(map[string]*memTable)})
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestSQLStore(t *testing.T) {
+	for name, dialect := range map[string]idempotency.Dialect{"question": idempotency.DialectQuestion, "dollar": idempotency.DialectDollar} {
+		t.Run(name, func(t *testing.T) {
+			db := openDB(t)
+			store := idempotency.NewSQLStore(db, "", dialect)
+			assert.NoError(t, store.Migrate(t.Context()))
+			// migrations can be run more than once
+			assert.NoError(t, store.Migrate(t.Context()))
+
+			testStore(t, store)
+
+			_, err := store.Get(t.Context(), "missing")
+			assert.ErrorIs(t, err, idempotency.ErrNotFound)
+
+			t.Run("test that expired pending keys are unknown", func(t *testing.T) {
+				store := idempotency.NewSQLStore(db, "leases", dialect)
+				store.Lease = 10 * time.Millisecond
+				assert.NoError(t, store.Migrate(t.Context()))
+				testLease(t, store)
+			})
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("idempotency key not found")
+
+// Store persists idempotency keys and the outcome of their calls
+type Store interface {
+	// Reserve saves a pending record for key and returns true if key is new. If key
+	// exists, its record is returned with false. Reserve must be atomic, so that only
+	// one of concurrent calls with the same key creates the record.
+	Reserve(ctx context.Context, key, requestHash string) (Record, bool, error)
+	// Complete saves the outcome of the call made with record.Key
+	Complete(ctx context.Context, record Record) error
+	// Get returns the record of key, or ErrNotFound
+	Get(ctx context.Context, key string) (Record, error)
+	// Delete removes key, so that the next call with it is sent again
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryStore is a Store that keeps records in memory. It is meant for tests and
+// single process deployments, records are lost when the process exits.
+type MemoryStore struct {
+	// Lease is how long pending records are held before their outcome is unknown, it
+	// defaults to DefaultLease
+	Lease time.Duration
+
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, key, requestHash string) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		return record, false, nil
+	}
+
+	now := time.Now().UTC()
+	record := Record{Key: key, RequestHash: requestHash, Status: StatusPending,
+		LeaseUntil: now.Add(lease(s.Lease)), CreatedAt: now, UpdatedAt: now}
+	s.records[key] = record
+	return record, true, nil
+}
+
+func lease(d time.Duration) time.Duration {
+	if d <= 0 {
+		return DefaultLease
+	}
+	return d
+}
+
+func (s *MemoryStore) Complete(_ context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.records[record.Key]
+	if !ok {
+		return ErrNotFound
+	}
+
+	current.Status = record.Status
+	current.Response = record.Response
+	current.Error = record.Error
+	current.UpdatedAt = time.Now().UTC()
+	s.records[record.Key] = current
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return Record{}, ErrNotFound
+	}
+	return record, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
@@ -0,0 +1,48 @@
+// Package csvx matches the header line of csv files to the fields read from their rows,
+// for files exported by providers and back offices that name the same column differently.
+package csvx
+
+import "strings"
+
+// Columns are the header names accepted for each field, matched without regard to case
+type Columns map[string][]string
+
+// Index is the position of the column of each field of a header line
+type Index map[string]int
+
+// Index returns the position of the column of each field found in header
+func (c Columns) Index(header []string) Index {
+	index := make(Index)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, names := range c {
+			for _, n := range names {
+				if n == name {
+					index[field] = i
+				}
+			}
+		}
+	}
+	return index
+}
+
+// Missing returns the first of fields that has no column, or an empty string when all of
+// them have one
+func (i Index) Missing(fields ...string) string {
+	for _, field := range fields {
+		if _, ok := i[field]; !ok {
+			return field
+		}
+	}
+	return ""
+}
+
+// Value returns the trimmed value of field in record, or an empty string when the field
+// has no column or the record is too short
+func (i Index) Value(record []string, field string) string {
+	n, ok := i[field]
+	if !ok || n >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[n])
+}
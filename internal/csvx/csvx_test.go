@@ -0,0 +1,24 @@
+package csvx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumns_Index(t *testing.T) {
+	columns := Columns{
+		"receipt": {"receipt", "transaction_id"},
+		"amount":  {"amount"},
+		"status":  {"status"},
+	}
+
+	index := columns.Index([]string{" Transaction_ID", "AMOUNT ", "narration"})
+	assert.Equal(t, Index{"receipt": 0, "amount": 1}, index)
+	assert.Equal(t, "", index.Missing("receipt", "amount"))
+	assert.Equal(t, "status", index.Missing("receipt", "status"))
+
+	assert.Equal(t, "100", index.Value([]string{"SGR123", " 100 "}, "amount"))
+	assert.Equal(t, "", index.Value([]string{"SGR123"}, "amount"))
+	assert.Equal(t, "", index.Value([]string{"SGR123", "100"}, "status"))
+}
@@ -0,0 +1,253 @@
+// Package exchange reads what the request hooks of the payments packages report about an
+// exchange with a provider: the bodies sent and received, the request id and result code
+// in the response, and the outcome of the request.
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/gorequest"
+)
+
+// Body is a request or response body that keeps what is read from it, so that it can be
+// inspected after it is sent or decoded
+type Body struct {
+	io.Reader
+	io.Closer
+	buf bytes.Buffer
+}
+
+// Capture wraps body in a Body, unless it is one already
+func Capture(body io.ReadCloser) io.ReadCloser {
+	if _, ok := body.(*Body); ok {
+		return body
+	}
+	b := &Body{Closer: body}
+	b.Reader = io.TeeReader(body, &b.buf)
+	return b
+}
+
+// Captured returns what was read from body if it was wrapped by Capture
+func Captured(body io.ReadCloser) []byte {
+	if b, ok := body.(*Body); ok {
+		return b.buf.Bytes()
+	}
+	return nil
+}
+
+// CaptureResponse returns an unmarshal hook named name that keeps the response body as it
+// is decoded, so that the complete hooks can read it with Captured
+func CaptureResponse(name string) gorequest.Hook {
+	return gorequest.Hook{
+		Name: name,
+		Fn: func(r *gorequest.Request) {
+			if r.Response == nil || r.Response.Body == nil {
+				return
+			}
+			r.Response.Body = Capture(r.Response.Body)
+		}}
+}
+
+// Fields names the fields of a provider's response bodies that identify a request and its
+// result. Each name is a path of JSON keys separated by dots e.g. meta.code; arrays along
+// the path are read at their first element. The first field present in a response is used.
+type Fields struct {
+	// RequestID are the fields that identify the request with the provider
+	RequestID []string
+	// ResultCode are the fields of the provider's result or error code
+	ResultCode []string
+}
+
+// DarajaFields are the fields of daraja responses. Errors carry a requestId and errorCode,
+// accepted requests a conversation or checkout request id and a ResponseCode.
+var DarajaFields = Fields{
+	RequestID:  []string{"requestId", "CheckoutRequestID", "ConversationID", "OriginatorConversationID"},
+	ResultCode: []string{"errorCode", "ResponseCode", "ResultCode"},
+}
+
+// QuikkFields are the fields of quikk responses. The id of the response resource
+// identifies the request, and the code of its meta or the status of its errors is the
+// result.
+var QuikkFields = Fields{
+	RequestID:  []string{"data.id"},
+	ResultCode: []string{"meta.code", "errors.status"},
+}
+
+// TandaFields are the fields of tanda responses, the tracking id and status of payment
+// requests and the status of errors
+var TandaFields = Fields{
+	RequestID:  []string{"trackingId"},
+	ResultCode: []string{"status"},
+}
+
+// Lookup returns the request id and result code of a JSON response body. Either is empty
+// when the body has none of its fields.
+func (f Fields) Lookup(body []byte) (requestID, resultCode string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+
+	var v any
+	decoder := jsoniter.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return "", ""
+	}
+	return lookup(v, f.RequestID), lookup(v, f.ResultCode)
+}
+
+// lookup returns the value of the first of fields present in v
+func lookup(v any, fields []string) string {
+	for _, field := range fields {
+		if value, ok := path(v, strings.Split(field, ".")); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func path(v any, keys []string) (string, bool) {
+	for len(keys) > 0 {
+		switch t := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = t[keys[0]]; !ok {
+				return "", false
+			}
+			keys = keys[1:]
+		case []any:
+			if len(t) == 0 {
+				return "", false
+			}
+			v = t[0]
+		default:
+			return "", false
+		}
+	}
+
+	switch t := v.(type) {
+	case string:
+		return t, t != ""
+	case interface{ String() string }:
+		return t.String(), true
+	default:
+		return "", false
+	}
+}
+
+// Outcome classifies how a request completed
+type Outcome string
+
+const (
+	// OutcomeSuccess is a request the provider accepted
+	OutcomeSuccess Outcome = "success"
+	// OutcomeProviderError is a request the provider responded to with an error
+	OutcomeProviderError Outcome = "provider_error"
+	// OutcomeTransportError is a request that got no response
+	OutcomeTransportError Outcome = "transport_error"
+	// OutcomeTimeout is a request whose deadline passed before it got a response
+	OutcomeTimeout Outcome = "timeout"
+	// OutcomeNotSent is a request that failed before it was sent, e.g. in validation
+	// or authentication
+	OutcomeNotSent Outcome = "not_sent"
+)
+
+// Classify returns the outcome of a completed request
+func Classify(r *gorequest.Request) Outcome {
+	if r.Error == nil {
+		return OutcomeSuccess
+	}
+
+	var netErr net.Error
+	if errors.Is(r.Error, context.DeadlineExceeded) || (errors.As(r.Error, &netErr) && netErr.Timeout()) {
+		return OutcomeTimeout
+	}
+	if r.Response != nil && r.Response.StatusCode != 0 {
+		return OutcomeProviderError
+	}
+	if r.AttemptTime.IsZero() {
+		return OutcomeNotSent
+	}
+	return OutcomeTransportError
+}
+
+// DecodeError is an error response of a provider whose body could not be decoded
+type DecodeError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *DecodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// coded is implemented by the decoded error responses of the providers
+type coded interface {
+	ResultCode() string
+}
+
+// ambiguousCodes are the error codes of providers that are returned when the provider
+// may still process the request
+var ambiguousCodes = map[string]bool{
+	"500.002.1001": true, // service temporarily unavailable
+}
+
+// transientCodes are the error codes of providers that throttle requests, the request is
+// not processed and can be sent again
+var transientCodes = map[string]bool{
+	"500.003.02": true, // spike arrest violation
+	"500.003.03": true, // quota violation
+}
+
+func resultCode(err error) string {
+	var c coded
+	if errors.As(err, &c) {
+		return c.ResultCode()
+	}
+	return ""
+}
+
+// Ambiguous reports whether err leaves it unknown if the provider processed the request,
+// e.g. a timeout, a server error without a decodable body, or an error code returned
+// while the provider is unavailable. Other errors are either provider error responses
+// or errors returned before the request was sent, and mean that it was not processed.
+func Ambiguous(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return true
+	}
+
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) && decodeErr.StatusCode >= http.StatusInternalServerError {
+		return true
+	}
+	return ambiguousCodes[resultCode(err)]
+}
+
+// Transient reports whether err is a rejection of a provider that is throttling requests.
+// The request was not processed and can be sent again later.
+func Transient(err error) bool {
+	if err == nil {
+		return false
+	}
+	return transientCodes[resultCode(err)]
+}
@@ -0,0 +1,144 @@
+// Package redact removes credentials and personal data from the headers and bodies of
+// provider exchanges before they are written to logs or files.
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Redacted replaces the values of redacted headers and fields
+const Redacted = "REDACTED"
+
+// DefaultHeaders are the headers that carry credentials or session cookies
+var DefaultHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// Redactor redacts headers and JSON or form fields by name, and masks MSISDNs
+type Redactor struct {
+	headers     []string
+	keys        map[string]struct{}
+	keepMSISDNs bool
+}
+
+// New returns a Redactor of headers and of the fields named keys, matched without regard
+// to case. MSISDNs are masked unless keepMSISDNs is true.
+func New(headers, keys []string, keepMSISDNs bool) Redactor {
+	r := Redactor{
+		headers:     headers,
+		keys:        make(map[string]struct{}, len(keys)),
+		keepMSISDNs: keepMSISDNs,
+	}
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+	}
+	return r
+}
+
+// Header returns a copy of header with the redacted headers replaced
+func (r Redactor) Header(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range r.headers {
+		if header.Get(name) != "" {
+			header.Set(name, Redacted)
+		}
+	}
+	return header
+}
+
+// Key reports whether the field key is redacted
+func (r Redactor) Key(key string) bool {
+	_, ok := r.keys[strings.ToLower(key)]
+	return ok
+}
+
+// Body redacts the fields of a JSON or form body, and masks the MSISDNs in any body
+func (r Redactor) Body(body []byte, contentType string) string {
+	text := string(body)
+
+	var v any
+	decoder := jsoniter.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err == nil {
+		if b, err := jsoniter.Marshal(r.Value(v)); err == nil {
+			return string(b)
+		}
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(text); err == nil {
+			for key := range form {
+				if r.Key(key) {
+					form.Set(key, Redacted)
+				}
+			}
+			text = form.Encode()
+		}
+	}
+	return r.String(text)
+}
+
+// Value redacts the fields and masks the MSISDNs of a JSON value decoded with UseNumber,
+// in place. MSISDNs decoded as numbers are masked with zeros so that the value still encodes as a number.
+func (r Redactor) Value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if r.Key(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = r.Value(value)
+		}
+	case []any:
+		for i := range v {
+			v[i] = r.Value(v[i])
+		}
+	case string:
+		return r.String(v)
+	case json.Number:
+		if r.keepMSISDNs {
+			return v
+		}
+		return json.Number(MaskMSISDNs(string(v), '0'))
+	}
+	return v
+}
+
+// String masks the MSISDNs in text
+func (r Redactor) String(text string) string {
+	if r.keepMSISDNs {
+		return text
+	}
+	return MaskMSISDNs(text, '*')
+}
+
+// reMSISDN matches kenyan mobile numbers in the international and local formats
+var reMSISDN = regexp.MustCompile(`\+?254[17]\d{8}|0[17]\d{8}`)
+
+// MaskMSISDNs replaces all but the first 4 and last 3 characters of the MSISDNs in text
+// with mask, the way quikk masks them e.g. 2547*****678. Digits within longer numbers
+// such as timestamps are left alone.
+func MaskMSISDNs(text string, mask byte) string {
+	digit := func(i int) bool { return i >= 0 && i < len(text) && text[i] >= '0' && text[i] <= '9' }
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range reMSISDN.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if digit(start-1) || digit(end) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(text[start : start+4])
+		b.WriteString(strings.Repeat(string(mask), end-start-7))
+		b.WriteString(text[end-3 : end])
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
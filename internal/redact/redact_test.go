@@ -0,0 +1,53 @@
+package redact
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskMSISDNs(t *testing.T) {
+	tcs := map[string]struct {
+		text     string
+		expected string
+	}{
+		"international":   {"254712345678", "2547*****678"},
+		"with plus":       {"+254112345678", "+254******678"},
+		"local":           {"0712345678", "0712***678"},
+		"in text":         {"Sent to 254712345678 - John", "Sent to 2547*****678 - John"},
+		"timestamp":       {"20191219102115", "20191219102115"},
+		"longer number":   {"2547123456789", "2547123456789"},
+		"checkout id":     {"ws_CO_0712345678", "ws_CO_0712***678"},
+		"no msisdn":       {"600000", "600000"},
+		"several msisdns": {"254712345678,0798765432", "2547*****678,0798***432"},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, MaskMSISDNs(tc.text, '*'))
+		})
+	}
+}
+
+func TestRedactor_Body(t *testing.T) {
+	r := New([]string{"Authorization"}, []string{"Password", "access_token"}, false)
+
+	t.Run("test that json fields are redacted", func(t *testing.T) {
+		body := r.Body([]byte(`{"password":"secret","PhoneNumber":254712345678,"nested":[{"access_token":"t","to":"0712345678"}]}`), "application/json")
+		assert.JSONEq(t, `{"password":"REDACTED","PhoneNumber":254700000678,"nested":[{"access_token":"REDACTED","to":"0712***678"}]}`, body)
+	})
+
+	t.Run("test that form fields are redacted", func(t *testing.T) {
+		body := r.Body([]byte("grant_type=client_credentials&password=secret"), "application/x-www-form-urlencoded")
+		assert.Equal(t, "grant_type=client_credentials&password=REDACTED", body)
+	})
+
+	t.Run("test that headers are redacted", func(t *testing.T) {
+		header := http.Header{"Authorization": {"Bearer token"}, "Accept": {"application/json"}}
+		redacted := r.Header(header)
+		assert.Equal(t, Redacted, redacted.Get("Authorization"))
+		assert.Equal(t, "application/json", redacted.Get("Accept"))
+		assert.Equal(t, "Bearer token", header.Get("Authorization"))
+	})
+}
@@ -0,0 +1,289 @@
+package lifecycle
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+// Submitted is the event of a payment being sent to provider. The ids known before
+// sending, e.g. the OriginatorConversationID or tanda reference, should be included.
+func Submitted(provider Provider, correlationIDs ...string) Event {
+	return Event{Provider: provider, State: StateSubmitted, CorrelationIDs: correlationIDs, Source: "lifecycle.Submitted"}
+}
+
+// Failed is the event of a payment that failed before it was accepted, e.g. a request
+// error response
+func Failed(provider Provider, reason string, correlationIDs ...string) Event {
+	return Event{Provider: provider, State: StateFailed, ResultDesc: reason, CorrelationIDs: correlationIDs, Source: "lifecycle.Failed"}
+}
+
+// TimedOut is the event of a payment without a result in the expected time, e.g. a
+// request timeout or a daraja QueueTimeOutURL notification
+func TimedOut(provider Provider, reason string, correlationIDs ...string) Event {
+	return Event{Provider: provider, State: StateTimedOut, ResultDesc: reason, CorrelationIDs: correlationIDs, Source: "lifecycle.TimedOut"}
+}
+
+// result returns a completed or failed event. The receipt of completed payments is
+// added to the correlation ids, so that reversals can be matched to the payment.
+func result(provider Provider, success bool, receipt string, event Event) Event {
+	event.Provider = provider
+	event.State = StateFailed
+	if success {
+		event.State = StateCompleted
+		event.Receipt = receipt
+		if receipt != "" {
+			event.CorrelationIDs = append(event.CorrelationIDs, receipt)
+		}
+	}
+	return event
+}
+
+// DARAJA EVENTS
+
+func darajaResponse(res daraja.ResponseDefault, source string) Event {
+	event := Event{
+		Provider:       ProviderDaraja,
+		State:          StateAccepted,
+		CorrelationIDs: []string{res.OriginatorConversationID, res.ConversationID},
+		ResultCode:     res.ResponseCode.String(),
+		ResultDesc:     res.ResponseDescription,
+		Source:         source,
+	}
+	if res.ResponseCode != daraja.SuccessSubmission {
+		event.State = StateFailed
+	}
+	return event
+}
+
+// FromDarajaB2C returns the event of a b2c response
+func FromDarajaB2C(res daraja.ResponseB2C) Event {
+	return darajaResponse(daraja.ResponseDefault(res), "daraja.ResponseB2C")
+}
+
+// FromDarajaB2B returns the event of a b2b response
+func FromDarajaB2B(res daraja.ResponseB2B) Event {
+	return darajaResponse(daraja.ResponseDefault(res), "daraja.ResponseB2B")
+}
+
+// FromDarajaB2CTopUp returns the event of a b2c account top-up response. Top-up results
+// are b2b results, and are read with FromDarajaB2BResult.
+func FromDarajaB2CTopUp(res daraja.ResponseB2CTopUp) Event {
+	return darajaResponse(daraja.ResponseDefault(res), "daraja.ResponseB2CTopUp")
+}
+
+// FromDarajaB2Pochi returns the event of a b2pochi response. B2pochi results are b2c
+// results, and are read with FromDarajaB2CResult.
+func FromDarajaB2Pochi(res daraja.ResponseB2Pochi) Event {
+	return darajaResponse(daraja.ResponseDefault(res), "daraja.ResponseB2Pochi")
+}
+
+// FromDarajaTaxRemittance returns the event of a tax remittance response. Tax remittance
+// results are b2b results, and are read with FromDarajaB2BResult.
+func FromDarajaTaxRemittance(res daraja.ResponseTaxRemittance) Event {
+	return darajaResponse(daraja.ResponseDefault(res), "daraja.ResponseTaxRemittance")
+}
+
+// FromDarajaB2BExpressCheckout returns the event of a b2b express checkout response. The
+// response has no ids, so the event is matched by the RequestRefID of the request.
+func FromDarajaB2BExpressCheckout(requestRefID string, res daraja.ResponseB2BExpressCheckout) Event {
+	event := Event{
+		Provider:       ProviderDaraja,
+		State:          StateAccepted,
+		CorrelationIDs: []string{requestRefID},
+		ResultCode:     res.Code,
+		ResultDesc:     res.Status,
+		Source:         "daraja.ResponseB2BExpressCheckout",
+	}
+	if res.Code != "0" {
+		event.State = StateFailed
+	}
+	return event
+}
+
+// FromDarajaC2BExpress returns the event of a stk push response
+func FromDarajaC2BExpress(res daraja.ResponseC2BExpress) Event {
+	event := Event{
+		Provider:       ProviderDaraja,
+		State:          StateAccepted,
+		CorrelationIDs: []string{res.CheckoutRequestID, res.MerchantRequestID},
+		ResultCode:     res.ResponseCode.String(),
+		ResultDesc:     res.ResponseDescription,
+		Source:         "daraja.ResponseC2BExpress",
+	}
+	if res.ResponseCode != daraja.SuccessSubmission {
+		event.State = StateFailed
+	}
+	return event
+}
+
+func darajaResult(code daraja.ResultCode, desc, receipt, source string, ids ...string) Event {
+	return result(ProviderDaraja, code == daraja.ResultCodeSuccess, receipt, Event{
+		CorrelationIDs: ids,
+		ResultCode:     strconv.Itoa(int(code)),
+		ResultDesc:     desc,
+		Source:         source,
+	})
+}
+
+// FromDarajaB2CResult returns the event of a b2c or b2pochi result webhook
+func FromDarajaB2CResult(w daraja.WebhookRequestB2C) Event {
+	r := w.Result
+	return darajaResult(r.ResultCode, r.ResultDesc, r.TransactionID, "daraja.WebhookRequestB2C",
+		r.OriginatorConversationID, r.ConversationID)
+}
+
+// FromDarajaB2BResult returns the event of a b2b, b2c account top-up or tax remittance result
+// webhook
+func FromDarajaB2BResult(w daraja.WebhookRequestB2B) Event {
+	r := w.Result
+	return darajaResult(r.ResultCode, r.ResultDesc, r.TransactionID, "daraja.WebhookRequestB2B",
+		r.OriginatorConversationID, r.ConversationID)
+}
+
+// FromDarajaB2BExpressCheckoutResult returns the event of a b2b express checkout callback,
+// matched by its requestId
+func FromDarajaB2BExpressCheckoutResult(w daraja.WebhookRequestB2BExpressCheckout) Event {
+	event := FromDarajaB2BResult(w.B2B())
+	event.Source = "daraja.WebhookRequestB2BExpressCheckout"
+	return event
+}
+
+// FromDarajaC2BExpressResult returns the event of a stk push callback
+func FromDarajaC2BExpressResult(w daraja.WebhookRequestC2BExpress) Event {
+	callback := w.Body.StkCallback
+
+	var receipt string
+	if callback.CallbackMetadata != nil {
+		for _, item := range callback.CallbackMetadata.Item {
+			if item.Name == "MpesaReceiptNumber" && item.Value != nil {
+				receipt = fmt.Sprint(item.Value)
+			}
+		}
+	}
+	return darajaResult(callback.ResultCode, callback.ResultDesc, receipt, "daraja.WebhookRequestC2BExpress",
+		callback.CheckoutRequestID, callback.MerchantRequestID)
+}
+
+// FromDarajaReversalResult returns the event that reverses the original payment of a
+// successful reversal result. The payment is matched by the OriginalTransactionID result
+// parameter, which is the receipt of the payment. It returns false for failed reversals,
+// which do not change the original payment.
+func FromDarajaReversalResult(w daraja.WebhookRequestC2BReversal) (Event, bool) {
+	r := w.Result
+	if r.ResultCode != daraja.ResultCodeSuccess || r.ResultParameters == nil {
+		return Event{}, false
+	}
+
+	for _, param := range r.ResultParameters.ResultParameter {
+		if param.Key == "OriginalTransactionID" && param.Value != nil {
+			return Event{
+				Provider:       ProviderDaraja,
+				State:          StateReversed,
+				CorrelationIDs: []string{fmt.Sprint(param.Value)},
+				ResultCode:     strconv.Itoa(int(r.ResultCode)),
+				ResultDesc:     r.ResultDesc,
+				Source:         "daraja.WebhookRequestC2BReversal",
+			}, true
+		}
+	}
+	return Event{}, false
+}
+
+// QUIKK EVENTS
+
+// FromQuikkResponse returns the event of a charge, payout or transfer response, matched
+// by its resource_id. The resource_id is the txn_charge_id or response_id of the webhook.
+func FromQuikkResponse(res quikk.ResponseDefault) Event {
+	event := Event{Provider: ProviderQuikk, State: StateAccepted, Source: "quikk.ResponseDefault"}
+	if res.Data != nil {
+		event.CorrelationIDs = []string{res.Data.Attributes.ResourceID}
+	}
+	if res.Meta != nil && res.Meta.Status == "FAIL" {
+		event.State = StateFailed
+		event.ResultCode = string(res.Meta.Code)
+		event.ResultDesc = res.Meta.Detail
+	}
+	return event
+}
+
+// quikkResult returns the event of a quikk webhook matched by id. The data id of quikk
+// webhooks is a counter that is repeated across payments, so it is not used.
+func quikkResult[T any](w quikk.WebhookResult[T], receipt, source, id string) Event {
+	event := Event{CorrelationIDs: []string{id}, Source: source}
+
+	failed := w.Meta != nil && w.Meta.Status == "FAIL"
+	if failed {
+		event.ResultCode = string(w.Meta.Code)
+		event.ResultDesc = w.Meta.Detail
+	}
+	return result(ProviderQuikk, !failed, receipt, event)
+}
+
+// FromQuikkCharge returns the event of a charge webhook, matched by its txn_charge_id
+func FromQuikkCharge(w quikk.WebhookResult[quikk.WebhookAttributesCharge]) Event {
+	a := w.Data.Attributes
+	return quikkResult(w, a.TxnID, "quikk.WebhookAttributesCharge", a.TxnChargeID)
+}
+
+// FromQuikkPayout returns the event of a payout webhook, matched by its response_id
+func FromQuikkPayout(w quikk.WebhookResult[quikk.WebhookAttributesPayout]) Event {
+	a := w.Data.Attributes
+	return quikkResult(w, a.TxnID, "quikk.WebhookAttributesPayout", a.ResponseID)
+}
+
+// FromQuikkTransfer returns the event of a transfer webhook, matched by its response_id
+func FromQuikkTransfer(w quikk.WebhookResult[quikk.WebhookAttributesTransfer]) Event {
+	a := w.Data.Attributes
+	return quikkResult(w, a.TxnID, "quikk.WebhookAttributesTransfer", a.ResponseID)
+}
+
+// TANDA EVENTS
+
+// tandaState maps a tanda payment status to a State
+func tandaState(status tanda.PaymentStatus) State {
+	switch status {
+	case tanda.PaymentStatusS000000:
+		return StateCompleted
+	case tanda.PaymentStatusP202000:
+		return StateAccepted
+	default:
+		return StateFailed
+	}
+}
+
+// FromTandaResponse returns the event of a payment response
+func FromTandaResponse(res tanda.ResponsePayment) Event {
+	return Event{
+		Provider:       ProviderTanda,
+		State:          tandaState(res.Status),
+		CorrelationIDs: []string{res.TrackingID, res.Reference},
+		ResultCode:     string(res.Status),
+		ResultDesc:     res.Message,
+		Source:         "tanda.ResponsePayment",
+	}
+}
+
+// FromTandaIPN returns the event of a payment status notification, matched by its trackingId
+func FromTandaIPN(w tanda.WebhookRequestPaymentStatus) Event {
+	event := Event{
+		Provider:       ProviderTanda,
+		State:          tandaState(w.Status),
+		CorrelationIDs: []string{w.TrackingID, w.Reference},
+		ResultCode:     string(w.Status),
+		ResultDesc:     w.Message,
+		Source:         "tanda.WebhookRequestPaymentStatus",
+	}
+	if event.State == StateCompleted {
+		// the provider's reference e.g. the M-PESA receipt, is sent in the result
+		receipt := w.Result.Ref
+		if receipt == "" {
+			receipt = w.TransactionID
+		}
+		event = result(ProviderTanda, true, receipt, event)
+	}
+	return event
+}
@@ -0,0 +1,188 @@
+// Package lifecycle tracks payments through their states, from created to completed,
+// failed, reversed or timed out, and enforces the valid transitions between them.
+//
+// Events are built from the client responses and webhook models of daraja, quikk and
+// tanda, and applied to payments with a Machine. Payments and their events are persisted
+// through a Store. Webhooks are matched to the payment they belong to by the ids the
+// provider returns, e.g. the daraja ConversationID or CheckoutRequestID, the quikk
+// response_id or the tanda trackingId.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/SirWaithaka/payments/money"
+)
+
+// Provider identifies the payment provider of a payment
+type Provider string
+
+const (
+	ProviderDaraja Provider = "daraja"
+	ProviderQuikk  Provider = "quikk"
+	ProviderTanda  Provider = "tanda"
+)
+
+// Payment is a payment tracked through its lifecycle
+type Payment struct {
+	// ID is the caller's identifier of the payment
+	ID       string
+	Provider Provider
+	// Operation is the client operation used to send the payment e.g. daraja.OperationB2C
+	Operation string
+	Amount    money.Money
+	State     State
+	// CorrelationIDs are the provider ids of the payment, used to match webhooks
+	CorrelationIDs []string
+	// Receipt is the provider's transaction id e.g. the M-PESA receipt number
+	Receipt    string
+	ResultCode string
+	ResultDesc string
+	// Version is incremented on every update, stores use it to reject concurrent updates
+	Version   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Event is a change in the lifecycle of a payment
+type Event struct {
+	PaymentID string
+	Provider  Provider
+	// State is the state the payment moves to
+	State State
+	// CorrelationIDs are provider ids found in the event. They are added to the
+	// payment, and are used to find the payment when PaymentID is not known.
+	CorrelationIDs []string
+	Receipt        string
+	ResultCode     string
+	ResultDesc     string
+	// Source describes where the event came from e.g. "daraja.WebhookRequestB2C"
+	Source string
+	At     time.Time
+}
+
+// Machine applies events to payments, and persists them in a Store
+type Machine struct {
+	store Store
+	now   func() time.Time
+}
+
+func New(store Store) *Machine {
+	return &Machine{store: store, now: time.Now}
+}
+
+// Create records a new payment in StateCreated
+func (m *Machine) Create(ctx context.Context, payment Payment) (Payment, error) {
+	if payment.ID == "" {
+		return Payment{}, errors.New("payment id is required")
+	}
+
+	now := m.now().UTC()
+	payment.CorrelationIDs = slices.DeleteFunc(slices.Clone(payment.CorrelationIDs), func(id string) bool { return id == "" })
+	payment.State = StateCreated
+	payment.Version = 1
+	payment.CreatedAt, payment.UpdatedAt = now, now
+
+	event := Event{PaymentID: payment.ID, Provider: payment.Provider, State: StateCreated,
+		CorrelationIDs: payment.CorrelationIDs, Source: "lifecycle.Create", At: now}
+	if err := m.store.Create(ctx, payment, event); err != nil {
+		return Payment{}, err
+	}
+	return payment, nil
+}
+
+// Apply applies event to the payment with event.PaymentID, or if it is empty, to the
+// payment matching one of event.CorrelationIDs. An event for the state the payment is
+// already in is a repeated notification, and only adds its ids to the payment.
+func (m *Machine) Apply(ctx context.Context, event Event) (Payment, error) {
+	if event.At.IsZero() {
+		event.At = m.now().UTC()
+	}
+
+	// retry updates that lose a race with a concurrent update of the same payment
+	for attempt := 0; ; attempt++ {
+		payment, err := m.find(ctx, event)
+		if err != nil {
+			return Payment{}, err
+		}
+
+		updated, err := transition(payment, event)
+		if err != nil {
+			return payment, err
+		}
+
+		event.PaymentID = payment.ID
+		err = m.store.Update(ctx, updated, event)
+		if errors.Is(err, ErrConflict) && attempt < 3 {
+			continue
+		}
+		if err != nil {
+			return payment, err
+		}
+		return updated, nil
+	}
+}
+
+func (m *Machine) find(ctx context.Context, event Event) (Payment, error) {
+	if event.PaymentID != "" {
+		return m.store.Get(ctx, event.PaymentID)
+	}
+
+	for _, id := range event.CorrelationIDs {
+		payment, err := m.store.Find(ctx, event.Provider, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return payment, err
+	}
+	return Payment{}, ErrNotFound
+}
+
+// transition returns payment updated with event. A submitted or accepted event for a
+// payment that is already past that state, e.g. a sync response that arrives after the
+// result, only adds its correlation ids.
+func transition(payment Payment, event Event) (Payment, error) {
+	late := false
+	if payment.State != event.State && !payment.State.CanTransition(event.State) {
+		late = (event.State == StateSubmitted || event.State == StateAccepted) && event.State.precedes(payment.State)
+		if !late {
+			return payment, &TransitionError{PaymentID: payment.ID, From: payment.State, To: event.State}
+		}
+	}
+
+	for _, id := range event.CorrelationIDs {
+		if id != "" && !slices.Contains(payment.CorrelationIDs, id) {
+			payment.CorrelationIDs = append(slices.Clip(payment.CorrelationIDs), id)
+		}
+	}
+	payment.Version++
+	payment.UpdatedAt = event.At
+	if late {
+		return payment, nil
+	}
+
+	payment.State = event.State
+	if event.Receipt != "" {
+		payment.Receipt = event.Receipt
+	}
+	if event.ResultCode != "" {
+		payment.ResultCode = event.ResultCode
+	}
+	if event.ResultDesc != "" {
+		payment.ResultDesc = event.ResultDesc
+	}
+	return payment, nil
+}
+
+// History returns the events of the payment with id, oldest first
+func (m *Machine) History(ctx context.Context, id string) ([]Event, error) {
+	return m.store.Events(ctx, id)
+}
+
+// Get returns the payment with id
+func (m *Machine) Get(ctx context.Context, id string) (Payment, error) {
+	return m.store.Get(ctx, id)
+}
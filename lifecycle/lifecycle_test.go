@@ -0,0 +1,303 @@
+package lifecycle_test
+
+import (
+	"sync"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/lifecycle"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+func decode[T any](t *testing.T, body string) T {
+	t.Helper()
+
+	var v T
+	assert.NoError(t, jsoniter.Unmarshal([]byte(body), &v))
+	return v
+}
+
+func newPayment(t *testing.T, m *lifecycle.Machine, id string, provider lifecycle.Provider) {
+	t.Helper()
+
+	_, err := m.Create(t.Context(), lifecycle.Payment{ID: id, Provider: provider, Amount: money.FromUnits(100, money.KES)})
+	assert.NoError(t, err)
+}
+
+func TestMachine_Daraja(t *testing.T) {
+	ctx := t.Context()
+	m := lifecycle.New(lifecycle.NewMemoryStore())
+	newPayment(t, m, "payout-1", lifecycle.ProviderDaraja)
+
+	_, err := m.Apply(ctx, lifecycle.Event{PaymentID: "payout-1", State: lifecycle.StateSubmitted, CorrelationIDs: []string{"16740-34861180-1"}})
+	assert.NoError(t, err)
+
+	response := daraja.ResponseB2C{
+		ConversationID:           "AG_20191219_00005797af5d7d75f652",
+		OriginatorConversationID: "16740-34861180-1",
+		ResponseCode:             daraja.SuccessSubmission,
+		ResponseDescription:      "Accept the service request successfully.",
+	}
+	// responses are matched by the OriginatorConversationID
+	payment, err := m.Apply(ctx, lifecycle.FromDarajaB2C(response))
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateAccepted, payment.State)
+
+	// results are matched by the ConversationID
+	result := decode[daraja.WebhookRequestB2C](t, `{"Result":{"ResultType":0,"ResultCode":0,
+"ResultDesc":"The service request is processed successfully.","OriginatorConversationID":"other",
+"ConversationID":"AG_20191219_00005797af5d7d75f652","TransactionID":"NLJ41HAY6Q"}}`)
+	payment, err = m.Apply(ctx, lifecycle.FromDarajaB2CResult(result))
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateCompleted, payment.State)
+	assert.Equal(t, "NLJ41HAY6Q", payment.Receipt)
+	assert.Equal(t, "0", payment.ResultCode)
+
+	// repeated webhooks do not fail
+	_, err = m.Apply(ctx, lifecycle.FromDarajaB2CResult(result))
+	assert.NoError(t, err)
+
+	// a completed payment cannot fail
+	failed := result
+	failed.Result.ResultCode = daraja.ResultCodeInsufficientBalance
+	_, err = m.Apply(ctx, lifecycle.FromDarajaB2CResult(failed))
+	assert.ErrorIs(t, err, lifecycle.ErrInvalidTransition)
+
+	// reversals are matched by the receipt
+	reversal := decode[daraja.WebhookRequestC2BReversal](t, `{"Result":{"ResultType":0,"ResultCode":0,
+"ResultDesc":"The service request is processed successfully.","ConversationID":"AG_20191219_0000",
+"TransactionID":"NLJ61HAY6X","ResultParameters":{"ResultParameter":[{"Key":"OriginalTransactionID","Value":"NLJ41HAY6Q"}]}}}`)
+	event, ok := lifecycle.FromDarajaReversalResult(reversal)
+	assert.True(t, ok)
+	payment, err = m.Apply(ctx, event)
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateReversed, payment.State)
+
+	history, err := m.History(ctx, "payout-1")
+	assert.NoError(t, err)
+	var states []lifecycle.State
+	for _, e := range history {
+		states = append(states, e.State)
+	}
+	assert.Equal(t, []lifecycle.State{lifecycle.StateCreated, lifecycle.StateSubmitted, lifecycle.StateAccepted,
+		lifecycle.StateCompleted, lifecycle.StateCompleted, lifecycle.StateReversed}, states)
+}
+
+func TestMachine_DarajaC2BExpress(t *testing.T) {
+	ctx := t.Context()
+	m := lifecycle.New(lifecycle.NewMemoryStore())
+	newPayment(t, m, "order-1", lifecycle.ProviderDaraja)
+
+	_, err := m.Apply(ctx, lifecycle.Event{PaymentID: "order-1", State: lifecycle.StateSubmitted})
+	assert.NoError(t, err)
+
+	response := daraja.ResponseC2BExpress{
+		MerchantRequestID: "29115-34620561-1",
+		CheckoutRequestID: "ws_CO_191220191020363925",
+		ResponseCode:      daraja.SuccessSubmission,
+	}
+	_, err = m.Apply(ctx, lifecycle.Event{PaymentID: "order-1", State: lifecycle.StateAccepted,
+		CorrelationIDs: lifecycle.FromDarajaC2BExpress(response).CorrelationIDs})
+	assert.NoError(t, err)
+
+	callback := decode[daraja.WebhookRequestC2BExpress](t, `{"Body":{"stkCallback":{"MerchantRequestID":"29115-34620561-1",
+"CheckoutRequestID":"ws_CO_191220191020363925","ResultCode":1032,"ResultDesc":"Request cancelled by user."}}}`)
+	payment, err := m.Apply(ctx, lifecycle.FromDarajaC2BExpressResult(callback))
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateFailed, payment.State)
+	assert.Equal(t, "1032", payment.ResultCode)
+	assert.Equal(t, "Request cancelled by user.", payment.ResultDesc)
+}
+
+func TestMachine_DarajaB2BExpressCheckout(t *testing.T) {
+	ctx := t.Context()
+	m := lifecycle.New(lifecycle.NewMemoryStore())
+	newPayment(t, m, "order-1", lifecycle.ProviderDaraja)
+
+	requestRefID := "404e1aec-19e0-4ce3-973d-bd92e94c8021"
+	_, err := m.Apply(ctx, lifecycle.Event{PaymentID: "order-1", State: lifecycle.StateSubmitted, CorrelationIDs: []string{requestRefID}})
+	assert.NoError(t, err)
+
+	response := daraja.ResponseB2BExpressCheckout{Code: "0", Status: "USSD Initiated Successfully"}
+	payment, err := m.Apply(ctx, lifecycle.FromDarajaB2BExpressCheckout(requestRefID, response))
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateAccepted, payment.State)
+
+	callback := decode[daraja.WebhookRequestB2BExpressCheckout](t, `{"resultCode":"0","resultDesc":"The service request is processed successfully.",
+"amount":"71.0","requestId":"404e1aec-19e0-4ce3-973d-bd92e94c8021","resultType":"0","conversationID":"AG_20230426_2010434680d9f5a73766",
+"transactionId":"RDQ01NFT1Q","status":"SUCCESS"}`)
+	payment, err = m.Apply(ctx, lifecycle.FromDarajaB2BExpressCheckoutResult(callback))
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateCompleted, payment.State)
+	assert.Equal(t, "RDQ01NFT1Q", payment.Receipt)
+}
+
+func TestMachine_Quikk(t *testing.T) {
+	ctx := t.Context()
+	m := lifecycle.New(lifecycle.NewMemoryStore())
+	newPayment(t, m, "payout-1", lifecycle.ProviderQuikk)
+
+	_, err := m.Apply(ctx, lifecycle.Event{PaymentID: "payout-1", State: lifecycle.StateSubmitted})
+	assert.NoError(t, err)
+
+	response := decode[quikk.ResponseDefault](t, `{"data":{"id":"ref-1","type":"payout","attributes":{"resource_id":"AG_20190905_00005f0dcb86732c611c"}}}`)
+	_, err = m.Apply(ctx, lifecycle.Event{PaymentID: "payout-1", State: lifecycle.StateAccepted,
+		CorrelationIDs: lifecycle.FromQuikkResponse(response).CorrelationIDs})
+	assert.NoError(t, err)
+
+	webhook := decode[quikk.WebhookResult[quikk.WebhookAttributesPayout]](t, `{"data":{"type":"payout","id":"1",
+"attributes":{"txn_id":"NH90HBCXPM","response_id":"AG_20190905_00005f0dcb86732c611c"}},
+"meta":{"status":"FAIL","code":"17","detail":"The initiator is not allowed to initiate this request"}}`)
+	payment, err := m.Apply(ctx, lifecycle.FromQuikkPayout(webhook))
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateFailed, payment.State)
+	assert.Equal(t, "17", payment.ResultCode)
+	assert.Empty(t, payment.Receipt)
+}
+
+func TestMachine_QuikkDataID(t *testing.T) {
+	ctx := t.Context()
+	m := lifecycle.New(lifecycle.NewMemoryStore())
+
+	payout := func(id, ref, resourceID string) {
+		newPayment(t, m, id, lifecycle.ProviderQuikk)
+		_, err := m.Apply(ctx, lifecycle.Event{PaymentID: id, State: lifecycle.StateSubmitted})
+		assert.NoError(t, err)
+
+		response := decode[quikk.ResponseDefault](t, `{"data":{"id":"`+ref+`","type":"payout","attributes":{"resource_id":"`+resourceID+`"}}}`)
+		_, err = m.Apply(ctx, lifecycle.Event{PaymentID: id, State: lifecycle.StateAccepted,
+			CorrelationIDs: lifecycle.FromQuikkResponse(response).CorrelationIDs})
+		assert.NoError(t, err)
+	}
+	payout("payout-1", "ref-1", "AG_20190905_00005f0dcb86732c611c")
+	payout("payout-2", "ref-2", "AG_20190905_00005f0dcb86732c611d")
+
+	// the data ids of webhooks of different payouts can be the same
+	webhook := func(txnID, responseID string) quikk.WebhookResult[quikk.WebhookAttributesPayout] {
+		return decode[quikk.WebhookResult[quikk.WebhookAttributesPayout]](t, `{"data":{"type":"payout","id":"1",
+"attributes":{"txn_id":"`+txnID+`","response_id":"`+responseID+`"}},"meta":{"status":"SUCCESS","code":"0"}}`)
+	}
+
+	payment, err := m.Apply(ctx, lifecycle.FromQuikkPayout(webhook("NH90HBCXPM", "AG_20190905_00005f0dcb86732c611c")))
+	assert.NoError(t, err)
+	assert.Equal(t, "payout-1", payment.ID)
+
+	payment, err = m.Apply(ctx, lifecycle.FromQuikkPayout(webhook("NH90HBCXPN", "AG_20190905_00005f0dcb86732c611d")))
+	assert.NoError(t, err)
+	assert.Equal(t, "payout-2", payment.ID)
+	assert.Equal(t, lifecycle.StateCompleted, payment.State)
+	assert.Equal(t, "NH90HBCXPN", payment.Receipt)
+
+	payment, err = m.Get(ctx, "payout-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "NH90HBCXPM", payment.Receipt)
+}
+
+func TestMachine_Tanda(t *testing.T) {
+	ctx := t.Context()
+	m := lifecycle.New(lifecycle.NewMemoryStore())
+	newPayment(t, m, "payment-1", lifecycle.ProviderTanda)
+
+	_, err := m.Apply(ctx, lifecycle.Submitted(lifecycle.ProviderTanda, "REF00000001"))
+	assert.ErrorIs(t, err, lifecycle.ErrNotFound)
+
+	_, err = m.Apply(ctx, lifecycle.Event{PaymentID: "payment-1", State: lifecycle.StateSubmitted, CorrelationIDs: []string{"REF00000001"}})
+	assert.NoError(t, err)
+
+	response := tanda.ResponsePayment{TrackingID: "7dbd1ad8-2d7f-45e4-b4d6-d3cbd4a0bad2", Reference: "REF00000001",
+		Status: tanda.PaymentStatusP202000, Message: "Request received successfully."}
+	payment, err := m.Apply(ctx, lifecycle.FromTandaResponse(response))
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateAccepted, payment.State)
+
+	ipn := decode[tanda.WebhookRequestPaymentStatus](t, `{"trackingId":"7dbd1ad8-2d7f-45e4-b4d6-d3cbd4a0bad2",
+"transactionId":"b6d1ffa4-2e2d-4f1e-8b1f-2e5a8a6f2c11","status":"S000000","message":"Request processed successfully",
+"timestamp":"2025-08-16T10:00:00Z","result":{"ref":"QHF7V1W2XY"}}`)
+	payment, err = m.Apply(ctx, lifecycle.FromTandaIPN(ipn))
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateCompleted, payment.State)
+	assert.Equal(t, "QHF7V1W2XY", payment.Receipt)
+}
+
+func TestMachine_Correlation(t *testing.T) {
+	ctx := t.Context()
+	m := lifecycle.New(lifecycle.NewMemoryStore())
+
+	_, err := m.Create(ctx, lifecycle.Payment{ID: "a", Provider: lifecycle.ProviderDaraja, CorrelationIDs: []string{"1"}})
+	assert.NoError(t, err)
+	_, err = m.Create(ctx, lifecycle.Payment{ID: "b", Provider: lifecycle.ProviderQuikk, CorrelationIDs: []string{"1"}})
+	assert.NoError(t, err)
+
+	// correlation ids are scoped by provider
+	payment, err := m.Apply(ctx, lifecycle.Submitted(lifecycle.ProviderQuikk, "1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "b", payment.ID)
+
+	payment, err = m.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateCreated, payment.State)
+
+	_, err = m.Create(ctx, lifecycle.Payment{ID: "a", Provider: lifecycle.ProviderDaraja})
+	assert.ErrorIs(t, err, lifecycle.ErrDuplicate)
+}
+
+func TestMachine_ConcurrentEvents(t *testing.T) {
+	ctx := t.Context()
+	m := lifecycle.New(lifecycle.NewMemoryStore())
+	newPayment(t, m, "payout-1", lifecycle.ProviderDaraja)
+	_, err := m.Apply(ctx, lifecycle.Submitted(lifecycle.ProviderDaraja, "16740-34861180-1"))
+	assert.ErrorIs(t, err, lifecycle.ErrNotFound)
+	_, err = m.Apply(ctx, lifecycle.Event{PaymentID: "payout-1", State: lifecycle.StateSubmitted, CorrelationIDs: []string{"16740-34861180-1"}})
+	assert.NoError(t, err)
+
+	// the sync response and the result arrive at the same time
+	var wg sync.WaitGroup
+	for _, event := range []lifecycle.Event{
+		{Provider: lifecycle.ProviderDaraja, State: lifecycle.StateAccepted, CorrelationIDs: []string{"16740-34861180-1", "AG_20191219_00005797af5d7d75f652"}},
+		{Provider: lifecycle.ProviderDaraja, State: lifecycle.StateCompleted, CorrelationIDs: []string{"16740-34861180-1"}},
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.Apply(ctx, event)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	payment, err := m.Get(ctx, "payout-1")
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateCompleted, payment.State)
+	// the ids of the sync response are added even if it arrives after the result
+	assert.Contains(t, payment.CorrelationIDs, "AG_20191219_00005797af5d7d75f652")
+}
+
+func TestMachine_LateEvents(t *testing.T) {
+	ctx := t.Context()
+	m := lifecycle.New(lifecycle.NewMemoryStore())
+	newPayment(t, m, "payout-1", lifecycle.ProviderDaraja)
+	_, err := m.Apply(ctx, lifecycle.Event{PaymentID: "payout-1", State: lifecycle.StateSubmitted, CorrelationIDs: []string{"16740-34861180-1"}})
+	assert.NoError(t, err)
+	_, err = m.Apply(ctx, lifecycle.Event{Provider: lifecycle.ProviderDaraja, State: lifecycle.StateFailed,
+		CorrelationIDs: []string{"16740-34861180-1"}, ResultCode: "2001"})
+	assert.NoError(t, err)
+
+	payment, err := m.Apply(ctx, lifecycle.Event{Provider: lifecycle.ProviderDaraja, State: lifecycle.StateAccepted,
+		CorrelationIDs: []string{"16740-34861180-1", "AG_20191219_00005797af5d7d75f652"}, ResultCode: "0"})
+	assert.NoError(t, err)
+	assert.Equal(t, lifecycle.StateFailed, payment.State)
+	assert.Equal(t, "2001", payment.ResultCode)
+	assert.Contains(t, payment.CorrelationIDs, "AG_20191219_00005797af5d7d75f652")
+
+	_, err = m.Apply(ctx, lifecycle.Event{PaymentID: "payout-1", State: lifecycle.StateSubmitted})
+	assert.NoError(t, err)
+
+	// results still cannot move a payment backwards
+	_, err = m.Apply(ctx, lifecycle.Event{PaymentID: "payout-1", State: lifecycle.StateCompleted})
+	assert.ErrorIs(t, err, lifecycle.ErrInvalidTransition)
+}
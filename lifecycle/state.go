@@ -0,0 +1,90 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// State is a stage in the lifecycle of a payment
+type State string
+
+const (
+	// StateCreated payments are recorded but not yet sent to the provider
+	StateCreated State = "created"
+	// StateSubmitted payments are being sent to the provider
+	StateSubmitted State = "submitted"
+	// StateAccepted payments were accepted by the provider, e.g. daraja ResponseCode 0,
+	// and are waiting for the async result
+	StateAccepted State = "accepted"
+	// StateCompleted payments have a successful async result
+	StateCompleted State = "completed"
+	// StateFailed payments were rejected by the provider or have a failed async result
+	StateFailed State = "failed"
+	// StateReversed payments were completed and later reversed
+	StateReversed State = "reversed"
+	// StateTimedOut payments have no result within the expected time, e.g. a daraja
+	// queue timeout or a request timeout. A late result can still complete or fail them.
+	StateTimedOut State = "timed_out"
+)
+
+// transitions lists the states each state can move to. A result can arrive before the
+// sync response is recorded, which is why submitted payments can complete or fail directly.
+// The late submitted or accepted event only adds its correlation ids to the payment.
+var transitions = map[State][]State{
+	StateCreated:   {StateSubmitted, StateFailed},
+	StateSubmitted: {StateAccepted, StateCompleted, StateFailed, StateTimedOut},
+	StateAccepted:  {StateCompleted, StateFailed, StateTimedOut},
+	StateTimedOut:  {StateAccepted, StateCompleted, StateFailed},
+	StateCompleted: {StateReversed},
+}
+
+// CanTransition reports whether a payment in state s can move to state to
+func (s State) CanTransition(to State) bool {
+	return slices.Contains(transitions[s], to)
+}
+
+// precedes reports whether s comes before state to in the lifecycle, i.e. whether to can
+// be reached from s
+func (s State) precedes(to State) bool {
+	seen := map[State]bool{s: true}
+	next := []State{s}
+	for len(next) > 0 {
+		state := next[0]
+		next = next[1:]
+		for _, t := range transitions[state] {
+			if t == to {
+				return true
+			}
+			if !seen[t] {
+				seen[t] = true
+				next = append(next, t)
+			}
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether s is a final state. Completed payments are not terminal
+// because they can be reversed.
+func (s State) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// TransitionError is returned when an event would move a payment to a state that
+// cannot be reached from its current state. It matches ErrInvalidTransition with errors.Is.
+type TransitionError struct {
+	PaymentID string
+	From      State
+	To        State
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("payment %s: %s: %s -> %s", e.PaymentID, ErrInvalidTransition, e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
@@ -0,0 +1,35 @@
+package lifecycle_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/lifecycle"
+)
+
+func TestState_CanTransition(t *testing.T) {
+	tcs := []struct {
+		from, to lifecycle.State
+		valid    bool
+	}{
+		{lifecycle.StateCreated, lifecycle.StateSubmitted, true},
+		{lifecycle.StateCreated, lifecycle.StateCompleted, false},
+		{lifecycle.StateSubmitted, lifecycle.StateAccepted, true},
+		{lifecycle.StateSubmitted, lifecycle.StateCompleted, true},
+		{lifecycle.StateAccepted, lifecycle.StateTimedOut, true},
+		{lifecycle.StateTimedOut, lifecycle.StateCompleted, true},
+		{lifecycle.StateCompleted, lifecycle.StateReversed, true},
+		{lifecycle.StateCompleted, lifecycle.StateFailed, false},
+		{lifecycle.StateFailed, lifecycle.StateCompleted, false},
+		{lifecycle.StateReversed, lifecycle.StateCompleted, false},
+	}
+
+	for _, tc := range tcs {
+		assert.Equalf(t, tc.valid, tc.from.CanTransition(tc.to), "%s -> %s", tc.from, tc.to)
+	}
+
+	assert.True(t, lifecycle.StateFailed.IsTerminal())
+	assert.True(t, lifecycle.StateReversed.IsTerminal())
+	assert.False(t, lifecycle.StateCompleted.IsTerminal())
+}
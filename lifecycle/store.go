@@ -0,0 +1,125 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrNotFound  = errors.New("payment not found")
+	ErrDuplicate = errors.New("payment already exists")
+	// ErrConflict is returned by Store.Update when the payment was updated concurrently
+	ErrConflict = errors.New("payment was updated concurrently")
+)
+
+// Store persists payments and their events
+type Store interface {
+	// Create saves a new payment with its first event, or returns ErrDuplicate
+	Create(ctx context.Context, payment Payment, event Event) error
+	// Get returns the payment with id, or ErrNotFound
+	Get(ctx context.Context, id string) (Payment, error)
+	// Find returns the payment of provider with the given correlation id, or ErrNotFound
+	Find(ctx context.Context, provider Provider, correlationID string) (Payment, error)
+	// Update saves payment and appends event to its history. It must return ErrConflict
+	// unless the stored version of the payment is payment.Version-1.
+	Update(ctx context.Context, payment Payment, event Event) error
+	// Events returns the events of the payment with id, oldest first
+	Events(ctx context.Context, id string) ([]Event, error)
+}
+
+type correlationKey struct {
+	provider Provider
+	id       string
+}
+
+// MemoryStore is the reference Store implementation, it keeps payments in memory
+type MemoryStore struct {
+	mu       sync.RWMutex
+	payments map[string]Payment
+	events   map[string][]Event
+	ids      map[correlationKey]string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		payments: make(map[string]Payment),
+		events:   make(map[string][]Event),
+		ids:      make(map[correlationKey]string),
+	}
+}
+
+func (s *MemoryStore) index(payment Payment) {
+	for _, id := range payment.CorrelationIDs {
+		s.ids[correlationKey{provider: payment.Provider, id: id}] = payment.ID
+	}
+}
+
+func (s *MemoryStore) Create(_ context.Context, payment Payment, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.payments[payment.ID]; ok {
+		return ErrDuplicate
+	}
+
+	payment.CorrelationIDs = slices.Clone(payment.CorrelationIDs)
+	s.payments[payment.ID] = payment
+	s.events[payment.ID] = []Event{event}
+	s.index(payment)
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (Payment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	payment, ok := s.payments[id]
+	if !ok {
+		return Payment{}, ErrNotFound
+	}
+	payment.CorrelationIDs = slices.Clone(payment.CorrelationIDs)
+	return payment, nil
+}
+
+func (s *MemoryStore) Find(ctx context.Context, provider Provider, correlationID string) (Payment, error) {
+	s.mu.RLock()
+	id, ok := s.ids[correlationKey{provider: provider, id: correlationID}]
+	s.mu.RUnlock()
+
+	if !ok {
+		return Payment{}, ErrNotFound
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *MemoryStore) Update(_ context.Context, payment Payment, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.payments[payment.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if current.Version != payment.Version-1 {
+		return ErrConflict
+	}
+
+	payment.CorrelationIDs = slices.Clone(payment.CorrelationIDs)
+	s.payments[payment.ID] = payment
+	s.events[payment.ID] = append(s.events[payment.ID], event)
+	s.index(payment)
+	return nil
+}
+
+func (s *MemoryStore) Events(_ context.Context, id string) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events, ok := s.events[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(events), nil
+}
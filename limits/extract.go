@@ -0,0 +1,115 @@
+package limits
+
+import (
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/phone"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+// recipient normalises phone numbers to the msisdn format, so that the totals of a
+// number sent in different formats are tracked together
+func recipient(s string) string {
+	if n, err := phone.Parse(s); err == nil {
+		return n.String()
+	}
+	return s
+}
+
+func transaction(amount money.Money, err error, to string) (Transaction, bool, error) {
+	if err != nil {
+		return Transaction{}, false, err
+	}
+	return Transaction{Amount: amount, Recipient: recipient(to)}, true, nil
+}
+
+// DarajaExtractor reads the transactions of daraja stk push, b2c, b2b, b2c top-up,
+// b2pochi and tax remittance requests. Stk push transactions are tracked per paying
+// customer. Reversals return money to the sender and are not checked.
+func DarajaExtractor(params any) (Transaction, bool, error) {
+	switch p := params.(type) {
+	case daraja.RequestC2BExpress:
+		amount, err := p.Money()
+		return transaction(amount, err, p.PhoneNumber)
+	case daraja.RequestB2C:
+		amount, err := p.Money()
+		return transaction(amount, err, p.PartyB)
+	case daraja.RequestB2B:
+		amount, err := p.Money()
+		return transaction(amount, err, p.PartyB)
+	case daraja.RequestB2CTopUp:
+		amount, err := p.Money()
+		return transaction(amount, err, p.PartyB)
+	case daraja.RequestB2Pochi:
+		amount, err := p.Money()
+		return transaction(amount, err, p.PartyB)
+	case daraja.RequestTaxRemittance:
+		amount, err := p.Money()
+		return transaction(amount, err, p.PartyB)
+	default:
+		return Transaction{}, false, nil
+	}
+}
+
+// QuikkExtractor reads the transactions of quikk charge, payout and transfer requests.
+// Charge transactions are tracked per paying customer.
+func QuikkExtractor(params any) (Transaction, bool, error) {
+	switch p := params.(type) {
+	case quikk.RequestDefault[quikk.RequestCharge]:
+		amount, err := p.Data.Attributes.Money()
+		return transaction(amount, err, p.Data.Attributes.CustomerNo)
+	case quikk.RequestDefault[quikk.RequestPayout]:
+		amount, err := p.Data.Attributes.Money()
+		return transaction(amount, err, p.Data.Attributes.RecipientNo)
+	case quikk.RequestDefault[quikk.RequestTransfer]:
+		amount, err := p.Data.Attributes.Money()
+		return transaction(amount, err, p.Data.Attributes.RecipientNo)
+	default:
+		return Transaction{}, false, nil
+	}
+}
+
+// tandaRecipients are the parameters that identify the recipient of a tanda payment,
+// in order of preference. Only one of them is sent for each command.
+var tandaRecipients = []tanda.ParameterID{
+	tanda.ParameterIDAccountNumber,
+	tanda.ParameterIDMobileNumber,
+	tanda.ParameterIDPartyB,
+	tanda.ParameterIDBusinessNumber,
+}
+
+// TandaExtractor reads the transactions of tanda payment requests. Payments without a
+// recipient parameter have no Recipient and skip the daily check.
+func TandaExtractor(params any) (Transaction, bool, error) {
+	p, ok := params.(tanda.RequestPayment)
+	if !ok {
+		return Transaction{}, false, nil
+	}
+
+	amount, err := p.Money()
+	if err != nil {
+		return Transaction{}, false, err
+	}
+	for _, id := range tandaRecipients {
+		if to, ok := p.Parameter(id); ok {
+			return transaction(amount, nil, to)
+		}
+	}
+	return Transaction{Amount: amount}, true, nil
+}
+
+// Daraja creates a Limiter for daraja requests
+func Daraja(cfg Config) Limiter {
+	return New("daraja", DarajaExtractor, cfg)
+}
+
+// Quikk creates a Limiter for quikk requests
+func Quikk(cfg Config) Limiter {
+	return New("quikk", QuikkExtractor, cfg)
+}
+
+// Tanda creates a Limiter for tanda requests
+func Tanda(cfg Config) Limiter {
+	return New("tanda", TandaExtractor, cfg)
+}
@@ -0,0 +1,246 @@
+// Package limits provides request hooks that enforce transaction limits before a
+// request is sent to daraja, quikk or tanda. M-PESA enforces per-transaction and daily
+// limits on its side as well, but only reports breaches asynchronously with result codes
+// that are hard to act on. Checking the limits locally fails the request early with a
+// LimitExceeded error instead.
+package limits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/internal/exchange"
+	"github.com/SirWaithaka/payments/money"
+)
+
+var ErrLimitExceeded = errors.New("transaction limit exceeded")
+
+// Kind is the kind of limit that was exceeded
+type Kind string
+
+const (
+	KindMinimum Kind = "minimum"
+	KindMaximum Kind = "maximum"
+	KindDaily   Kind = "daily"
+)
+
+// Limit configures the amounts allowed for an operation. A zero value of any
+// of the fields disables that limit.
+type Limit struct {
+	// Min is the smallest amount allowed per transaction
+	Min money.Money
+	// Max is the largest amount allowed per transaction
+	Max money.Money
+	// Daily is the largest total amount a single recipient can be sent within the
+	// rolling window of the Limiter, which defaults to 24 hours. The total includes the
+	// amounts sent to the recipient by every operation of the provider.
+	Daily money.Money
+}
+
+// LimitExceeded is the error returned by the Limiter hooks when a transaction is
+// outside the configured limits. It matches ErrLimitExceeded with errors.Is.
+type LimitExceeded struct {
+	Provider  string
+	Operation string
+	Recipient string
+	Kind      Kind
+	// Limit is the configured limit that was exceeded
+	Limit money.Money
+	// Amount is the amount of the rejected transaction
+	Amount money.Money
+	// Total is the amount already sent to the recipient within the window,
+	// it is only set for daily limits
+	Total money.Money
+}
+
+func (e *LimitExceeded) Error() string {
+	prefix := fmt.Sprintf("%s %s: %s", e.Provider, e.Operation, ErrLimitExceeded)
+
+	switch e.Kind {
+	case KindMinimum:
+		return fmt.Sprintf("%s: amount %s is below the minimum of %s", prefix, e.Amount, e.Limit)
+	case KindMaximum:
+		return fmt.Sprintf("%s: amount %s is above the maximum of %s", prefix, e.Amount, e.Limit)
+	default:
+		return fmt.Sprintf("%s: amount %s to %s exceeds the daily limit of %s, %s already sent",
+			prefix, e.Amount, e.Recipient, e.Limit, e.Total)
+	}
+}
+
+func (e *LimitExceeded) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// Transaction is the amount and recipient of a request payload
+type Transaction struct {
+	Amount money.Money
+	// Recipient identifies who receives the amount, e.g. the msisdn, till or paybill.
+	// Daily totals are tracked per recipient, transactions without one skip the daily check.
+	Recipient string
+}
+
+// Extractor returns the Transaction of a request payload. It returns false for
+// payloads that do not move money, which are not checked.
+type Extractor func(params any) (Transaction, bool, error)
+
+// Config configures a Limiter
+type Config struct {
+	// Limits for each operation, keyed by gorequest.Operation.Name e.g. daraja.OperationB2C.
+	// Requests of operations without limits are not checked.
+	Limits map[string]Limit
+	// Store keeps the totals sent to each recipient, it defaults to a MemoryStore.
+	// Use a shared Store when requests are sent from more than one process.
+	Store Store
+	// Window is the period over which daily totals are summed, it defaults to 24 hours.
+	// It can be at most MaxWindow when the Store is a MemoryStore.
+	Window time.Duration
+	// Now returns the current time, it defaults to time.Now
+	Now func() time.Time
+}
+
+// Limiter enforces the configured limits on the requests of a provider
+type Limiter struct {
+	provider string
+	extract  Extractor
+	cfg      Config
+}
+
+// New creates a Limiter for the requests of provider, whose payloads are read with extract
+func New(provider string, extract Extractor, cfg Config) Limiter {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 24 * time.Hour
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return Limiter{provider: provider, extract: extract, cfg: cfg}
+}
+
+func (l Limiter) key(recipient string) string {
+	return l.provider + ":" + recipient
+}
+
+// reservationKey is the context key of the daily limit reservation of a request
+type reservationKey string
+
+type reservation struct {
+	key string
+	id  string
+}
+
+// Install adds the Check hook to the validate hooks and the Record hook to the
+// complete hooks. Both are needed for daily limits, the amount reserved by Check is
+// released by Record when the request fails.
+func (l Limiter) Install(hooks *gorequest.Hooks) {
+	hooks.Validate.PushBackHook(l.Check())
+	hooks.Complete.PushBackHook(l.Record())
+}
+
+// Check is a validate hook that rejects requests whose amount is outside the limits
+// of the operation, with a LimitExceeded error. Amounts within the daily limit are
+// reserved in the Store until the Record hook runs, so requests with daily limits must
+// be sent with Send rather than only built.
+func (l Limiter) Check() gorequest.Hook {
+	return gorequest.Hook{
+		Name: l.provider + ".LimitsCheck",
+		Fn: func(r *gorequest.Request) {
+			limit, ok := l.cfg.Limits[r.Operation.Name]
+			if !ok {
+				return
+			}
+
+			txn, ok, err := l.extract(r.Params)
+			if err != nil {
+				r.Error = err
+				return
+			}
+			if !ok {
+				return
+			}
+
+			exceeded := &LimitExceeded{
+				Provider:  l.provider,
+				Operation: r.Operation.Name,
+				Recipient: txn.Recipient,
+				Amount:    txn.Amount,
+			}
+
+			if !limit.Min.IsZero() {
+				if cmp, err := txn.Amount.Cmp(limit.Min); err != nil {
+					r.Error = err
+					return
+				} else if cmp < 0 {
+					exceeded.Kind, exceeded.Limit = KindMinimum, limit.Min
+					r.Error = exceeded
+					return
+				}
+			}
+
+			if !limit.Max.IsZero() {
+				if cmp, err := txn.Amount.Cmp(limit.Max); err != nil {
+					r.Error = err
+					return
+				} else if cmp > 0 {
+					exceeded.Kind, exceeded.Limit = KindMaximum, limit.Max
+					r.Error = exceeded
+					return
+				}
+			}
+
+			if limit.Daily.IsZero() || txn.Recipient == "" {
+				return
+			}
+
+			now := l.cfg.Now()
+			key := l.key(txn.Recipient)
+			res, err := l.cfg.Store.Reserve(r.Context(), key, txn.Amount, limit.Daily, now.Add(-l.cfg.Window), now)
+			if err != nil {
+				r.Error = err
+				return
+			}
+			if res.Exceeded {
+				exceeded.Kind, exceeded.Limit, exceeded.Total = KindDaily, limit.Daily, res.Total
+				r.Error = exceeded
+				return
+			}
+			r.WithContext(context.WithValue(r.Context(), reservationKey(l.provider), reservation{key: key, id: res.ID}))
+		}}
+}
+
+// Record is a complete hook that settles the amount reserved by Check. The amount is
+// released when the request was not sent or the provider rejected it, and is otherwise
+// committed to the daily total of the recipient, since a request that timed out or got
+// an ambiguous error may still have been paid out.
+func (l Limiter) Record() gorequest.Hook {
+	return gorequest.Hook{
+		Name: l.provider + ".LimitsRecord",
+		Fn: func(r *gorequest.Request) {
+			res, ok := r.Context().Value(reservationKey(l.provider)).(reservation)
+			if !ok {
+				return
+			}
+
+			// the outcome of the request is not changed by the store, an amount that could
+			// not be settled stays reserved and counts towards the total
+			ctx := context.WithoutCancel(r.Context())
+			var err error
+			switch outcome := exchange.Classify(r); {
+			case outcome == exchange.OutcomeNotSent,
+				outcome == exchange.OutcomeProviderError && !exchange.Ambiguous(r.Error):
+				err = l.cfg.Store.Release(ctx, res.key, res.id)
+			default:
+				err = l.cfg.Store.Commit(ctx, res.key, res.id)
+			}
+			if err != nil && r.Config.Logger != nil {
+				r.Config.Logger.Log(fmt.Sprintf("ERROR: %s %s: settling daily limit of %s: %v", l.provider, r.Operation.Name, res.key, err))
+			}
+		}}
+}
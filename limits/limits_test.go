@@ -0,0 +1,375 @@
+package limits_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/limits"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+func kes(units int64) money.Money {
+	return money.FromUnits(units, money.KES)
+}
+
+func b2c(amount, partyB string) daraja.RequestB2C {
+	return daraja.RequestB2C{
+		CommandID: daraja.CommandBusinessPayment,
+		Amount:    amount,
+		PartyA:    "600000",
+		PartyB:    partyB,
+	}
+}
+
+func darajaServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(daraja.EndpointB2cPayment, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(`{"ConversationID":"AG_20191219_00005797af5d7d75f652","OriginatorConversationID":"16740-34861180-1","ResponseCode":"0","ResponseDescription":"Accept the service request successfully."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"requestId":"10101","errorCode":"500.003.1001","errorMessage":"Internal Server Error"}`))
+	})
+	mux.HandleFunc(daraja.EndpointB2Pochi, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ConversationID":"AG_20191219_00005797af5d7d75f653","OriginatorConversationID":"16740-34861180-2","ResponseCode":"0","ResponseDescription":"Accept the service request successfully."}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLimiter_Check(t *testing.T) {
+	limiter := limits.Daraja(limits.Config{
+		Limits: map[string]limits.Limit{
+			daraja.OperationB2C: {Min: kes(10), Max: kes(150000)},
+		},
+	})
+
+	tcs := []struct {
+		amount string
+		kind   limits.Kind
+	}{
+		{"10", ""},
+		{"150000", ""},
+		{"9", limits.KindMinimum},
+		{"150001", limits.KindMaximum},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.amount, func(t *testing.T) {
+			client := daraja.New(daraja.Config{Endpoint: "http://foo.bar"})
+			limiter.Install(&client.Hooks)
+
+			req, _ := client.B2CRequest(b2c(tc.amount, "254712345678"))
+			err := req.Build()
+			if tc.kind == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, limits.ErrLimitExceeded)
+
+			var exceeded *limits.LimitExceeded
+			assert.ErrorAs(t, err, &exceeded)
+			assert.Equal(t, tc.kind, exceeded.Kind)
+			assert.Equal(t, "daraja", exceeded.Provider)
+			assert.Equal(t, daraja.OperationB2C, exceeded.Operation)
+		})
+	}
+
+	t.Run("test that operations without limits are not checked", func(t *testing.T) {
+		client := daraja.New(daraja.Config{Endpoint: "http://foo.bar"})
+		limiter.Install(&client.Hooks)
+
+		req, _ := client.B2BRequest(daraja.RequestB2B{Amount: "1000000", PartyB: "600000"})
+		assert.NoError(t, req.Build())
+	})
+}
+
+func TestLimiter_Daily(t *testing.T) {
+	now := time.Date(2025, 8, 16, 10, 0, 0, 0, time.UTC)
+	limiter := limits.Daraja(limits.Config{
+		Limits: map[string]limits.Limit{daraja.OperationB2C: {Daily: kes(1000)}},
+		Now:    func() time.Time { return now },
+	})
+
+	send := func(server *httptest.Server, amount, partyB string) error {
+		client := daraja.New(daraja.Config{Endpoint: server.URL})
+		limiter.Install(&client.Hooks)
+
+		_, err := client.B2C(t.Context(), b2c(amount, partyB))
+		return err
+	}
+
+	server := darajaServer(t, http.StatusOK)
+	assert.NoError(t, send(server, "600", "254712345678"))
+	assert.NoError(t, send(server, "400", "0712345678"))
+
+	// the same recipient in a different format exceeds the daily limit
+	err := send(server, "1", "+254712345678")
+	var exceeded *limits.LimitExceeded
+	assert.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, limits.KindDaily, exceeded.Kind)
+	assert.Equal(t, "254712345678", exceeded.Recipient)
+	assert.Equal(t, kes(1000), exceeded.Total)
+	assert.Equal(t, kes(1), exceeded.Amount)
+
+	// other recipients have their own totals
+	assert.NoError(t, send(server, "1000", "254700000000"))
+
+	// failed requests are not added to the total
+	assert.Error(t, send(darajaServer(t, http.StatusInternalServerError), "500", "254711111111"))
+	assert.NoError(t, send(server, "1000", "254711111111"))
+
+	// amounts fall out of the rolling window
+	now = now.Add(24 * time.Hour)
+	assert.NoError(t, send(server, "1000", "254712345678"))
+}
+
+func TestLimiter_DailyConcurrent(t *testing.T) {
+	limiter := limits.Daraja(limits.Config{
+		Limits: map[string]limits.Limit{daraja.OperationB2C: {Daily: kes(1000)}},
+	})
+	server := darajaServer(t, http.StatusOK)
+
+	var sent atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			client := daraja.New(daraja.Config{Endpoint: server.URL})
+			limiter.Install(&client.Hooks)
+			if _, err := client.B2C(context.Background(), b2c("100", "254712345678")); err == nil {
+				sent.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// amounts are reserved before they are sent, so concurrent requests cannot exceed the limit
+	assert.Equal(t, int32(10), sent.Load())
+}
+
+func TestLimiter_DailyOperations(t *testing.T) {
+	limiter := limits.Daraja(limits.Config{
+		Limits: map[string]limits.Limit{
+			daraja.OperationB2C:     {Daily: kes(1000)},
+			daraja.OperationB2Pochi: {Daily: kes(1000)},
+		},
+	})
+	server := darajaServer(t, http.StatusOK)
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	limiter.Install(&client.Hooks)
+
+	_, err := client.B2C(t.Context(), b2c("600", "254712345678"))
+	assert.NoError(t, err)
+
+	// the total of the recipient includes the amounts sent by other operations
+	pochi := daraja.RequestB2Pochi{CommandID: daraja.CommandBusinessPayToPochi, Amount: "500", PartyA: "600000", PartyB: "254712345678"}
+	_, err = client.B2Pochi(t.Context(), pochi)
+	var exceeded *limits.LimitExceeded
+	assert.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, kes(600), exceeded.Total)
+
+	pochi.Amount = "400"
+	_, err = client.B2Pochi(t.Context(), pochi)
+	assert.NoError(t, err)
+}
+
+// failingStore is a Store whose reservations cannot be committed
+type failingStore struct {
+	*limits.MemoryStore
+}
+
+func (s failingStore) Commit(context.Context, string, string) error {
+	return errors.New("store unavailable")
+}
+
+// ctxStore is a Store that records the context error of commits
+type ctxStore struct {
+	*limits.MemoryStore
+	err chan error
+}
+
+func (s ctxStore) Commit(ctx context.Context, key, id string) error {
+	s.err <- ctx.Err()
+	return s.MemoryStore.Commit(ctx, key, id)
+}
+
+func TestLimiter_Record(t *testing.T) {
+	t.Run("test that canceled requests are still settled", func(t *testing.T) {
+		store := ctxStore{MemoryStore: limits.NewMemoryStore(), err: make(chan error, 1)}
+		limiter := limits.Daraja(limits.Config{
+			Limits: map[string]limits.Limit{daraja.OperationB2C: {Daily: kes(1000)}},
+			Store:  store,
+		})
+
+		ctx, cancel := context.WithCancel(t.Context())
+		mux := http.NewServeMux()
+		mux.HandleFunc(daraja.EndpointB2cPayment, func(w http.ResponseWriter, r *http.Request) {
+			// the caller gives up after the request was received
+			cancel()
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		client := daraja.New(daraja.Config{Endpoint: server.URL})
+		limiter.Install(&client.Hooks)
+
+		_, err := client.B2C(ctx, b2c("600", "254712345678"))
+		assert.Error(t, err)
+		assert.NoError(t, <-store.err)
+	})
+
+	t.Run("test that a store error does not fail a sent request", func(t *testing.T) {
+		limiter := limits.Daraja(limits.Config{
+			Limits: map[string]limits.Limit{daraja.OperationB2C: {Daily: kes(1000)}},
+			Store:  failingStore{limits.NewMemoryStore()},
+		})
+
+		client := daraja.New(daraja.Config{Endpoint: darajaServer(t, http.StatusOK).URL})
+		limiter.Install(&client.Hooks)
+
+		res, err := client.B2C(t.Context(), b2c("600", "254712345678"))
+		assert.NoError(t, err)
+		assert.Equal(t, "AG_20191219_00005797af5d7d75f652", res.ConversationID)
+
+		// the amount stays reserved
+		_, err = client.B2C(t.Context(), b2c("600", "254712345678"))
+		assert.ErrorIs(t, err, limits.ErrLimitExceeded)
+	})
+
+	t.Run("test that ambiguous errors keep the amount", func(t *testing.T) {
+		limiter := limits.Daraja(limits.Config{
+			Limits: map[string]limits.Limit{daraja.OperationB2C: {Daily: kes(1000)}},
+		})
+
+		// create a mock test server that fails without a daraja error response
+		mux := http.NewServeMux()
+		mux.HandleFunc(daraja.EndpointB2cPayment, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		client := daraja.New(daraja.Config{Endpoint: server.URL})
+		limiter.Install(&client.Hooks)
+
+		_, err := client.B2C(t.Context(), b2c("600", "254712345678"))
+		assert.Error(t, err)
+		_, err = client.B2C(t.Context(), b2c("600", "254712345678"))
+		assert.ErrorIs(t, err, limits.ErrLimitExceeded)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := t.Context()
+	now := time.Date(2025, 8, 16, 10, 0, 0, 0, time.UTC)
+	store := limits.NewMemoryStore()
+
+	res, err := store.Reserve(ctx, "key", kes(600), kes(1000), now.Add(-24*time.Hour), now)
+	assert.NoError(t, err)
+	assert.False(t, res.Exceeded)
+	assert.NoError(t, store.Commit(ctx, "key", res.ID))
+
+	// a limiter with a shorter window does not discard the amounts of a longer one
+	later := now.Add(2 * time.Hour)
+	res, err = store.Reserve(ctx, "key", kes(100), kes(1000), later.Add(-time.Hour), later)
+	assert.NoError(t, err)
+	assert.Equal(t, kes(0), res.Total)
+	assert.NoError(t, store.Release(ctx, "key", res.ID))
+
+	res, err = store.Reserve(ctx, "key", kes(500), kes(1000), later.Add(-24*time.Hour), later)
+	assert.NoError(t, err)
+	assert.True(t, res.Exceeded)
+	assert.Equal(t, kes(600), res.Total)
+
+	// committed amounts are not released
+	res, err = store.Reserve(ctx, "key", kes(400), kes(1000), later.Add(-24*time.Hour), later)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Commit(ctx, "key", res.ID))
+	assert.NoError(t, store.Release(ctx, "key", res.ID))
+
+	res, err = store.Reserve(ctx, "key", kes(1), kes(1000), later.Add(-24*time.Hour), later)
+	assert.NoError(t, err)
+	assert.True(t, res.Exceeded)
+	assert.Equal(t, kes(1000), res.Total)
+}
+
+func TestQuikkExtractor(t *testing.T) {
+	limiter := limits.Quikk(limits.Config{
+		Limits: map[string]limits.Limit{quikk.OperationPayout: {Max: kes(70000)}},
+	})
+
+	client := quikk.New(quikk.Config{Endpoint: "http://foo.bar"})
+	limiter.Install(&client.Hooks)
+
+	req, _ := client.PayoutRequest(quikk.RequestPayout{Amount: 70000.50, RecipientNo: "254712345678", ShortCode: "174379"}, "ref")
+	err := req.Build()
+
+	var exceeded *limits.LimitExceeded
+	assert.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, money.New(7000050, money.KES), exceeded.Amount)
+	assert.Equal(t, "254712345678", exceeded.Recipient)
+}
+
+func TestTandaExtractor(t *testing.T) {
+	payment := tanda.RequestPayment{CommandID: tanda.CommandMerchantTo3rdPartyMerchantPayment, Reference: "REF00000001"}
+	payment.AddParameter(tanda.ParameterIDAmount, "2500")
+	payment.AddParameter(tanda.ParameterIDPartyA, "600000")
+	payment.AddParameter(tanda.ParameterIDPartyB, "123456")
+
+	txn, ok, err := limits.TandaExtractor(payment)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, limits.Transaction{Amount: kes(2500), Recipient: "123456"}, txn)
+
+	_, ok, err = limits.TandaExtractor(daraja.RequestB2C{})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	t.Run("test that payments without a recipient skip the daily check", func(t *testing.T) {
+		limiter := limits.Tanda(limits.Config{
+			Limits: map[string]limits.Limit{tanda.OperationPayment: {Daily: kes(3000)}},
+		})
+		client := tanda.New(tanda.Config{Endpoint: "http://foo.bar"})
+		limiter.Install(&client.Hooks)
+
+		payment := tanda.RequestPayment{CommandID: tanda.CommandMerchantTo3rdPartyMerchantPayment, Reference: "REF00000002"}
+		payment.AddParameter(tanda.ParameterIDAmount, "2500")
+		payment.AddParameter(tanda.ParameterIDPartyA, "600000")
+
+		txn, ok, err := limits.TandaExtractor(payment)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, txn.Recipient)
+
+		for range 2 {
+			req, _ := client.PaymentRequest("org", payment)
+			assert.NoError(t, req.Build())
+		}
+	})
+}
+
+func TestDarajaExtractor_Reversal(t *testing.T) {
+	_, ok, err := limits.DarajaExtractor(daraja.RequestReversal{Amount: "100", ReceiverParty: "600000"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,138 @@
+package limits
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SirWaithaka/payments/money"
+)
+
+// MaxWindow is the longest window over which a MemoryStore keeps amounts. Limiters that
+// share a MemoryStore can use any window up to it.
+const MaxWindow = 7 * 24 * time.Hour
+
+// Reservation is the result of reserving an amount against the daily limit of a recipient
+type Reservation struct {
+	// ID identifies the reservation to Commit or Release it, it is empty when the amount
+	// was not reserved
+	ID string
+	// Total is the sum of the amounts reserved for the recipient within the window,
+	// before this reservation
+	Total money.Money
+	// Exceeded reports that the amount was not reserved because the total would have
+	// exceeded the limit
+	Exceeded bool
+}
+
+// Store keeps the amounts sent to each recipient, so that daily totals can be checked.
+// An amount is reserved before its request is sent, and either committed once it may have
+// been paid out or released if it certainly was not.
+type Store interface {
+	// Reserve adds amount to the total of key at the given time, unless the sum of the
+	// amounts reserved for key after since plus amount exceeds limit. Checking the total
+	// and adding the amount must be atomic, so that concurrent requests cannot exceed the
+	// limit together.
+	Reserve(ctx context.Context, key string, amount, limit money.Money, since, at time.Time) (Reservation, error)
+	// Commit keeps the reserved amount in the total of key
+	Commit(ctx context.Context, key, id string) error
+	// Release removes the reserved amount from the total of key. Committed amounts are
+	// not released.
+	Release(ctx context.Context, key, id string) error
+}
+
+type entry struct {
+	id        string
+	amount    money.Money
+	at        time.Time
+	committed bool
+}
+
+// MemoryStore is a Store that keeps amounts in memory. Amounts older than MaxWindow are
+// discarded.
+type MemoryStore struct {
+	mu      sync.Mutex
+	next    int
+	entries map[string][]entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string][]entry)}
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, key string, amount, limit money.Money, since, at time.Time) (Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// discard entries outside the longest window, which is fixed so that limiters with
+	// different windows can share the store
+	oldest := at.Add(-MaxWindow)
+	var entries []entry
+	for _, e := range s.entries[key] {
+		if e.at.After(oldest) {
+			entries = append(entries, e)
+		}
+	}
+
+	total := money.New(0, amount.Currency())
+	for _, e := range entries {
+		if !e.at.After(since) {
+			continue
+		}
+		var err error
+		if total, err = total.Add(e.amount); err != nil {
+			return Reservation{}, err
+		}
+	}
+
+	next, err := total.Add(amount)
+	if err != nil {
+		return Reservation{}, err
+	}
+	if cmp, err := next.Cmp(limit); err != nil {
+		return Reservation{}, err
+	} else if cmp > 0 {
+		s.set(key, entries)
+		return Reservation{Total: total, Exceeded: true}, nil
+	}
+
+	s.next++
+	id := strconv.Itoa(s.next)
+	s.set(key, append(entries, entry{id: id, amount: amount, at: at}))
+	return Reservation{ID: id, Total: total}, nil
+}
+
+func (s *MemoryStore) set(key string, entries []entry) {
+	if len(entries) == 0 {
+		delete(s.entries, key)
+		return
+	}
+	s.entries[key] = entries
+}
+
+func (s *MemoryStore) Commit(_ context.Context, key, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, e := range s.entries[key] {
+		if e.id == id {
+			s.entries[key][i].committed = true
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.entries[key]
+	for i, e := range entries {
+		if e.id == id && !e.committed {
+			s.set(key, append(entries[:i:i], entries[i+1:]...))
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Package logging provides request hooks that log the outcome of daraja, quikk and tanda
+// requests with log/slog.
+//
+// A Logger is installed on the Hooks of a client and logs one record per request once it
+// completes, with the operation, provider, latency, http status and the request id and
+// result code the provider returned, so that a request can be traced through the
+// provider's support channels. At the debug level the headers and bodies of the exchange
+// are logged as well. Credentials and personal data are redacted from everything that is
+// logged: the headers in DefaultRedactedHeaders and the fields in DefaultRedactedKeys are
+// replaced with Redacted, and MSISDNs anywhere else are masked.
+//
+// The token requests of daraja.Authenticate and tanda.Authenticate are sent with hooks of
+// their own and are not logged.
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/internal/exchange"
+	"github.com/SirWaithaka/payments/internal/redact"
+)
+
+// Redacted is logged in place of redacted values
+const Redacted = redact.Redacted
+
+// DefaultRedactedHeaders are the headers redacted from every record
+var DefaultRedactedHeaders = redact.DefaultHeaders
+
+// DefaultRedactedKeys are the JSON and form fields redacted from every record, matched
+// without regard to case
+var DefaultRedactedKeys = []string{
+	"SecurityCredential", "Password", "PhoneNumber", "MSISDN", "sender_no",
+	"access_token", "client_secret",
+}
+
+// Attribute keys of the records
+const (
+	KeyProvider   = "provider"
+	KeyOperation  = "operation"
+	KeyLatency    = "latency"
+	KeyStatus     = "status"
+	KeyRequestID  = "request_id"
+	KeyResultCode = "result_code"
+	KeyError      = "error"
+	KeyRequest    = "request"
+	KeyResponse   = "response"
+)
+
+// Fields names the fields of a provider's response bodies that are logged as the request
+// id and result code
+type Fields = exchange.Fields
+
+// Config configures a Logger
+type Config struct {
+	// Logger writes the records, it defaults to slog.Default()
+	Logger *slog.Logger
+	// Level is the level of the records of successful requests, it defaults to
+	// slog.LevelInfo. Failed requests are logged at slog.LevelError.
+	Level slog.Level
+	// RedactHeaders are redacted in addition to DefaultRedactedHeaders
+	RedactHeaders []string
+	// RedactKeys are redacted in addition to DefaultRedactedKeys
+	RedactKeys []string
+}
+
+// Logger logs the requests of a provider
+type Logger struct {
+	provider string
+	fields   Fields
+	cfg      Config
+	redact   redact.Redactor
+}
+
+// New creates a Logger for the requests of provider, that logs the request id and result
+// code read from fields of the response
+func New(provider string, fields Fields, cfg Config) Logger {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	headers := append(append([]string{}, DefaultRedactedHeaders...), cfg.RedactHeaders...)
+	keys := append(append([]string{}, DefaultRedactedKeys...), cfg.RedactKeys...)
+	return Logger{
+		provider: provider,
+		fields:   fields,
+		cfg:      cfg,
+		redact:   redact.New(headers, keys, false),
+	}
+}
+
+// Install adds the CaptureRequest hook to the front of the send hooks, a hook that captures
+// the response body to the front of the unmarshal hooks and the Log hook to the complete
+// hooks
+func (l Logger) Install(hooks *gorequest.Hooks) {
+	hooks.Send.PushFrontHook(l.CaptureRequest())
+	hooks.Unmarshal.PushFrontHook(exchange.CaptureResponse(l.provider + ".LogCaptureResponse"))
+	hooks.Complete.PushBackHook(l.Log())
+}
+
+// debug reports whether the records of the request include the exchange
+func (l Logger) debug(r *gorequest.Request) bool {
+	return l.cfg.Logger.Enabled(r.Context(), slog.LevelDebug)
+}
+
+// CaptureRequest is a send hook that keeps the request body when the exchange is logged
+func (l Logger) CaptureRequest() gorequest.Hook {
+	return gorequest.Hook{
+		Name: l.provider + ".LogCaptureRequest",
+		Fn: func(r *gorequest.Request) {
+			if !l.debug(r) || r.Request.Body == nil || r.Request.Body == http.NoBody {
+				return
+			}
+			r.Request.Body = exchange.Capture(r.Request.Body)
+		}}
+}
+
+// Log is a complete hook that logs the outcome of the request
+func (l Logger) Log() gorequest.Hook {
+	return gorequest.Hook{
+		Name: l.provider + ".Log",
+		Fn: func(r *gorequest.Request) {
+			ctx := r.Context()
+
+			level, msg := l.cfg.Level, "request completed"
+			if r.Error != nil {
+				level, msg = slog.LevelError, "request failed"
+			}
+			if !l.cfg.Logger.Enabled(ctx, level) {
+				return
+			}
+
+			attrs := []slog.Attr{
+				slog.String(KeyProvider, l.provider),
+				slog.String(KeyOperation, r.Operation.Name),
+			}
+			if !r.AttemptTime.IsZero() {
+				attrs = append(attrs, slog.Duration(KeyLatency, time.Since(r.AttemptTime)))
+			}
+
+			var resBody []byte
+			if r.Response != nil {
+				resBody = exchange.Captured(r.Response.Body)
+				if r.Response.StatusCode != 0 {
+					attrs = append(attrs, slog.Int(KeyStatus, r.Response.StatusCode))
+				}
+			}
+
+			requestID, resultCode := l.fields.Lookup(resBody)
+			if requestID != "" {
+				attrs = append(attrs, slog.String(KeyRequestID, requestID))
+			}
+			if resultCode != "" {
+				attrs = append(attrs, slog.String(KeyResultCode, resultCode))
+			}
+			if r.Error != nil {
+				attrs = append(attrs, slog.String(KeyError, l.redact.String(r.Error.Error())))
+			}
+
+			if l.debug(r) && r.Request != nil {
+				attrs = append(attrs, l.exchange(r, resBody)...)
+			}
+
+			l.cfg.Logger.LogAttrs(ctx, level, msg, attrs...)
+		}}
+}
+
+// exchange returns the redacted headers and bodies of the request and response
+func (l Logger) exchange(r *gorequest.Request, resBody []byte) []slog.Attr {
+	request := []any{
+		slog.String("method", r.Request.Method),
+		slog.String("url", l.redact.String(r.Request.URL.String())),
+		slog.Any("header", l.redact.Header(r.Request.Header)),
+	}
+	if body := exchange.Captured(r.Request.Body); len(body) > 0 {
+		request = append(request, slog.String("body", l.redact.Body(body, r.Request.Header.Get("Content-Type"))))
+	}
+	attrs := []slog.Attr{slog.Group(KeyRequest, request...)}
+
+	if r.Response != nil && r.Response.StatusCode != 0 {
+		response := []any{slog.Any("header", l.redact.Header(r.Response.Header))}
+		if len(resBody) > 0 {
+			response = append(response, slog.String("body", l.redact.Body(resBody, r.Response.Header.Get("Content-Type"))))
+		}
+		attrs = append(attrs, slog.Group(KeyResponse, response...))
+	}
+	return attrs
+}
@@ -0,0 +1,241 @@
+package logging_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/daraja/daratest"
+	"github.com/SirWaithaka/payments/logging"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/quikk/quikktest"
+	"github.com/SirWaithaka/payments/tanda"
+	"github.com/SirWaithaka/payments/tanda/tandatest"
+)
+
+// output is a buffer of JSON log records
+type output struct {
+	bytes.Buffer
+}
+
+func newLogger(level slog.Level) (*slog.Logger, *output) {
+	out := &output{}
+	return slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level})), out
+}
+
+// records decodes the records written to the buffer
+func (out *output) records(t *testing.T) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, jsoniter.UnmarshalFromString(line, &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// callbacks starts an https server that accepts webhooks
+func callbacks(t *testing.T) string {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func b2c(url string) daraja.RequestB2C {
+	return daraja.RequestB2C{
+		OriginatorConversationID: "fake_id",
+		InitiatorName:            "fake_name",
+		SecurityCredential:       "fake_credential",
+		CommandID:                daraja.CommandBusinessPayment,
+		Amount:                   "150",
+		PartyA:                   "600000",
+		PartyB:                   "254712345678",
+		Remarks:                  "test payment",
+		QueueTimeOutURL:          url,
+		ResultURL:                url,
+	}
+}
+
+func TestLogger_Daraja(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	url := callbacks(t)
+
+	t.Run("test that a successful request is logged", func(t *testing.T) {
+		logger, out := newLogger(slog.LevelInfo)
+		client := server.Client()
+		logging.Daraja(logging.Config{Logger: logger}).Install(&client.Hooks)
+
+		res, err := client.B2C(t.Context(), b2c(url))
+		require.NoError(t, err)
+
+		records := out.records(t)
+		require.Len(t, records, 1)
+		record := records[0]
+		assert.Equal(t, "INFO", record["level"])
+		assert.Equal(t, "daraja", record[logging.KeyProvider])
+		assert.Equal(t, daraja.OperationB2C, record[logging.KeyOperation])
+		assert.EqualValues(t, http.StatusOK, record[logging.KeyStatus])
+		assert.Equal(t, res.ConversationID, record[logging.KeyRequestID])
+		assert.Equal(t, "0", record[logging.KeyResultCode])
+		assert.Contains(t, record, logging.KeyLatency)
+		assert.NotContains(t, record, logging.KeyRequest)
+	})
+
+	t.Run("test that a rejected request is logged with the error code", func(t *testing.T) {
+		server.Script(daraja.OperationB2C, daratest.SubscriberLocked)
+
+		logger, out := newLogger(slog.LevelInfo)
+		client := server.Client()
+		logging.Daraja(logging.Config{Logger: logger}).Install(&client.Hooks)
+
+		_, err := client.B2C(t.Context(), b2c(url))
+		require.Error(t, err)
+
+		records := out.records(t)
+		require.Len(t, records, 1)
+		record := records[0]
+		assert.Equal(t, "ERROR", record["level"])
+		assert.EqualValues(t, http.StatusInternalServerError, record[logging.KeyStatus])
+		assert.Equal(t, daraja.SubscriberLock.String(), record[logging.KeyResultCode])
+		assert.NotEmpty(t, record[logging.KeyRequestID])
+		assert.Contains(t, record[logging.KeyError], "Unable to lock subscriber")
+	})
+
+	t.Run("test that the exchange is logged redacted at the debug level", func(t *testing.T) {
+		logger, out := newLogger(slog.LevelDebug)
+		client := server.Client()
+		logging.Daraja(logging.Config{Logger: logger}).Install(&client.Hooks)
+
+		_, err := client.B2C(t.Context(), b2c(url))
+		require.NoError(t, err)
+		_, err = client.C2BExpress(t.Context(), daraja.RequestC2BExpress{
+			BusinessShortCode: "174379",
+			Password:          daraja.PasswordEncode("174379", "passkey", daraja.NewTimestamp().String()),
+			Timestamp:         daraja.NewTimestamp(),
+			TransactionType:   daraja.TypeCustomerPayBillOnline,
+			Amount:            "10",
+			PartyA:            "254712345678",
+			PartyB:            "174379",
+			PhoneNumber:       "254712345678",
+			CallBackURL:       url,
+			AccountReference:  "F0000020",
+			TransactionDesc:   "Deposit",
+		})
+		require.NoError(t, err)
+
+		assert.NotContains(t, out.String(), "254712345678")
+		assert.NotContains(t, out.String(), "fake_credential")
+		assert.NotContains(t, out.String(), "Bearer")
+
+		records := out.records(t)
+		require.Len(t, records, 2)
+
+		request := records[0][logging.KeyRequest].(map[string]any)
+		assert.Equal(t, http.MethodPost, request["method"])
+		assert.Equal(t, []any{logging.Redacted}, request["header"].(map[string]any)["Authorization"])
+		assert.Contains(t, request["body"], `"SecurityCredential":"REDACTED"`)
+		assert.Contains(t, request["body"], `"PartyB":"2547*****678"`)
+		assert.Contains(t, records[0][logging.KeyResponse].(map[string]any)["body"], "ConversationID")
+
+		request = records[1][logging.KeyRequest].(map[string]any)
+		assert.Contains(t, request["body"], `"PhoneNumber":"REDACTED"`)
+		assert.Contains(t, request["body"], `"Password":"REDACTED"`)
+		assert.NotEmpty(t, records[1][logging.KeyRequestID])
+	})
+
+	t.Run("test that a failure to send is logged", func(t *testing.T) {
+		logger, out := newLogger(slog.LevelInfo)
+		client := daraja.New(daraja.Config{Endpoint: "http://127.0.0.1:0"})
+		logging.Daraja(logging.Config{Logger: logger}).Install(&client.Hooks)
+
+		_, err := client.B2C(t.Context(), b2c(url))
+		require.Error(t, err)
+
+		records := out.records(t)
+		require.Len(t, records, 1)
+		assert.Equal(t, "ERROR", records[0]["level"])
+		assert.NotContains(t, records[0], logging.KeyStatus)
+		assert.NotContains(t, records[0], logging.KeyResultCode)
+		assert.NotEmpty(t, records[0][logging.KeyError])
+	})
+}
+
+func TestLogger_Quikk(t *testing.T) {
+	server := quikktest.NewServer(callbacks(t))
+	defer server.Close()
+
+	logger, out := newLogger(slog.LevelDebug)
+	client := server.Client()
+	logging.Quikk(logging.Config{Logger: logger}).Install(&client.Hooks)
+
+	payout := quikk.RequestPayout{Amount: 100, RecipientNo: "254712345678", RecipientType: "msisdn", ShortCode: "174379"}
+	res, err := client.Payout(t.Context(), payout, "payout-1")
+	require.NoError(t, err)
+
+	server.Script(quikk.OperationPayout, quikktest.RateLimited)
+	_, err = client.Payout(t.Context(), payout, "payout-2")
+	require.Error(t, err)
+
+	assert.NotContains(t, out.String(), "254712345678")
+
+	records := out.records(t)
+	require.Len(t, records, 2)
+	assert.Equal(t, "quikk", records[0][logging.KeyProvider])
+	assert.Equal(t, res.Data.ID, records[0][logging.KeyRequestID])
+	assert.Equal(t, []any{logging.Redacted}, records[0][logging.KeyRequest].(map[string]any)["header"].(map[string]any)["Authorization"])
+
+	assert.Equal(t, "ERROR", records[1]["level"])
+	assert.EqualValues(t, http.StatusTooManyRequests, records[1][logging.KeyStatus])
+	assert.Equal(t, "429", records[1][logging.KeyResultCode])
+}
+
+func TestLogger_Tanda(t *testing.T) {
+	server := tandatest.NewServer()
+	defer server.Close()
+
+	logger, out := newLogger(slog.LevelInfo)
+	client := server.Client()
+	logging.Tanda(logging.Config{Logger: logger}).Install(&client.Hooks)
+
+	payment := func(reference string) tanda.RequestPayment {
+		payment := tanda.RequestPayment{CommandID: tanda.CommandMerchantToCustomerMobileMoneyPayment, Reference: reference}
+		payment.AddParameter(tanda.ParameterIDAmount, "100")
+		payment.AddParameter(tanda.ParameterIDShortCode, "174379")
+		payment.AddParameter(tanda.ParameterIDAccountNumber, "254712345678")
+		payment.AddParameter(tanda.ParameterIDNarration, "Payment")
+		payment.AddParameter(tanda.ParameterIDIpnUrl, callbacks(t))
+		return payment
+	}
+
+	res, err := client.Payment(t.Context(), tandatest.OrganizationID, payment("REF00000001"))
+	require.NoError(t, err)
+
+	server.Script(tanda.CommandMerchantToCustomerMobileMoneyPayment, tandatest.InsufficientBalance)
+	_, err = client.Payment(t.Context(), tandatest.OrganizationID, payment("REF00000002"))
+	require.Error(t, err)
+
+	records := out.records(t)
+	require.Len(t, records, 2)
+	assert.Equal(t, "tanda", records[0][logging.KeyProvider])
+	assert.Equal(t, tanda.OperationPayment, records[0][logging.KeyOperation])
+	assert.Equal(t, res.TrackingID, records[0][logging.KeyRequestID])
+	assert.Equal(t, string(tanda.PaymentStatusP202000), records[0][logging.KeyResultCode])
+
+	assert.Equal(t, "ERROR", records[1]["level"])
+	assert.Equal(t, string(tanda.PaymentStatusE422006), records[1][logging.KeyResultCode])
+	assert.NotContains(t, records[1], logging.KeyRequestID)
+}
@@ -0,0 +1,25 @@
+package logging
+
+import "github.com/SirWaithaka/payments/internal/exchange"
+
+// Fields of the responses of each provider
+var (
+	DarajaFields = exchange.DarajaFields
+	QuikkFields  = exchange.QuikkFields
+	TandaFields  = exchange.TandaFields
+)
+
+// Daraja creates a Logger for daraja requests
+func Daraja(cfg Config) Logger {
+	return New("daraja", DarajaFields, cfg)
+}
+
+// Quikk creates a Logger for quikk requests
+func Quikk(cfg Config) Logger {
+	return New("quikk", QuikkFields, cfg)
+}
+
+// Tanda creates a Logger for tanda requests
+func Tanda(cfg Config) Logger {
+	return New("tanda", TandaFields, cfg)
+}
@@ -0,0 +1,218 @@
+// Package metrics provides request hooks that export Prometheus metrics of daraja, quikk
+// and tanda requests, and gauges of the account balances reported by their callbacks.
+//
+// A Metrics holds the collectors and the registry they are served from with Handler. The
+// Recorder of each provider is installed on the Hooks of a client and counts its requests
+// and observes their latency by provider, operation and outcome. The outcome of a request
+// is one of:
+//
+//   - success, the provider accepted the request
+//   - provider_error, the provider responded with an error. The code label carries the
+//     result code of the response, or its http status when it has none.
+//   - transport_error, the request got no response
+//   - timeout, the deadline of the request passed before it got a response
+//   - not_sent, the request failed before it was sent, e.g. in validation or authentication
+//
+// Balance gauges are set from the result callbacks of balance queries with DarajaBalance
+// and QuikkBalance.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/internal/exchange"
+	"github.com/SirWaithaka/payments/quikk"
+)
+
+// Labels of the metrics
+const (
+	LabelProvider  = "provider"
+	LabelOperation = "operation"
+	LabelOutcome   = "outcome"
+	LabelCode      = "code"
+	LabelShortCode = "shortcode"
+	LabelAccount   = "account"
+)
+
+// Accounts of a shortcode, the values of the account label of the balance gauge
+const (
+	AccountWorking                = "working"
+	AccountUtility                = "utility"
+	AccountChargesPaid            = "charges_paid"
+	AccountMerchant               = "merchant"
+	AccountFloat                  = "float"
+	AccountOrganizationSettlement = "organization_settlement"
+)
+
+// Fields names the fields of a provider's response bodies that are read for the result
+// code of provider errors
+type Fields = exchange.Fields
+
+// Config configures a Metrics
+type Config struct {
+	// Registry is where the collectors are registered and served from, it defaults to a
+	// new prometheus.Registry
+	Registry *prometheus.Registry
+	// Namespace prefixes the names of the metrics, it defaults to "payments"
+	Namespace string
+	// Buckets of the request duration histogram in seconds, they default to
+	// prometheus.DefBuckets
+	Buckets []float64
+}
+
+// Metrics are the collectors of the requests and balances of payment providers
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	balance  *prometheus.GaugeVec
+}
+
+// New creates the collectors and registers them with cfg.Registry
+func New(cfg Config) (*Metrics, error) {
+	if cfg.Registry == nil {
+		cfg.Registry = prometheus.NewRegistry()
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = "payments"
+	}
+	if len(cfg.Buckets) == 0 {
+		cfg.Buckets = prometheus.DefBuckets
+	}
+
+	m := &Metrics{
+		registry: cfg.Registry,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Name:      "requests_total",
+			Help:      "Requests to payment providers by outcome.",
+		}, []string{LabelProvider, LabelOperation, LabelOutcome, LabelCode}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.Namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests to payment providers, from when they are sent until they complete.",
+			Buckets:   cfg.Buckets,
+		}, []string{LabelProvider, LabelOperation, LabelOutcome}),
+		balance: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.Namespace,
+			Name:      "account_balance",
+			Help:      "Available balance of the accounts of a shortcode, as last reported by a balance query.",
+		}, []string{LabelProvider, LabelShortCode, LabelAccount}),
+	}
+
+	for _, c := range []prometheus.Collector{m.requests, m.duration, m.balance} {
+		if err := cfg.Registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Handler serves the metrics of the registry in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Recorder records the requests of a provider
+type Recorder struct {
+	metrics  *Metrics
+	provider string
+	fields   Fields
+}
+
+// Recorder returns the Recorder of the requests of provider, that reads the result code
+// of provider errors from fields of the response
+func (m *Metrics) Recorder(provider string, fields Fields) Recorder {
+	return Recorder{metrics: m, provider: provider, fields: fields}
+}
+
+// Daraja returns the Recorder of daraja requests
+func (m *Metrics) Daraja() Recorder {
+	return m.Recorder("daraja", exchange.DarajaFields)
+}
+
+// Quikk returns the Recorder of quikk requests
+func (m *Metrics) Quikk() Recorder {
+	return m.Recorder("quikk", exchange.QuikkFields)
+}
+
+// Tanda returns the Recorder of tanda requests
+func (m *Metrics) Tanda() Recorder {
+	return m.Recorder("tanda", exchange.TandaFields)
+}
+
+// Install adds a hook that captures the response body to the front of the unmarshal hooks
+// and the Observe hook to the complete hooks
+func (r Recorder) Install(hooks *gorequest.Hooks) {
+	hooks.Unmarshal.PushFrontHook(exchange.CaptureResponse(r.provider + ".MetricsCaptureResponse"))
+	hooks.Complete.PushBackHook(r.Observe())
+}
+
+// Observe is a complete hook that counts the request by its outcome and observes its
+// latency
+func (r Recorder) Observe() gorequest.Hook {
+	return gorequest.Hook{
+		Name: r.provider + ".MetricsObserve",
+		Fn: func(req *gorequest.Request) {
+			outcome := exchange.Classify(req)
+
+			var code string
+			if outcome == exchange.OutcomeProviderError {
+				_, code = r.fields.Lookup(exchange.Captured(req.Response.Body))
+				if code == "" {
+					code = strconv.Itoa(req.Response.StatusCode)
+				}
+			}
+
+			r.metrics.requests.WithLabelValues(r.provider, req.Operation.Name, string(outcome), code).Inc()
+			if !req.AttemptTime.IsZero() {
+				r.metrics.duration.WithLabelValues(r.provider, req.Operation.Name, string(outcome)).
+					Observe(time.Since(req.AttemptTime).Seconds())
+			}
+		}}
+}
+
+// account returns the account label of a daraja account name e.g. charges_paid for
+// "Charges Paid Account"
+func account(name string) string {
+	name = strings.TrimSuffix(strings.TrimSpace(name), " Account")
+	return strings.ReplaceAll(strings.ToLower(name), " ", "_")
+}
+
+// DarajaBalance sets the balance gauges of shortCode from the AccountBalance result
+// parameter of a daraja balance query callback, one for each account it reports
+func (m *Metrics) DarajaBalance(shortCode string, webhook daraja.WebhookRequestBalance) error {
+	balances, err := webhook.Balances()
+	if err != nil {
+		return err
+	}
+
+	for _, balance := range balances {
+		m.balance.WithLabelValues("daraja", shortCode, account(balance.Account)).Set(balance.Available.Float64())
+	}
+	return nil
+}
+
+// QuikkBalance sets the balance gauges of shortCode from the attributes of a quikk
+// balance search callback
+func (m *Metrics) QuikkBalance(shortCode string, attrs quikk.WebhookAttributesBalanceSearch) {
+	for name, balance := range map[string]float64{
+		AccountWorking:                attrs.WorkingAccountBalance,
+		AccountUtility:                attrs.UtilityAccountBalance,
+		AccountChargesPaid:            attrs.ChargesPaidAccountBalance,
+		AccountMerchant:               attrs.MerchantAccountBalance,
+		AccountOrganizationSettlement: attrs.OrgSettlementAccountBalance,
+	} {
+		m.balance.WithLabelValues("quikk", shortCode, name).Set(balance)
+	}
+}
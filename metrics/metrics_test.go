@@ -0,0 +1,210 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/daraja/daratest"
+	"github.com/SirWaithaka/payments/metrics"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/quikk/quikktest"
+	"github.com/SirWaithaka/payments/tanda"
+	"github.com/SirWaithaka/payments/tanda/tandatest"
+)
+
+// scrape returns the metrics served by the handler of m
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(b)
+}
+
+// callbacks starts an https server that accepts webhooks
+func callbacks(t *testing.T) string {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func b2c(url string) daraja.RequestB2C {
+	return daraja.RequestB2C{
+		OriginatorConversationID: "fake_id",
+		InitiatorName:            "fake_name",
+		SecurityCredential:       "fake_credential",
+		CommandID:                daraja.CommandBusinessPayment,
+		Amount:                   "150",
+		PartyA:                   "600000",
+		PartyB:                   "254712345678",
+		Remarks:                  "test payment",
+		QueueTimeOutURL:          url,
+		ResultURL:                url,
+	}
+}
+
+func TestRecorder_Daraja(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	url := callbacks(t)
+
+	m, err := metrics.New(metrics.Config{})
+	require.NoError(t, err)
+
+	client := server.Client()
+	m.Daraja().Install(&client.Hooks)
+
+	_, err = client.B2C(t.Context(), b2c(url))
+	require.NoError(t, err)
+	_, err = client.B2C(t.Context(), b2c(url))
+	require.NoError(t, err)
+
+	server.Script(daraja.OperationB2C, daratest.SubscriberLocked)
+	_, err = client.B2C(t.Context(), b2c(url))
+	require.Error(t, err)
+
+	// a server that responds after the deadline of the request
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+	client = daraja.New(daraja.Config{Endpoint: slow.URL})
+	m.Daraja().Install(&client.Hooks)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.B2C(ctx, b2c(url))
+	require.Error(t, err)
+
+	client = daraja.New(daraja.Config{Endpoint: "http://127.0.0.1:0"})
+	m.Daraja().Install(&client.Hooks)
+	_, err = client.B2C(t.Context(), b2c(url))
+	require.Error(t, err)
+
+	out := scrape(t, m)
+	for _, line := range []string{
+		`payments_requests_total{code="",operation="b2c",outcome="success",provider="daraja"} 2`,
+		`payments_requests_total{code="500.001.1001",operation="b2c",outcome="provider_error",provider="daraja"} 1`,
+		`payments_requests_total{code="",operation="b2c",outcome="timeout",provider="daraja"} 1`,
+		`payments_requests_total{code="",operation="b2c",outcome="transport_error",provider="daraja"} 1`,
+		`payments_request_duration_seconds_count{operation="b2c",outcome="success",provider="daraja"} 2`,
+		`payments_request_duration_seconds_count{operation="b2c",outcome="provider_error",provider="daraja"} 1`,
+	} {
+		assert.Contains(t, out, line)
+	}
+}
+
+func TestRecorder_Quikk(t *testing.T) {
+	server := quikktest.NewServer(callbacks(t))
+	defer server.Close()
+
+	m, err := metrics.New(metrics.Config{Namespace: "ops"})
+	require.NoError(t, err)
+
+	client := server.Client()
+	m.Quikk().Install(&client.Hooks)
+
+	server.Script(quikk.OperationPayout, quikktest.RateLimited)
+	payout := quikk.RequestPayout{Amount: 100, RecipientNo: "254712345678", RecipientType: "msisdn", ShortCode: "174379"}
+	_, err = client.Payout(t.Context(), payout, "payout-1")
+	require.Error(t, err)
+
+	assert.Contains(t, scrape(t, m), `ops_requests_total{code="429",operation="`+quikk.OperationPayout+`",outcome="provider_error",provider="quikk"} 1`)
+}
+
+func TestRecorder_Tanda(t *testing.T) {
+	server := tandatest.NewServer()
+	defer server.Close()
+
+	m, err := metrics.New(metrics.Config{})
+	require.NoError(t, err)
+
+	client := server.Client()
+	m.Tanda().Install(&client.Hooks)
+
+	server.Script(tanda.CommandMerchantToCustomerMobileMoneyPayment, tandatest.InsufficientBalance)
+	payment := tanda.RequestPayment{CommandID: tanda.CommandMerchantToCustomerMobileMoneyPayment, Reference: "REF00000001"}
+	payment.AddParameter(tanda.ParameterIDAmount, "100")
+	payment.AddParameter(tanda.ParameterIDShortCode, "174379")
+	payment.AddParameter(tanda.ParameterIDAccountNumber, "254712345678")
+	payment.AddParameter(tanda.ParameterIDNarration, "Payment")
+	payment.AddParameter(tanda.ParameterIDIpnUrl, callbacks(t))
+
+	_, err = client.Payment(t.Context(), tandatest.OrganizationID, payment)
+	require.Error(t, err)
+
+	assert.Contains(t, scrape(t, m), `payments_requests_total{code="E422006",operation="`+tanda.OperationPayment+`",outcome="provider_error",provider="tanda"} 1`)
+}
+
+func TestMetrics_Balance(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m, err := metrics.New(metrics.Config{Registry: registry})
+	require.NoError(t, err)
+
+	t.Run("test that daraja balances are set", func(t *testing.T) {
+		body := `{"Result":{"ResultType":0,"ResultCode":0,"ResultDesc":"The service request is processed successfully.",
+"OriginatorConversationID":"16917-22577599-3","ConversationID":"AG_20200206_00005e091a8ec6b9eac5","TransactionID":"OA90000000",
+"ResultParameters":{"ResultParameter":[{"Key":"AccountBalance","Value":"Working Account|KES|700000.00|700000.00|0.00|0.00&Utility Account|KES|228037.00|228037.00|0.00|0.00&Charges Paid Account|KES|-1540.00|-1540.00|0.00|0.00"}]}}}`
+
+		var webhook daraja.WebhookRequestBalance
+		require.NoError(t, jsoniter.UnmarshalFromString(body, &webhook))
+		require.NoError(t, m.DarajaBalance("600000", webhook))
+
+		assert.ErrorIs(t, m.DarajaBalance("600000", daraja.WebhookRequestBalance{}), daraja.ErrInvalidAccountBalance)
+	})
+
+	t.Run("test that quikk balances are set", func(t *testing.T) {
+		m.QuikkBalance("174379", quikk.WebhookAttributesBalanceSearch{
+			WorkingAccountBalance:     4761531.1,
+			UtilityAccountBalance:     2000,
+			ChargesPaidAccountBalance: -30,
+		})
+	})
+
+	expected := `
+# HELP payments_account_balance Available balance of the accounts of a shortcode, as last reported by a balance query.
+# TYPE payments_account_balance gauge
+payments_account_balance{account="charges_paid",provider="daraja",shortcode="600000"} -1540
+payments_account_balance{account="utility",provider="daraja",shortcode="600000"} 228037
+payments_account_balance{account="working",provider="daraja",shortcode="600000"} 700000
+payments_account_balance{account="charges_paid",provider="quikk",shortcode="174379"} -30
+payments_account_balance{account="merchant",provider="quikk",shortcode="174379"} 0
+payments_account_balance{account="organization_settlement",provider="quikk",shortcode="174379"} 0
+payments_account_balance{account="utility",provider="quikk",shortcode="174379"} 2000
+payments_account_balance{account="working",provider="quikk",shortcode="174379"} 4.7615311e+06
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "payments_account_balance"))
+}
+
+func TestNew(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	_, err := metrics.New(metrics.Config{Registry: registry})
+	require.NoError(t, err)
+
+	// the collectors of a namespace can only be registered once
+	_, err = metrics.New(metrics.Config{Registry: registry})
+	assert.Error(t, err)
+}
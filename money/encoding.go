@@ -0,0 +1,118 @@
+package money
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+type jsonMoney struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON encodes Money as an object with a decimal string amount,
+// e.g. {"amount":"100.50","currency":"KES"}, so that no precision is lost
+func (m Money) MarshalJSON() ([]byte, error) {
+	return jsoniter.Marshal(jsonMoney{Amount: m.Decimal(), Currency: m.currency})
+}
+
+func (m *Money) UnmarshalJSON(b []byte) error {
+	var v jsonMoney
+	if err := jsoniter.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	parsed, err := Parse(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Whole is the wire format of amounts sent as strings of whole currency units
+// e.g. "100", which is how daraja and tanda expect amounts. Encoding an amount with
+// a fraction fails with ErrFractionalAmount. Decoded amounts are in KES, and negative
+// amounts are rejected with ErrInvalidAmount.
+type Whole Money
+
+func (w Whole) String() string {
+	return Money(w).Decimal()
+}
+
+func (w Whole) MarshalText() ([]byte, error) {
+	units, err := Money(w).Units()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strconv.FormatInt(units, 10)), nil
+}
+
+func (w *Whole) UnmarshalText(text []byte) error {
+	m, err := ParseUnits(string(text), KES)
+	if err != nil {
+		return err
+	}
+	if m.IsNegative() {
+		return fmt.Errorf("%w: %s is negative", ErrInvalidAmount, text)
+	}
+	*w = Whole(m)
+	return nil
+}
+
+func (w Whole) MarshalJSON() ([]byte, error) {
+	text, err := w.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return jsoniter.Marshal(string(text))
+}
+
+func (w *Whole) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := jsoniter.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return w.UnmarshalText([]byte(s))
+}
+
+// Number is the wire format of amounts sent as JSON numbers e.g. 100.5, which is
+// how quikk sends and expects amounts. Amounts are encoded and decoded from their
+// decimal representation, without going through float64. Decoded amounts are in KES.
+type Number Money
+
+func (n Number) String() string {
+	return Money(n).Decimal()
+}
+
+func (n Number) MarshalJSON() ([]byte, error) {
+	// trim insignificant zeros of the fraction e.g. 100.50 -> 100.5, 100.00 -> 100
+	b := []byte(Money(n).Decimal())
+	b = bytes.TrimRight(b, "0")
+	b = bytes.TrimSuffix(b, []byte("."))
+	return b, nil
+}
+
+func (n *Number) UnmarshalJSON(b []byte) error {
+	var num jsoniter.Number
+	if err := jsoniter.Unmarshal(b, &num); err != nil {
+		return err
+	}
+
+	m, err := Parse(num.String(), KES)
+	if err != nil {
+		// fallback for numbers with more than 2 decimals or exponents
+		f, ferr := num.Float64()
+		if ferr != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, num)
+		}
+		if m, err = FromFloat(f, KES); err != nil {
+			return err
+		}
+	}
+	*n = Number(m)
+	return nil
+}
@@ -0,0 +1,77 @@
+package money_test
+
+import (
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/money"
+)
+
+func TestMoney_JSON(t *testing.T) {
+	m := money.New(-154050, money.KES)
+
+	b, err := jsoniter.Marshal(m)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":"-1540.50","currency":"KES"}`, string(b))
+
+	var result money.Money
+	assert.NoError(t, jsoniter.Unmarshal(b, &result))
+	assert.Equal(t, m, result)
+}
+
+func TestWhole_JSON(t *testing.T) {
+	type payload struct {
+		Amount money.Whole `json:"Amount"`
+	}
+
+	b, err := jsoniter.Marshal(payload{Amount: money.Whole(money.FromUnits(100, money.KES))})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Amount":"100"}`, string(b))
+
+	// fractional shillings are rejected
+	_, err = money.Whole(money.New(10050, money.KES)).MarshalText()
+	assert.ErrorIs(t, err, money.ErrFractionalAmount)
+
+	var result payload
+	assert.NoError(t, jsoniter.Unmarshal([]byte(`{"Amount":"250"}`), &result))
+	assert.Equal(t, money.FromUnits(250, money.KES), money.Money(result.Amount))
+
+	assert.Error(t, jsoniter.Unmarshal([]byte(`{"Amount":"250.5"}`), &result))
+
+	// negative amounts are rejected
+	assert.Error(t, jsoniter.Unmarshal([]byte(`{"Amount":"-250"}`), &result))
+	var w money.Whole
+	assert.ErrorIs(t, w.UnmarshalText([]byte("-250")), money.ErrInvalidAmount)
+}
+
+func TestNumber_JSON(t *testing.T) {
+	type payload struct {
+		Amount money.Number `json:"amount"`
+	}
+
+	tcs := []struct {
+		cents    int64
+		expected string
+	}{
+		{10000, `{"amount":100}`},
+		{10050, `{"amount":100.5}`},
+		{10005, `{"amount":100.05}`},
+		{0, `{"amount":0}`},
+	}
+	for _, tc := range tcs {
+		b, err := jsoniter.Marshal(payload{Amount: money.Number(money.New(tc.cents, money.KES))})
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, string(b))
+
+		var result payload
+		assert.NoError(t, jsoniter.Unmarshal(b, &result))
+		assert.Equal(t, tc.cents, money.Money(result.Amount).Cents())
+	}
+
+	// numbers are decoded without float rounding errors
+	var result payload
+	assert.NoError(t, jsoniter.Unmarshal([]byte(`{"amount":4517632.27}`), &result))
+	assert.Equal(t, int64(451763227), money.Money(result.Amount).Cents())
+}
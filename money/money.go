@@ -0,0 +1,210 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount     = errors.New("invalid amount")
+	ErrFractionalAmount  = errors.New("amount must be in whole units")
+	ErrCurrencyMismatch  = errors.New("currency mismatch")
+	ErrAmountOutOfBounds = errors.New("amount out of bounds")
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	KES Currency = "KES"
+	USD Currency = "USD"
+)
+
+// Money is an amount of money stored as an integer number of cents (hundredths of a
+// currency unit) together with its currency. It avoids the rounding errors of float64
+// amounts. The zero value is zero of an unspecified currency.
+type Money struct {
+	cents    int64
+	currency Currency
+}
+
+// New creates Money from an amount in cents
+func New(cents int64, currency Currency) Money {
+	return Money{cents: cents, currency: currency}
+}
+
+// FromUnits creates Money from an amount in whole currency units e.g. shillings
+func FromUnits(units int64, currency Currency) Money {
+	return Money{cents: units * 100, currency: currency}
+}
+
+// FromFloat creates Money from a float64 amount, rounding to the nearest cent.
+// It is meant for amounts decoded from providers that send JSON numbers.
+func FromFloat(amount float64, currency Currency) (Money, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	cents := math.Round(amount * 100)
+	if cents > math.MaxInt64 || cents < math.MinInt64 {
+		return Money{}, ErrAmountOutOfBounds
+	}
+	return Money{cents: int64(cents), currency: currency}, nil
+}
+
+// Parse creates Money from a decimal string e.g. "100", "100.5" or "-1540.00".
+// The amount is parsed exactly, and at most 2 decimal places are allowed.
+func Parse(s string, currency Currency) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, ErrInvalidAmount
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	units, fraction, hasFraction := strings.Cut(s, ".")
+	if units == "" || len(fraction) > 2 || (hasFraction && fraction == "") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	for _, part := range []string{units, fraction} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+			}
+		}
+	}
+
+	// pad fraction to exactly 2 digits
+	fraction += strings.Repeat("0", 2-len(fraction))
+	cents, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return Money{}, ErrAmountOutOfBounds
+	}
+
+	if negative {
+		cents = -cents
+	}
+	return Money{cents: cents, currency: currency}, nil
+}
+
+// ParseUnits creates Money from a string of whole currency units e.g. "100".
+// Amounts with non-zero fractions are rejected with ErrFractionalAmount.
+func ParseUnits(s string, currency Currency) (Money, error) {
+	m, err := Parse(s, currency)
+	if err != nil {
+		return Money{}, err
+	}
+	if !m.IsWhole() {
+		return Money{}, fmt.Errorf("%w: %q", ErrFractionalAmount, s)
+	}
+	return m, nil
+}
+
+// Cents returns the amount in cents
+func (m Money) Cents() int64 {
+	return m.cents
+}
+
+// Currency returns the currency of the amount
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.cents == 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.cents < 0
+}
+
+// IsWhole reports whether the amount has no fractional cents
+func (m Money) IsWhole() bool {
+	return m.cents%100 == 0
+}
+
+// Units returns the amount in whole currency units. It returns ErrFractionalAmount
+// if the amount has a fraction, since M-PESA only transacts whole shillings.
+func (m Money) Units() (int64, error) {
+	if !m.IsWhole() {
+		return 0, fmt.Errorf("%w: %s", ErrFractionalAmount, m)
+	}
+	return m.cents / 100, nil
+}
+
+// Float64 returns the amount in currency units as a float64.
+// It should only be used for presentation or for providers that require JSON numbers.
+func (m Money) Float64() float64 {
+	return float64(m.cents) / 100
+}
+
+// Decimal returns the amount as a decimal string with 2 decimal places e.g. "100.50"
+func (m Money) Decimal() string {
+	sign, cents := "", uint64(m.cents)
+	if m.cents < 0 {
+		// negate without overflowing on math.MinInt64
+		sign, cents = "-", uint64(-(m.cents+1))+1
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// String returns the currency and decimal amount e.g. "KES 100.50"
+func (m Money) String() string {
+	if m.currency == "" {
+		return m.Decimal()
+	}
+	return string(m.currency) + " " + m.Decimal()
+}
+
+// Equal reports whether both the amount and currency are equal
+func (m Money) Equal(o Money) bool {
+	return m.cents == o.cents && m.currency == o.currency
+}
+
+// Cmp compares m and o and returns -1, 0 or +1. It returns ErrCurrencyMismatch
+// if the amounts are in different currencies.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.currency != o.currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.cents < o.cents:
+		return -1, nil
+	case m.cents > o.cents:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Add returns the sum of m and o
+func (m Money) Add(o Money) (Money, error) {
+	if m.currency != o.currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.cents + o.cents
+	if (o.cents > 0 && sum < m.cents) || (o.cents < 0 && sum > m.cents) {
+		return Money{}, ErrAmountOutOfBounds
+	}
+	return Money{cents: sum, currency: m.currency}, nil
+}
+
+// Sub returns the difference of m and o
+func (m Money) Sub(o Money) (Money, error) {
+	if o.cents == math.MinInt64 {
+		return Money{}, ErrAmountOutOfBounds
+	}
+	return m.Add(Money{cents: -o.cents, currency: o.currency})
+}
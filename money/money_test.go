@@ -0,0 +1,108 @@
+package money_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/money"
+)
+
+func TestParse(t *testing.T) {
+	tcs := []struct {
+		input string
+		cents int64
+		err   error
+	}{
+		{"100", 10000, nil},
+		{"100.5", 10050, nil},
+		{"100.05", 10005, nil},
+		{"-1540.00", -154000, nil},
+		{"+1", 100, nil},
+		{" 20 ", 2000, nil},
+		{"", 0, money.ErrInvalidAmount},
+		{"10.", 0, money.ErrInvalidAmount},
+		{".5", 0, money.ErrInvalidAmount},
+		{"10.005", 0, money.ErrInvalidAmount},
+		{"1e3", 0, money.ErrInvalidAmount},
+		{"ten", 0, money.ErrInvalidAmount},
+		{"99999999999999999999", 0, money.ErrAmountOutOfBounds},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.input, func(t *testing.T) {
+			m, err := money.Parse(tc.input, money.KES)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.cents, m.Cents())
+			assert.Equal(t, money.KES, m.Currency())
+		})
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	m, err := money.ParseUnits("100.00", money.KES)
+	assert.NoError(t, err)
+	assert.Equal(t, money.FromUnits(100, money.KES), m)
+
+	_, err = money.ParseUnits("100.50", money.KES)
+	assert.ErrorIs(t, err, money.ErrFractionalAmount)
+}
+
+func TestFromFloat(t *testing.T) {
+	// 0.1 + 0.2 is not exactly 0.3 as a float64
+	m, err := money.FromFloat(0.1+0.2, money.KES)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30), m.Cents())
+
+	m, err = money.FromFloat(4517632.27, money.KES)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(451763227), m.Cents())
+
+	_, err = money.FromFloat(math.NaN(), money.KES)
+	assert.ErrorIs(t, err, money.ErrInvalidAmount)
+}
+
+func TestMoney_Decimal(t *testing.T) {
+	assert.Equal(t, "100.50", money.New(10050, money.KES).Decimal())
+	assert.Equal(t, "0.05", money.New(5, money.KES).Decimal())
+	assert.Equal(t, "-0.05", money.New(-5, money.KES).Decimal())
+	assert.Equal(t, "-92233720368547758.08", money.New(math.MinInt64, money.KES).Decimal())
+	assert.Equal(t, "KES 1.00", money.FromUnits(1, money.KES).String())
+}
+
+func TestMoney_Units(t *testing.T) {
+	units, err := money.New(10000, money.KES).Units()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), units)
+
+	_, err = money.New(10050, money.KES).Units()
+	assert.ErrorIs(t, err, money.ErrFractionalAmount)
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a := money.New(10050, money.KES)
+	b := money.New(50, money.KES)
+
+	sum, err := a.Add(b)
+	assert.NoError(t, err)
+	assert.Equal(t, money.FromUnits(101, money.KES), sum)
+
+	diff, err := a.Sub(b)
+	assert.NoError(t, err)
+	assert.Equal(t, money.FromUnits(100, money.KES), diff)
+
+	cmp, err := a.Cmp(b)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cmp)
+
+	_, err = a.Add(money.New(1, money.USD))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	_, err = money.New(math.MaxInt64, money.KES).Add(b)
+	assert.ErrorIs(t, err, money.ErrAmountOutOfBounds)
+}
@@ -0,0 +1,205 @@
+package phone
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const countryCode = "254"
+
+var ErrInvalidNumber = errors.New("invalid kenyan mobile number")
+
+// Network is the mobile network operator a number is registered to
+type Network string
+
+const (
+	NetworkUnknown   Network = "Unknown"
+	NetworkSafaricom Network = "Safaricom"
+	NetworkAirtel    Network = "Airtel"
+	NetworkTelkom    Network = "Telkom"
+)
+
+// networkPrefixes maps the leading digits of the 9-digit subscriber number
+// to the network operator that was allocated the number range.
+// Longer prefixes take precedence over shorter ones.
+var networkPrefixes = map[string]Network{
+	// Safaricom
+	"70": NetworkSafaricom, "71": NetworkSafaricom, "72": NetworkSafaricom, "79": NetworkSafaricom,
+	"740": NetworkSafaricom, "741": NetworkSafaricom, "742": NetworkSafaricom, "743": NetworkSafaricom,
+	"745": NetworkSafaricom, "746": NetworkSafaricom, "748": NetworkSafaricom, "757": NetworkSafaricom,
+	"758": NetworkSafaricom, "759": NetworkSafaricom, "768": NetworkSafaricom, "769": NetworkSafaricom,
+	"110": NetworkSafaricom, "111": NetworkSafaricom, "112": NetworkSafaricom, "113": NetworkSafaricom,
+	"114": NetworkSafaricom, "115": NetworkSafaricom,
+	// Airtel
+	"73": NetworkAirtel, "78": NetworkAirtel,
+	"750": NetworkAirtel, "751": NetworkAirtel, "752": NetworkAirtel, "753": NetworkAirtel,
+	"754": NetworkAirtel, "755": NetworkAirtel, "756": NetworkAirtel, "762": NetworkAirtel,
+	"100": NetworkAirtel, "101": NetworkAirtel, "102": NetworkAirtel, "103": NetworkAirtel,
+	"104": NetworkAirtel, "105": NetworkAirtel, "106": NetworkAirtel,
+	// Telkom
+	"77": NetworkTelkom,
+}
+
+// Format describes how a number is written out
+type Format int
+
+const (
+	// FormatMSISDN is the country code followed by the subscriber number e.g. 254712345678
+	FormatMSISDN Format = iota
+	// FormatE164 is the international format e.g. +254712345678
+	FormatE164
+	// FormatNational is the local format with a trunk prefix e.g. 0712345678
+	FormatNational
+)
+
+// Provider is a payment service provider that expects numbers in a specific format
+type Provider string
+
+const (
+	ProviderDaraja Provider = "daraja"
+	ProviderQuikk  Provider = "quikk"
+	ProviderTanda  Provider = "tanda"
+)
+
+// providerFormats maps each provider to the number format it expects
+var providerFormats = map[Provider]Format{
+	ProviderDaraja: FormatMSISDN, // e.g. PhoneNumber, PartyA and PartyB fields
+	ProviderQuikk:  FormatMSISDN, // e.g. customer_no and recipient_no fields
+	ProviderTanda:  FormatMSISDN, // e.g. accountNumber and mobileNumber parameters
+}
+
+// Number is a normalized Kenyan mobile number
+type Number struct {
+	// subscriber is the 9-digit number without country code or trunk prefix
+	subscriber string
+}
+
+// Parse parses a Kenyan mobile number written in any of the common formats, e.g.
+// "+254 712 345 678", "254712345678", "0712345678", "0112-345-678" or "712345678".
+// Spaces, dashes, dots and brackets are ignored.
+func Parse(s string) (Number, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+
+	digits = strings.TrimPrefix(digits, "+")
+	switch {
+	case len(digits) == 12 && strings.HasPrefix(digits, countryCode):
+		digits = digits[len(countryCode):]
+	case len(digits) == 10 && strings.HasPrefix(digits, "0"):
+		digits = digits[1:]
+	}
+
+	if len(digits) != 9 || (digits[0] != '7' && digits[0] != '1') {
+		return Number{}, ErrInvalidNumber
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Number{}, ErrInvalidNumber
+		}
+	}
+
+	return Number{subscriber: digits}, nil
+}
+
+// IsZero reports whether n is the zero value
+func (n Number) IsZero() bool {
+	return n.subscriber == ""
+}
+
+// String returns the number in FormatMSISDN
+func (n Number) String() string {
+	return n.Format(FormatMSISDN)
+}
+
+// Format writes out the number in the given format
+func (n Number) Format(f Format) string {
+	if n.IsZero() {
+		return ""
+	}
+
+	switch f {
+	case FormatE164:
+		return "+" + countryCode + n.subscriber
+	case FormatNational:
+		return "0" + n.subscriber
+	default:
+		return countryCode + n.subscriber
+	}
+}
+
+// For writes out the number in the format expected by the given provider
+func (n Number) For(p Provider) string {
+	return n.Format(providerFormats[p])
+}
+
+// Network detects the mobile network operator from the number prefix
+func (n Number) Network() Network {
+	for i := 3; i >= 2; i-- {
+		if len(n.subscriber) < i {
+			continue
+		}
+		if network, ok := networkPrefixes[n.subscriber[:i]]; ok {
+			return network
+		}
+	}
+	return NetworkUnknown
+}
+
+// SHA1 returns the hex encoded sha1 hash of the number in FormatMSISDN,
+// as sent in the sender_no_sha1 and recipient_no_sha1 fields of quikk webhooks
+func (n Number) SHA1() string {
+	sum := sha1.Sum([]byte(n.Format(FormatMSISDN)))
+	return hex.EncodeToString(sum[:])
+}
+
+// SHA256 returns the hex encoded sha256 hash of the number in FormatMSISDN,
+// as sent in the sender_no_sha256 and recipient_no_sha256 fields of quikk webhooks
+func (n Number) SHA256() string {
+	sum := sha256.Sum256([]byte(n.Format(FormatMSISDN)))
+	return hex.EncodeToString(sum[:])
+}
+
+// MatchesHash reports whether hash is the sha1 or sha256 hex digest of the number
+func (n Number) MatchesHash(hash string) bool {
+	if n.IsZero() {
+		return false
+	}
+	hash = strings.ToLower(hash)
+	return hash == n.SHA1() || hash == n.SHA256()
+}
+
+// MatchesMask reports whether a masked number e.g. "2547*****567" could be the number.
+// The mask is compared digit by digit against the number in the same format, with
+// '*' matching any digit.
+func (n Number) MatchesMask(masked string) bool {
+	if n.IsZero() {
+		return false
+	}
+
+	for _, f := range []Format{FormatMSISDN, FormatNational, FormatE164} {
+		s := n.Format(f)
+		if len(s) != len(masked) {
+			continue
+		}
+
+		matches := true
+		for i := range len(s) {
+			if masked[i] != '*' && masked[i] != s[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package phone_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/phone"
+)
+
+func TestParse(t *testing.T) {
+	tcs := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{"254712345678", "254712345678", false},
+		{"+254712345678", "254712345678", false},
+		{"+254 712 345 678", "254712345678", false},
+		{"0712345678", "254712345678", false},
+		{"0712-345-678", "254712345678", false},
+		{"712345678", "254712345678", false},
+		{"0112345678", "254112345678", false},
+		{"(0720) 000 000", "254720000000", false},
+		{"", "", true},
+		{"071234567", "", true},
+		{"07123456789", "", true},
+		{"0212345678", "", true},
+		{"255712345678", "", true},
+		{"07123a5678", "", true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.input, func(t *testing.T) {
+			n, err := phone.Parse(tc.input)
+			if tc.err {
+				assert.ErrorIs(t, err, phone.ErrInvalidNumber)
+				assert.True(t, n.IsZero())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, n.String())
+		})
+	}
+}
+
+func TestNumber_Format(t *testing.T) {
+	n, err := phone.Parse("0720000000")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "254720000000", n.Format(phone.FormatMSISDN))
+	assert.Equal(t, "+254720000000", n.Format(phone.FormatE164))
+	assert.Equal(t, "0720000000", n.Format(phone.FormatNational))
+
+	assert.Equal(t, "254720000000", n.For(phone.ProviderDaraja))
+	assert.Equal(t, "254720000000", n.For(phone.ProviderQuikk))
+	assert.Equal(t, "254720000000", n.For(phone.ProviderTanda))
+
+	// zero value formats to an empty string
+	assert.Equal(t, "", phone.Number{}.Format(phone.FormatE164))
+}
+
+func TestNumber_Network(t *testing.T) {
+	tcs := []struct {
+		input    string
+		expected phone.Network
+	}{
+		{"0712345678", phone.NetworkSafaricom},
+		{"0799345678", phone.NetworkSafaricom},
+		{"0745345678", phone.NetworkSafaricom},
+		{"0110345678", phone.NetworkSafaricom},
+		{"0733345678", phone.NetworkAirtel},
+		{"0752345678", phone.NetworkAirtel},
+		{"0101345678", phone.NetworkAirtel},
+		{"0772345678", phone.NetworkTelkom},
+		{"0747345678", phone.NetworkUnknown},
+		{"0119345678", phone.NetworkUnknown},
+	}
+
+	for _, tc := range tcs {
+		n, err := phone.Parse(tc.input)
+		assert.NoError(t, err)
+		assert.Equalf(t, tc.expected, n.Network(), "network of %s", tc.input)
+	}
+}
+
+func TestNumber_Hashes(t *testing.T) {
+	// hashes taken from the quikk webhook examples for sender_no 254701234567
+	sha1 := "f001d87b438ff2959d6786f02d7dfa0fa2bbd17b"
+	sha256 := "0b75ca5ae93ccfb939dbeda34f9b5e608d8eca085efaa9ad7f40ff9d81a6acca"
+
+	n, err := phone.Parse("0701 234 567")
+	assert.NoError(t, err)
+
+	assert.Equal(t, sha1, n.SHA1())
+	assert.Equal(t, sha256, n.SHA256())
+	assert.True(t, n.MatchesHash(sha1))
+	assert.True(t, n.MatchesHash(sha256))
+
+	other, _ := phone.Parse("0701234568")
+	assert.False(t, other.MatchesHash(sha1))
+	assert.False(t, phone.Number{}.MatchesHash(sha1))
+}
+
+func TestNumber_MatchesMask(t *testing.T) {
+	n, err := phone.Parse("254701234567")
+	assert.NoError(t, err)
+
+	assert.True(t, n.MatchesMask("2547*****567"))
+	assert.True(t, n.MatchesMask("07******67"))
+	assert.False(t, n.MatchesMask("2547*****568"))
+	assert.False(t, n.MatchesMask("null"))
+}
@@ -98,6 +98,17 @@ func (client Client) ChargeRequest(input RequestCharge, ref string, opts ...gore
 	return req, &output
 }
 
+func (client Client) Charge(ctx context.Context, input RequestCharge, ref string) (ResponseDefault, error) {
+	req, out := client.ChargeRequest(input, ref)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseDefault{}, err
+	}
+
+	return *out, nil
+}
+
 func (client Client) PayoutRequest(input RequestPayout, ref string, opts ...gorequest.Option) (*gorequest.Request, *ResponseDefault) {
 	op := gorequest.Operation{
 		Name:   OperationPayout,
@@ -123,6 +134,17 @@ func (client Client) PayoutRequest(input RequestPayout, ref string, opts ...gore
 	return req, &output
 }
 
+func (client Client) Payout(ctx context.Context, input RequestPayout, ref string) (ResponseDefault, error) {
+	req, out := client.PayoutRequest(input, ref)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseDefault{}, err
+	}
+
+	return *out, nil
+}
+
 func (client Client) TransferRequest(input RequestTransfer, ref string, opts ...gorequest.Option) (*gorequest.Request, *ResponseDefault) {
 	op := gorequest.Operation{
 		Name:   OperationTransfer,
@@ -148,6 +170,17 @@ func (client Client) TransferRequest(input RequestTransfer, ref string, opts ...
 	return req, &output
 }
 
+func (client Client) Transfer(ctx context.Context, input RequestTransfer, ref string) (ResponseDefault, error) {
+	req, out := client.TransferRequest(input, ref)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseDefault{}, err
+	}
+
+	return *out, nil
+}
+
 func (client Client) BalanceRequest(input RequestAccountBalance, ref string, opts ...gorequest.Option) (*gorequest.Request, *ResponseDefault) {
 	op := gorequest.Operation{
 		Name:   OperationBalance,
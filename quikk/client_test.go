@@ -90,3 +90,60 @@ func TestClient_TransactionSearch(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, res.Data.Attributes.ResourceID, resourceID)
 }
+
+func TestClient_Charge(t *testing.T) {
+	resourceID := xid.New().String()
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(quikk2.EndpointCharge, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"data":{"id":"1","type":"charge","attributes":{"resource_id":"%s"}}}`, resourceID)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := quikk2.New(quikk2.Config{Endpoint: server.URL})
+	res, err := client.Charge(t.Context(), quikk2.RequestCharge{}, xid.New().String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, res.Data.Attributes.ResourceID, resourceID)
+}
+
+func TestClient_Payout(t *testing.T) {
+	resourceID := xid.New().String()
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(quikk2.EndpointPayout, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"data":{"id":"1","type":"payout","attributes":{"resource_id":"%s"}}}`, resourceID)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := quikk2.New(quikk2.Config{Endpoint: server.URL})
+	res, err := client.Payout(t.Context(), quikk2.RequestPayout{}, xid.New().String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, res.Data.Attributes.ResourceID, resourceID)
+}
+
+func TestClient_Transfer(t *testing.T) {
+	resourceID := xid.New().String()
+
+	// create a mock test server
+	mux := http.NewServeMux()
+	mux.HandleFunc(quikk2.EndpointTransfer, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"data":{"id":"1","type":"transfer","attributes":{"resource_id":"%s"}}}`, resourceID)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := quikk2.New(quikk2.Config{Endpoint: server.URL})
+	res, err := client.Transfer(t.Context(), quikk2.RequestTransfer{}, xid.New().String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, res.Data.Attributes.ResourceID, resourceID)
+}
@@ -9,6 +9,8 @@ import (
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/internal/exchange"
 )
 
 // Sign is a build hook that generates a signature for the request
@@ -33,6 +35,13 @@ func (r errorResponse) Error() string {
 	return fmt.Sprintf("<%s> %s", r.Errors[0].Status, r.Errors[0].Title)
 }
 
+func (r errorResponse) ResultCode() string {
+	if len(r.Errors) == 0 {
+		return ""
+	}
+	return r.Errors[0].Status
+}
+
 // ResponseDecoder decodes the response body into the Data field of gorequest.Request if the status code
 // is 200. Otherwise, it decodes into the ErrorResponse model
 var ResponseDecoder = gorequest.Hook{
@@ -44,7 +53,8 @@ var ResponseDecoder = gorequest.Hook{
 
 			response := &errorResponse{}
 			if err := jsoniter.NewDecoder(r.Response.Body).Decode(response); err != nil {
-				r.Error = errors.Join(statusError, errors.New("failed to decode response"), err)
+				err = errors.Join(statusError, errors.New("failed to decode response"), err)
+				r.Error = &exchange.DecodeError{StatusCode: r.Response.StatusCode, Err: err}
 				return
 			}
 			r.Error = errors.Join(statusError, response)
@@ -3,6 +3,8 @@ package quikk
 import (
 	"fmt"
 	"time"
+
+	"github.com/SirWaithaka/payments/money"
 )
 
 // ResultCode represents the code returned from quikk in both an asynchronous
@@ -606,3 +608,68 @@ type WebhookAttributesBalanceSearch struct {
 	OrgSettlementAccountBalance float64   `json:"balance_organization_settlement_ac"`
 	CheckedAt                   time.Time `json:"checked_at"`
 }
+
+// MONEY ACCESSORS
+
+// toMoney converts an amount decoded from or sent to quikk as a JSON number into money.Money
+func toMoney(amount float64) (money.Money, error) {
+	return money.FromFloat(amount, money.KES)
+}
+
+// fromMoney converts m into the JSON number amount expected by quikk.
+// Amounts with fractional shillings are rejected, since M-PESA only transacts whole shillings.
+func fromMoney(m money.Money) (float64, error) {
+	if m.Currency() != money.KES {
+		return 0, fmt.Errorf("%w: %s", money.ErrCurrencyMismatch, m.Currency())
+	}
+	units, err := m.Units()
+	return float64(units), err
+}
+
+// Money returns Amount as money.Money
+func (r RequestCharge) Money() (money.Money, error) { return toMoney(r.Amount) }
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestCharge) SetMoney(m money.Money) (err error) {
+	r.Amount, err = fromMoney(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestPayout) Money() (money.Money, error) { return toMoney(r.Amount) }
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestPayout) SetMoney(m money.Money) (err error) {
+	r.Amount, err = fromMoney(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (r RequestTransfer) Money() (money.Money, error) { return toMoney(r.Amount) }
+
+// SetMoney sets Amount from m. Amounts with fractional shillings are rejected
+func (r *RequestTransfer) SetMoney(m money.Money) (err error) {
+	r.Amount, err = fromMoney(m)
+	return err
+}
+
+// Money returns Amount as money.Money
+func (w WebhookAttributesPayinValidation) Money() (money.Money, error) { return toMoney(w.Amount) }
+
+// Money returns Amount as money.Money
+func (w WebhookAttributesPayinConfirmation) Money() (money.Money, error) { return toMoney(w.Amount) }
+
+// Money returns Amount as money.Money
+func (w WebhookAttributesCharge) Money() (money.Money, error) { return toMoney(w.Amount) }
+
+// Money returns Amount as money.Money
+func (w WebhookAttributesPayout) Money() (money.Money, error) { return toMoney(w.Amount) }
+
+// Money returns Amount as money.Money
+func (w WebhookAttributesTransfer) Money() (money.Money, error) { return toMoney(w.Amount) }
+
+// Money returns Amount as money.Money
+func (w WebhookAttributesRefund) Money() (money.Money, error) { return toMoney(w.Amount) }
+
+// Money returns Amount as money.Money
+func (w WebhookAttributesTransactionSearch) Money() (money.Money, error) { return toMoney(w.Amount) }
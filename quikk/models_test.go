@@ -0,0 +1,27 @@
+package quikk_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/quikk"
+)
+
+func TestRequestPayout_Money(t *testing.T) {
+	t.Run("test that the amount is converted", func(t *testing.T) {
+		m, err := quikk.RequestPayout{Amount: 150}.Money()
+		assert.NoError(t, err)
+		assert.Equal(t, money.New(15000, money.KES), m)
+	})
+
+	t.Run("test that an invalid amount is an error", func(t *testing.T) {
+		_, err := quikk.RequestPayout{Amount: math.NaN()}.Money()
+		assert.ErrorIs(t, err, money.ErrInvalidAmount)
+
+		_, err = quikk.WebhookAttributesPayout{Amount: math.Inf(1)}.Money()
+		assert.ErrorIs(t, err, money.ErrInvalidAmount)
+	})
+}
@@ -0,0 +1,298 @@
+package quikktest
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SirWaithaka/payments/quikk"
+)
+
+// layout of the txn_created_at and txn_charge_created_at webhook attributes
+const txnTime = "2006-01-02T15:04:05-0700"
+
+// document is the JSON:API document of a synchronous response
+type document struct {
+	Data data `json:"data"`
+}
+
+type data struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Attributes attributes `json:"attributes"`
+}
+
+type attributes struct {
+	ResourceID string `json:"resource_id"`
+}
+
+// meta is the meta object of a failed webhook
+type meta struct {
+	Status string           `json:"status"`
+	Code   quikk.ResultCode `json:"code"`
+	Detail string           `json:"detail"`
+}
+
+// webhook is the document posted to the webhook url, it decodes into quikk.WebhookResult
+type webhook[T any] struct {
+	Data quikk.Data[T] `json:"data"`
+	Meta *meta         `json:"meta,omitempty"`
+}
+
+func newWebhook[T any](id, typ string, attr T, sc Scenario) webhook[T] {
+	w := webhook[T]{Data: quikk.Data[T]{ID: id, Type: typ, Attributes: attr}}
+	if sc.failed() {
+		w.Meta = &meta{Status: "FAIL", Code: sc.Code, Detail: sc.Detail}
+	}
+	return w
+}
+
+// transaction is a completed charge, payout or transfer, found by transaction searches
+type transaction struct {
+	resourceID string
+	responseID string
+	txnID      string
+	txnType    string
+	category   string
+	amount     float64
+	sender     string
+	recipient  string
+	createdAt  time.Time
+}
+
+func hashes(number string) (string, string) {
+	s1 := sha1.Sum([]byte(number))
+	s256 := sha256.Sum256([]byte(number))
+	return hex.EncodeToString(s1[:]), hex.EncodeToString(s256[:])
+}
+
+// mask hides the middle digits of an msisdn the way quikk does e.g. 2547*****024
+func mask(msisdn string) string {
+	if len(msisdn) < 8 {
+		return msisdn
+	}
+	return msisdn[:4] + strings.Repeat("*", len(msisdn)-7) + msisdn[len(msisdn)-3:]
+}
+
+func msisdn(number string) bool {
+	if len(number) != 12 || !strings.HasPrefix(number, "254") {
+		return false
+	}
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) routes(mux *http.ServeMux) {
+	mux.Handle("POST "+quikk.EndpointCharge, handle(s, quikk.OperationCharge, "charge", s.charge))
+	mux.Handle("POST "+quikk.EndpointPayout, handle(s, quikk.OperationPayout, "payout", s.payout))
+	mux.Handle("POST "+quikk.EndpointTransfer, handle(s, quikk.OperationTransfer, "transfer", s.transfer))
+	mux.Handle("POST "+quikk.EndpointBalance, handle(s, quikk.OperationBalance, "search", s.balanceSearch))
+	mux.Handle("POST "+quikk.EndpointTransactionSearch, handle(s, quikk.OperationTransactionSearch, "search", s.transactionSearch))
+}
+
+// settle records a successful transaction and moves its amount in or out of the utility account
+func (s *Server) settle(txn transaction, sign float64) (balance float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transactions = append(s.transactions, txn)
+	s.balance += sign * txn.amount
+	return s.balance
+}
+
+func (s *Server) charge(id string, attr quikk.RequestCharge, sc Scenario) (data, error) {
+	switch {
+	case attr.Amount <= 0:
+		return data{}, badRequest("amount")
+	case !msisdn(attr.CustomerNo):
+		return data{}, badRequest("customer_no")
+	case attr.ShortCode == "":
+		return data{}, badRequest("short_code")
+	}
+
+	chargeID := s.chargeID()
+	result := quikk.WebhookAttributesCharge{TxnChargeID: chargeID}
+	s.deliver(sc, func() any { return newWebhook(id, "payin", result, sc) }, func() {
+		if sc.failed() {
+			return
+		}
+		now := time.Now()
+		result.TxnID = s.txnID()
+		result.Amount = attr.Amount
+		result.TxnChargeCreatedAt = now.Format(txnTime)
+		result.SenderType = "msisdn"
+		result.SenderNumber = attr.CustomerNo
+		result.SenderNumberSha1, result.SenderNumberSha256 = hashes(attr.CustomerNo)
+
+		s.settle(transaction{
+			resourceID: id, responseID: chargeID, txnID: result.TxnID, txnType: "payin", category: "Paybill",
+			amount: attr.Amount, sender: attr.CustomerNo, recipient: attr.ShortCode, createdAt: now,
+		}, 1)
+	})
+
+	return data{ID: chargeID, Type: "charge", Attributes: attributes{ResourceID: id}}, nil
+}
+
+func (s *Server) payout(id string, attr quikk.RequestPayout, sc Scenario) (data, error) {
+	switch {
+	case attr.Amount <= 0:
+		return data{}, badRequest("amount")
+	case !msisdn(attr.RecipientNo):
+		return data{}, badRequest("recipient_no")
+	case attr.ShortCode == "":
+		return data{}, badRequest("short_code")
+	}
+
+	responseID := s.responseID()
+	result := quikk.WebhookAttributesPayout{ResponseID: responseID}
+	s.deliver(sc, func() any { return newWebhook(id, "payout", result, sc) }, func() {
+		result.TxnID = s.txnID()
+		if sc.failed() {
+			return
+		}
+		now := time.Now()
+		result.RecipientType = "msisdn"
+		result.RecipientNumber = mask(attr.RecipientNo)
+		result.RecipientNumberSha1, result.RecipientNumberSha256 = hashes(attr.RecipientNo)
+		result.RecipientRegistered = "Y"
+		result.Amount = attr.Amount
+		result.TxnCreatedAt = now.Format(txnTime)
+
+		result.UtilityAccountBalance = s.settle(transaction{
+			resourceID: id, responseID: responseID, txnID: result.TxnID, txnType: "payout", category: "BusinessPayment",
+			amount: attr.Amount, sender: attr.ShortCode, recipient: attr.RecipientNo, createdAt: now,
+		}, -1)
+		result.WorkingAccountBalance = result.UtilityAccountBalance
+	})
+
+	return data{ID: responseID, Type: "payout", Attributes: attributes{ResourceID: id}}, nil
+}
+
+func (s *Server) transfer(id string, attr quikk.RequestTransfer, sc Scenario) (data, error) {
+	switch {
+	case attr.Amount <= 0:
+		return data{}, badRequest("amount")
+	case attr.RecipientNo == "":
+		return data{}, badRequest("recipient_no")
+	case attr.ShortCode == "":
+		return data{}, badRequest("short_code")
+	case attr.RecipientCategory != "" && attr.RecipientCategory != "till" && attr.RecipientCategory != "paybill":
+		return data{}, badRequest("recipient_category")
+	}
+
+	responseID := s.responseID()
+	result := quikk.WebhookAttributesTransfer{ResponseID: responseID}
+	s.deliver(sc, func() any { return newWebhook(id, "transfer", result, sc) }, func() {
+		result.TxnID = s.txnID()
+		if sc.failed() {
+			return
+		}
+		now := time.Now()
+		result.Reference = attr.AccountNo
+		result.RecipientType = "short_code"
+		result.RecipientNumber = attr.RecipientNo
+		result.RecipientNumberSha1, result.RecipientNumberSha256 = hashes(attr.RecipientNo)
+		result.RecipientName = "Test Recipient"
+		result.ShortCode = attr.ShortCode
+		result.ShortCodeName = "Test Shortcode"
+		result.Amount = attr.Amount
+		result.TxnCreatedAt = now.Format(txnTime)
+
+		result.UtilityAccountBalance = s.settle(transaction{
+			resourceID: id, responseID: responseID, txnID: result.TxnID, txnType: "transfer", category: "BusinessPayBill",
+			amount: attr.Amount, sender: attr.ShortCode, recipient: attr.RecipientNo, createdAt: now,
+		}, -1)
+		result.WorkingAccountBalance = result.UtilityAccountBalance
+	})
+
+	return data{ID: responseID, Type: "transfer", Attributes: attributes{ResourceID: id}}, nil
+}
+
+func (s *Server) balanceSearch(id string, attr quikk.RequestAccountBalance, sc Scenario) (data, error) {
+	if attr.ShortCode == "" {
+		return data{}, badRequest("short_code")
+	}
+
+	responseID := s.responseID()
+	result := quikk.WebhookAttributesBalanceSearch{ResponseID: responseID}
+	s.deliver(sc, func() any { return newWebhook(id, "search", result, sc) }, func() {
+		result.TxnID = s.txnID()
+		if sc.failed() {
+			return
+		}
+		s.mu.Lock()
+		balance := s.balance
+		s.mu.Unlock()
+
+		result.UtilityAccountBalance = balance
+		result.WorkingAccountBalance = balance
+		result.CheckedAt = time.Now().UTC()
+	})
+
+	return data{ID: responseID, Type: "search", Attributes: attributes{ResourceID: id}}, nil
+}
+
+// transactionSearch finds a completed transaction by the resource_id, response_id or txn_id
+// of its request. A transaction that is not found fails with the code "404".
+func (s *Server) transactionSearch(id string, attr quikk.RequestTransactionStatus, sc Scenario) (data, error) {
+	switch {
+	case attr.ShortCode == "":
+		return data{}, badRequest("short_code")
+	case attr.Reference == "":
+		return data{}, badRequest("q")
+	case attr.ReferenceType != "resource_id" && attr.ReferenceType != "response_id" && attr.ReferenceType != "txn_id":
+		return data{}, badRequest("on")
+	}
+
+	responseID := s.responseID()
+	result := quikk.WebhookAttributesTransactionSearch{ResponseID: responseID}
+	s.deliver(sc, func() any { return newWebhook(id, "search", result, sc) }, func() {
+		if sc.failed() {
+			return
+		}
+		txn, ok := s.find(attr.ReferenceType, attr.Reference)
+		if !ok {
+			sc.Code, sc.Detail = "404", "Transaction not found"
+			return
+		}
+
+		sha1, sha256 := hashes(txn.sender)
+		result = quikk.WebhookAttributesTransactionSearch{
+			TxnID:              txn.txnID,
+			ResourceID:         txn.resourceID,
+			ResponseID:         txn.responseID,
+			Amount:             txn.amount,
+			RecipientNumber:    txn.recipient,
+			SenderNumber:       mask(txn.sender),
+			SenderNumberSha1:   sha1,
+			SenderNumberSha256: sha256,
+			Category:           txn.category,
+			TxnType:            txn.txnType,
+			TxnStatus:          "Completed",
+			TxnCreatedAt:       txn.createdAt.Format(txnTime),
+		}
+	})
+
+	return data{ID: responseID, Type: "search", Attributes: attributes{ResourceID: id}}, nil
+}
+
+func (s *Server) find(on, q string) (transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, txn := range s.transactions {
+		switch {
+		case on == "resource_id" && txn.resourceID == q,
+			on == "response_id" && txn.responseID == q,
+			on == "txn_id" && txn.txnID == q:
+			return txn, true
+		}
+	}
+	return transaction{}, false
+}
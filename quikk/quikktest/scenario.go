@@ -0,0 +1,52 @@
+package quikktest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SirWaithaka/payments/quikk"
+)
+
+// Scenario scripts how the server handles a request of an operation
+type Scenario struct {
+	//Status rejects the request synchronously with an error envelope of the status
+	//and Detail. No webhook is sent.
+	Status int
+
+	//Code and Detail are sent in the meta of the webhook of an accepted request.
+	//The webhook is successful when Code is empty or quikk.ResultCodeSuccess.
+	Code   quikk.ResultCode
+	Detail string
+
+	//Timeout accepts the request but never sends its webhook
+	Timeout bool
+
+	//Delay is how long the server waits before sending the webhook
+	Delay time.Duration
+}
+
+func (sc Scenario) failed() bool {
+	return sc.Code != "" && sc.Code != quikk.ResultCodeSuccess
+}
+
+// Scenarios of accepted requests, distinguished by the result sent in the webhook
+var (
+	Success             = Scenario{Code: quikk.ResultCodeSuccess}
+	InsufficientBalance = Scenario{Code: quikk.ResultCodeInsufficientBalance, Detail: "The balance is insufficient for the transaction."}
+	Cancelled           = Scenario{Code: quikk.ResultCodeCancelledRequest, Detail: "[STK_CB - ]Request cancelled by user"}
+	UserUnreachable     = Scenario{Code: quikk.ResultCodeUserUnreachable, Detail: "[STK_CB - ]DS timeout user cannot be reached"}
+	RuleLimited         = Scenario{Code: quikk.ResultCodeRuleLimited, Detail: "The initiator is not allowed to initiate this request"}
+	Timeout             = Scenario{Timeout: true}
+)
+
+// Scenarios of requests rejected synchronously with an error envelope
+var (
+	ServiceUnavailable = Scenario{
+		Status: http.StatusServiceUnavailable,
+		Detail: "The service is temporarily unavailable, please try again later",
+	}
+	RateLimited = Scenario{
+		Status: http.StatusTooManyRequests,
+		Detail: "Rate limit exceeded",
+	}
+)
@@ -0,0 +1,357 @@
+// Package quikktest provides an in-process simulator of the quikk mpesa API for tests.
+//
+// A Server implements the /v1/mpesa endpoints of the quikk package with JSON:API responses
+// and error envelopes, and delivers the webhook of each accepted request to its webhook url.
+// Requests must be signed with quikk.Sign for Key and Secret, the server recomputes the
+// hmac-sha256 signature of the Date header and rejects requests with a bad signature or a
+// date outside MaxSkew. Each request takes the next Scenario scripted for its operation,
+// which makes results such as insufficient balance, cancelled charges and timeouts
+// reproducible without network access.
+package quikktest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/payments/quikk"
+)
+
+// Credentials accepted by the server
+const (
+	Key    = "quikktest_key"
+	Secret = "quikktest_secret"
+)
+
+// MaxSkew is how far the Date header of a signed request may be from the server clock
+const MaxSkew = 5 * time.Minute
+
+// OpeningBalance is the utility account balance of the shortcode when the server starts
+const OpeningBalance = 1_000_000.0
+
+// MediaType is the JSON:API media type of requests and responses
+const MediaType = "application/vnd.api+json"
+
+// failure is a synchronous error response
+type failure struct {
+	status int
+	detail string
+}
+
+func (f failure) Error() string {
+	return fmt.Sprintf("<%d> %s", f.status, f.detail)
+}
+
+// errorObject is an error of the JSON:API error envelope
+type errorObject struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+type errorEnvelope struct {
+	Errors []errorObject `json:"errors"`
+}
+
+// Server is an in-process quikk API. Create one with NewServer and close it with Close.
+type Server struct {
+	//URL is the base url of the server, use it as the quikk.Config Endpoint
+	URL string
+
+	server     *httptest.Server
+	webhook    *http.Client
+	webhookURL string
+
+	mu           sync.Mutex
+	seq          int
+	balance      float64
+	scripts      map[string][]Scenario
+	resources    map[string]struct{}
+	transactions []transaction
+	webhooks     sync.WaitGroup
+	errs         []error
+}
+
+// NewServer starts a Server that delivers the webhooks of accepted requests to webhookURL
+func NewServer(webhookURL string) *Server {
+	s := &Server{
+		webhook:    &http.Client{Timeout: 5 * time.Second},
+		webhookURL: webhookURL,
+		balance:    OpeningBalance,
+		scripts:    make(map[string][]Scenario),
+		resources:  make(map[string]struct{}),
+	}
+
+	mux := http.NewServeMux()
+	s.routes(mux)
+
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close waits for pending webhooks and shuts down the server
+func (s *Server) Close() {
+	s.webhooks.Wait()
+	s.server.Close()
+}
+
+// Client returns a quikk.Client for the server, signing requests with Key and Secret
+func (s *Server) Client() quikk.Client {
+	client := quikk.New(quikk.Config{Endpoint: s.URL})
+	client.Hooks.Build.PushBackHook(quikk.Sign(Key, Secret))
+	return client
+}
+
+// Script queues scenarios for an operation, e.g. quikk.OperationPayout. Each request of the
+// operation takes the next scenario in the queue, and Success once the queue is empty.
+func (s *Server) Script(operation string, scenarios ...Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[operation] = append(s.scripts[operation], scenarios...)
+}
+
+// Wait blocks until the pending webhooks are sent, and returns the errors of the ones
+// that could not be delivered
+func (s *Server) Wait() error {
+	s.webhooks.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := errors.Join(s.errs...)
+	s.errs = nil
+	return err
+}
+
+func (s *Server) next(operation string) Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queue := s.scripts[operation]
+	if len(queue) == 0 {
+		return Success
+	}
+	s.scripts[operation] = queue[1:]
+	return queue[0]
+}
+
+// signature returns the signature quikk expects for a Date header, the url escaped base64
+// encoding of the hmac-sha256 of "date: <date>"
+func signature(date string) string {
+	h := hmac.New(sha256.New, []byte(Secret))
+	h.Write([]byte("date: " + date))
+	return url.QueryEscape(base64.StdEncoding.EncodeToString(h.Sum(nil)))
+}
+
+// parseAuthorization parses the comma separated key="value" pairs of an Authorization header
+func parseAuthorization(header string) (map[string]string, error) {
+	params := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed parameter %q", pair)
+		}
+		value, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("malformed parameter %q", pair)
+		}
+		params[key] = value
+	}
+	return params, nil
+}
+
+func unauthorized(detail string) failure {
+	return failure{http.StatusUnauthorized, detail}
+}
+
+// verify checks the Authorization header of a request signed with quikk.Sign
+func (s *Server) verify(r *http.Request) error {
+	params, err := parseAuthorization(r.Header.Get("Authorization"))
+	if err != nil {
+		return unauthorized("Invalid authorization header")
+	}
+	if params["keyId"] != Key {
+		return unauthorized("Unknown key id")
+	}
+	if params["algorithm"] != "hmac-sha256" || params["headers"] != "date" {
+		return unauthorized("Unsupported signature algorithm or headers")
+	}
+
+	date := r.Header.Get("Date")
+	t, err := time.Parse(time.RFC1123, date)
+	if err != nil {
+		return unauthorized("Invalid date header")
+	}
+	if skew := time.Since(t); skew > MaxSkew || skew < -MaxSkew {
+		return unauthorized("Request date is too far from the server time")
+	}
+
+	if !hmac.Equal([]byte(params["signature"]), []byte(signature(date))) {
+		return unauthorized("Invalid signature")
+	}
+	return nil
+}
+
+// handle returns a handler of an operation. The handler verifies the signature of the
+// request, decodes the JSON:API document, checks its resource id and type, applies the
+// next scenario of the operation and replies with the data of fn.
+func handle[T any](s *Server, operation, typ string, fn func(id string, attr T, sc Scenario) (data, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.verify(r); err != nil {
+			s.fail(w, err)
+			return
+		}
+
+		if media, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); media != MediaType {
+			s.fail(w, failure{http.StatusUnsupportedMediaType, "Content-Type must be " + MediaType})
+			return
+		}
+
+		var req quikk.RequestDefault[T]
+		if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.fail(w, failure{http.StatusBadRequest, "Invalid request document"})
+			return
+		}
+		if req.Data.ID == "" {
+			s.fail(w, badRequest("data.id"))
+			return
+		}
+		if req.Data.Type != typ {
+			s.fail(w, failure{http.StatusConflict, fmt.Sprintf("Resource type must be %q", typ)})
+			return
+		}
+
+		// resource ids of accepted requests are unique per operation
+		key := operation + "/" + req.Data.ID
+		s.mu.Lock()
+		_, exists := s.resources[key]
+		s.mu.Unlock()
+		if exists {
+			s.fail(w, failure{http.StatusConflict, "Resource " + req.Data.ID + " already exists"})
+			return
+		}
+
+		sc := s.next(operation)
+		if sc.Status != 0 {
+			s.fail(w, failure{sc.Status, sc.Detail})
+			return
+		}
+
+		res, err := fn(req.Data.ID, req.Data.Attributes, sc)
+		if err != nil {
+			s.fail(w, err)
+			return
+		}
+
+		s.mu.Lock()
+		s.resources[key] = struct{}{}
+		s.mu.Unlock()
+		s.reply(w, document{Data: res})
+	}
+}
+
+func badRequest(field string) failure {
+	return failure{http.StatusBadRequest, "Invalid or missing " + field}
+}
+
+func (s *Server) reply(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(http.StatusOK)
+	_ = jsoniter.NewEncoder(w).Encode(body)
+}
+
+func (s *Server) fail(w http.ResponseWriter, err error) {
+	var f failure
+	if !errors.As(err, &f) {
+		f = failure{http.StatusInternalServerError, err.Error()}
+	}
+
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(f.status)
+	_ = jsoniter.NewEncoder(w).Encode(errorEnvelope{Errors: []errorObject{{
+		Status: strconv.Itoa(f.status),
+		Title:  http.StatusText(f.status),
+		Detail: f.detail,
+	}}})
+}
+
+// deliver posts the webhook returned by body to the webhook url after the delay of the
+// scenario, unless the scenario times out. The done func, if any, is called just before
+// body, to complete the result of the request.
+func (s *Server) deliver(sc Scenario, body func() any, done func()) {
+	if sc.Timeout {
+		return
+	}
+
+	s.webhooks.Add(1)
+	go func() {
+		defer s.webhooks.Done()
+		time.Sleep(sc.Delay)
+		if done != nil {
+			done()
+		}
+
+		if err := s.post(body()); err != nil {
+			s.mu.Lock()
+			s.errs = append(s.errs, fmt.Errorf("webhook %s: %w", s.webhookURL, err))
+			s.mu.Unlock()
+		}
+	}()
+}
+
+func (s *Server) post(body any) error {
+	b, err := jsoniter.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.webhook.Post(s.webhookURL, MediaType, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// ids
+
+func (s *Server) id() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return s.seq
+}
+
+// responseID returns an id in the format of quikk response ids e.g. AG_20190809_000040b4caf4c7a029c0
+func (s *Server) responseID() string {
+	return fmt.Sprintf("AG_%s_%020x", time.Now().Format("20060102"), s.id())
+}
+
+// chargeID returns an id in the format of quikk charge ids e.g. ws_CO_27072017151044001
+func (s *Server) chargeID() string {
+	return fmt.Sprintf("ws_CO_%s%06d", time.Now().Format("02012006150405"), s.id())
+}
+
+// txnID returns an M-PESA receipt number e.g. NI51HBHO4D
+func (s *Server) txnID() string {
+	return fmt.Sprintf("TST%07X", s.id())
+}
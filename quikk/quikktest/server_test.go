@@ -0,0 +1,297 @@
+package quikktest_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/quikk/quikktest"
+)
+
+// webhooks starts a server that collects the webhooks posted to it
+func webhooks(t *testing.T) (string, <-chan []byte) {
+	received := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := new(jsoniter.RawMessage)
+		assert.NoError(t, jsoniter.NewDecoder(r.Body).Decode(body))
+		received <- *body
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, received
+}
+
+func receive[T any](t *testing.T, received <-chan []byte) quikk.WebhookResult[T] {
+	t.Helper()
+
+	var webhook quikk.WebhookResult[T]
+	select {
+	case body := <-received:
+		assert.NoError(t, jsoniter.Unmarshal(body, &webhook))
+	case <-time.After(time.Second):
+		t.Fatal("webhook not received")
+	}
+	return webhook
+}
+
+// sign returns the Authorization header of quikk.Sign for a date
+func sign(key, secret, date string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte("date: " + date))
+	signature := url.QueryEscape(base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	return fmt.Sprintf(`keyId=%q,algorithm="hmac-sha256",headers="date",signature=%q`, key, signature)
+}
+
+var (
+	charge = quikk.RequestCharge{Amount: 500, CustomerNo: "254712345678", Reference: "ref", CustomerType: "msisdn", ShortCode: "174379"}
+	payout = quikk.RequestPayout{Amount: 100, RecipientNo: "254712345678", RecipientType: "msisdn", ShortCode: "174379"}
+)
+
+func TestServer_Charge(t *testing.T) {
+	url, received := webhooks(t)
+	server := quikktest.NewServer(url)
+	defer server.Close()
+	client := server.Client()
+
+	t.Run("test that a successful charge is matched by its txn_charge_id", func(t *testing.T) {
+		res, err := client.Charge(t.Context(), charge, "charge-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "charge", res.Data.Type)
+		assert.Equal(t, "charge-1", res.Data.Attributes.ResourceID)
+
+		webhook := receive[quikk.WebhookAttributesCharge](t, received)
+		assert.Nil(t, webhook.Meta)
+		assert.Equal(t, "charge-1", webhook.Data.ID)
+		assert.Equal(t, res.Data.ID, webhook.Data.Attributes.TxnChargeID)
+		assert.Equal(t, "254712345678", webhook.Data.Attributes.SenderNumber)
+		assert.Equal(t, 500.0, webhook.Data.Attributes.Amount)
+		assert.NotEmpty(t, webhook.Data.Attributes.TxnID)
+	})
+
+	t.Run("test that a cancelled charge fails in the meta", func(t *testing.T) {
+		server.Script(quikk.OperationCharge, quikktest.Cancelled)
+
+		res, err := client.Charge(t.Context(), charge, "charge-2")
+		assert.NoError(t, err)
+
+		webhook := receive[quikk.WebhookAttributesCharge](t, received)
+		if assert.NotNil(t, webhook.Meta) {
+			assert.Equal(t, "FAIL", webhook.Meta.Status)
+			assert.Equal(t, quikk.ResultCodeCancelledRequest, webhook.Meta.Code)
+		}
+		assert.Equal(t, res.Data.ID, webhook.Data.Attributes.TxnChargeID)
+		assert.Empty(t, webhook.Data.Attributes.TxnID)
+	})
+
+	t.Run("test that a timed out charge is not called back", func(t *testing.T) {
+		server.Script(quikk.OperationCharge, quikktest.Timeout)
+
+		_, err := client.Charge(t.Context(), charge, "charge-3")
+		assert.NoError(t, err)
+		assert.NoError(t, server.Wait())
+		assert.Empty(t, received)
+	})
+
+	t.Run("test that a resource id cannot be reused", func(t *testing.T) {
+		_, err := client.Charge(t.Context(), charge, "charge-1")
+		assert.ErrorContains(t, err, "<409> Conflict")
+	})
+}
+
+func TestServer_Payout(t *testing.T) {
+	url, received := webhooks(t)
+	server := quikktest.NewServer(url)
+	defer server.Close()
+	client := server.Client()
+
+	t.Run("test that a successful payout is found by a transaction search", func(t *testing.T) {
+		res, err := client.Payout(t.Context(), payout, "payout-1")
+		assert.NoError(t, err)
+
+		webhook := receive[quikk.WebhookAttributesPayout](t, received)
+		assert.Nil(t, webhook.Meta)
+		assert.Equal(t, res.Data.ID, webhook.Data.Attributes.ResponseID)
+		assert.Equal(t, "2547*****678", webhook.Data.Attributes.RecipientNumber)
+		assert.Equal(t, quikktest.OpeningBalance-100, webhook.Data.Attributes.UtilityAccountBalance)
+
+		_, err = client.TransactionSearch(t.Context(), quikk.RequestTransactionStatus{
+			ShortCode: "174379", Reference: webhook.Data.Attributes.TxnID, ReferenceType: "txn_id",
+		}, "search-1")
+		assert.NoError(t, err)
+
+		search := receive[quikk.WebhookAttributesTransactionSearch](t, received)
+		assert.Nil(t, search.Meta)
+		assert.Equal(t, "payout-1", search.Data.Attributes.ResourceID)
+		assert.Equal(t, res.Data.ID, search.Data.Attributes.ResponseID)
+		assert.Equal(t, 100.0, search.Data.Attributes.Amount)
+	})
+
+	t.Run("test that scenarios are taken in order", func(t *testing.T) {
+		server.Script(quikk.OperationPayout, quikktest.InsufficientBalance, quikktest.RuleLimited)
+
+		for i, code := range []quikk.ResultCode{quikk.ResultCodeInsufficientBalance, quikk.ResultCodeRuleLimited} {
+			_, err := client.Payout(t.Context(), payout, fmt.Sprintf("payout-failed-%d", i))
+			assert.NoError(t, err)
+
+			webhook := receive[quikk.WebhookAttributesPayout](t, received)
+			if assert.NotNil(t, webhook.Meta) {
+				assert.Equal(t, code, webhook.Meta.Code)
+			}
+		}
+	})
+
+	t.Run("test that a scripted error is responded synchronously", func(t *testing.T) {
+		server.Script(quikk.OperationPayout, quikktest.ServiceUnavailable)
+
+		_, err := client.Payout(t.Context(), payout, "payout-2")
+		assert.ErrorContains(t, err, "status code: 503")
+		assert.ErrorContains(t, err, "<503> Service Unavailable")
+
+		// the rejected resource id can be retried
+		_, err = client.Payout(t.Context(), payout, "payout-2")
+		assert.NoError(t, err)
+		receive[quikk.WebhookAttributesPayout](t, received)
+	})
+
+	t.Run("test that an unknown transaction is not found", func(t *testing.T) {
+		_, err := client.TransactionSearch(t.Context(), quikk.RequestTransactionStatus{
+			ShortCode: "174379", Reference: "unknown", ReferenceType: "resource_id",
+		}, "search-2")
+		assert.NoError(t, err)
+
+		search := receive[quikk.WebhookAttributesTransactionSearch](t, received)
+		if assert.NotNil(t, search.Meta) {
+			assert.Equal(t, quikk.ResultCode("404"), search.Meta.Code)
+		}
+	})
+}
+
+func TestServer_Balance(t *testing.T) {
+	url, received := webhooks(t)
+	server := quikktest.NewServer(url)
+	defer server.Close()
+	client := server.Client()
+
+	req, _ := client.BalanceRequest(quikk.RequestAccountBalance{ShortCode: "174379"}, "balance-1")
+	req.WithContext(t.Context())
+	assert.NoError(t, req.Send())
+
+	webhook := receive[quikk.WebhookAttributesBalanceSearch](t, received)
+	assert.Equal(t, "search", webhook.Data.Type)
+	assert.Equal(t, quikktest.OpeningBalance, webhook.Data.Attributes.UtilityAccountBalance)
+	assert.False(t, webhook.Data.Attributes.CheckedAt.IsZero())
+}
+
+func TestServer_Authentication(t *testing.T) {
+	url, _ := webhooks(t)
+	server := quikktest.NewServer(url)
+	defer server.Close()
+
+	post := func(authorization, date string) *http.Response {
+		body := `{"data":{"id":"1","type":"search","attributes":{"short_code":"174379"}}}`
+		req, err := http.NewRequest(http.MethodPost, server.URL+quikk.EndpointBalance, strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", quikktest.MediaType)
+		req.Header.Set("Date", date)
+		req.Header.Set("Authorization", authorization)
+
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		return res
+	}
+
+	now := time.Now().UTC().Format(time.RFC1123)
+	stale := time.Now().Add(-2 * quikktest.MaxSkew).UTC().Format(time.RFC1123)
+
+	tcs := map[string]struct {
+		authorization string
+		date          string
+		status        int
+	}{
+		"valid signature":   {sign(quikktest.Key, quikktest.Secret, now), now, http.StatusOK},
+		"unknown key":       {sign("other_key", quikktest.Secret, now), now, http.StatusUnauthorized},
+		"wrong secret":      {sign(quikktest.Key, "other_secret", now), now, http.StatusUnauthorized},
+		"stale date":        {sign(quikktest.Key, quikktest.Secret, stale), stale, http.StatusUnauthorized},
+		"unsigned date":     {sign(quikktest.Key, quikktest.Secret, stale), now, http.StatusUnauthorized},
+		"malformed header":  {"Bearer token", now, http.StatusUnauthorized},
+		"missing signature": {"", now, http.StatusUnauthorized},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			res := post(tc.authorization, tc.date)
+			assert.Equal(t, tc.status, res.StatusCode)
+			assert.Equal(t, quikktest.MediaType, res.Header.Get("Content-Type"))
+		})
+	}
+
+	t.Run("test that the client signs requests", func(t *testing.T) {
+		client := quikk.New(quikk.Config{Endpoint: server.URL})
+		client.Hooks.Build.PushBackHook(quikk.Sign(quikktest.Key, "other_secret"))
+
+		_, err := client.Payout(t.Context(), payout, "payout-1")
+		assert.ErrorContains(t, err, "<401> Unauthorized")
+	})
+}
+
+func TestServer_Validation(t *testing.T) {
+	url, _ := webhooks(t)
+	server := quikktest.NewServer(url)
+	defer server.Close()
+	client := server.Client()
+
+	tcs := map[string]func() error{
+		"charge amount": func() error {
+			input := charge
+			input.Amount = 0
+			_, err := client.Charge(t.Context(), input, "1")
+			return err
+		},
+		"charge customer_no": func() error {
+			input := charge
+			input.CustomerNo = "0712345678"
+			_, err := client.Charge(t.Context(), input, "2")
+			return err
+		},
+		"payout short_code": func() error {
+			input := payout
+			input.ShortCode = ""
+			_, err := client.Payout(t.Context(), input, "3")
+			return err
+		},
+		"transfer recipient_category": func() error {
+			_, err := client.Transfer(t.Context(), quikk.RequestTransfer{
+				Amount: 10, RecipientNo: "600000", ShortCode: "174379", RecipientCategory: "bank",
+			}, "4")
+			return err
+		},
+		"transaction search on": func() error {
+			_, err := client.TransactionSearch(t.Context(), quikk.RequestTransactionStatus{
+				ShortCode: "174379", Reference: "NI51HBHO4D", ReferenceType: "receipt",
+			}, "5")
+			return err
+		},
+		"resource id": func() error {
+			_, err := client.Payout(t.Context(), payout, "")
+			return err
+		},
+	}
+
+	for name, fn := range tcs {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorContains(t, fn(), "<400> Bad Request")
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package reconcile matches payment records against M-PESA org portal statements.
+//
+// Statements are read from the csv export of the org portal with ReadStatement, and
+// matched by receipt number to Records, which are built from stored payments, daraja and
+// quikk webhooks, or read from csv files with ReadRecords. Reconcile reports the records
+// missing from the statement, statement entries without a record, and the matched pairs
+// whose amount or status differ. It needs no network access.
+package reconcile
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/SirWaithaka/payments/money"
+)
+
+// Status is the outcome of a payment
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusReversed  Status = "reversed"
+)
+
+// Record is a stored payment to reconcile
+type Record struct {
+	// Receipt is the M-PESA receipt number e.g. the daraja TransactionID or
+	// MpesaReceiptNumber, or the quikk txn_id
+	Receipt string
+	// Reference is the caller's identifier of the payment
+	Reference string
+	Amount    money.Money
+	Status    Status
+	Time      time.Time
+}
+
+// Match is a record and the statement entry with its receipt
+type Match struct {
+	Record Record
+	Entry  Entry
+}
+
+// Report is the result of reconciling records with a statement
+type Report struct {
+	// Matched are the records that agree with their statement entry
+	Matched []Match
+	// Missing are the completed and reversed records that are not in the statement
+	Missing []Record
+	// Extra are the statement entries without a record
+	Extra []Entry
+	// AmountMismatches are the records whose amount differs from their statement entry
+	AmountMismatches []Match
+	// StatusMismatches are the records whose status differs from their statement entry
+	StatusMismatches []Match
+	// Duplicates are the records with the receipt of an earlier record
+	Duplicates []Record
+	// DuplicateEntries are the statement entries with the receipt of an earlier entry,
+	// records are matched to the first entry with their receipt
+	DuplicateEntries []Entry
+}
+
+// OK reports whether all records and entries were matched without differences
+func (r Report) OK() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.AmountMismatches) == 0 &&
+		len(r.StatusMismatches) == 0 && len(r.Duplicates) == 0 && len(r.DuplicateEntries) == 0
+}
+
+// Reconcile matches records to statement entries by receipt. Records should be for the
+// period of the statement, records of other periods are reported as missing.
+//
+// Failed and pending records are not expected in the statement, and are only reported
+// when the statement has an entry for their receipt with a different status. Completed
+// records without a receipt are reported as missing. Records and statement entries with
+// the receipt of an earlier one are reported as duplicates.
+func Reconcile(records []Record, entries []Entry) Report {
+	var report Report
+
+	statement := make(map[string]Entry, len(entries))
+	var unique []Entry
+	for _, entry := range entries {
+		if _, ok := statement[entry.Receipt]; ok {
+			report.DuplicateEntries = append(report.DuplicateEntries, entry)
+			continue
+		}
+		statement[entry.Receipt] = entry
+		unique = append(unique, entry)
+	}
+
+	seen := make(map[string]bool, len(records))
+	for _, record := range records {
+		if record.Receipt != "" && seen[record.Receipt] {
+			report.Duplicates = append(report.Duplicates, record)
+			continue
+		}
+		seen[record.Receipt] = true
+
+		entry, ok := statement[record.Receipt]
+		if !ok || record.Receipt == "" {
+			if record.Status == StatusCompleted || record.Status == StatusReversed {
+				report.Missing = append(report.Missing, record)
+			}
+			continue
+		}
+
+		match := Match{Record: record, Entry: entry}
+		// failed and pending records may not have an amount
+		amountMatches := record.Amount.IsZero() || record.Amount.Equal(entry.Amount())
+		statusMatches := record.Status == entry.State()
+		if !amountMatches {
+			report.AmountMismatches = append(report.AmountMismatches, match)
+		}
+		if !statusMatches {
+			report.StatusMismatches = append(report.StatusMismatches, match)
+		}
+		if amountMatches && statusMatches {
+			report.Matched = append(report.Matched, match)
+		}
+	}
+
+	for _, entry := range unique {
+		if !seen[entry.Receipt] {
+			report.Extra = append(report.Extra, entry)
+		}
+	}
+	return report
+}
+
+// WriteCSV writes the report as csv with a row per record and entry, for sharing with finance
+func WriteCSV(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"result", "receipt", "reference", "record_amount", "statement_amount",
+		"record_status", "statement_status", "statement_line"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	var rows [][]string
+	match := func(result string, matches []Match) {
+		for _, m := range matches {
+			rows = append(rows, []string{result, m.Record.Receipt, m.Record.Reference, m.Record.Amount.Decimal(),
+				m.Entry.Amount().Decimal(), string(m.Record.Status), string(m.Entry.State()), strconv.Itoa(m.Entry.Line)})
+		}
+	}
+	record := func(result string, records []Record) {
+		for _, r := range records {
+			rows = append(rows, []string{result, r.Receipt, r.Reference, r.Amount.Decimal(), "", string(r.Status), "", ""})
+		}
+	}
+	entry := func(result string, entries []Entry) {
+		for _, e := range entries {
+			rows = append(rows, []string{result, e.Receipt, "", "", e.Amount().Decimal(), "", string(e.State()), strconv.Itoa(e.Line)})
+		}
+	}
+
+	match("amount_mismatch", report.AmountMismatches)
+	match("status_mismatch", report.StatusMismatches)
+	record("missing", report.Missing)
+	record("duplicate", report.Duplicates)
+	entry("extra", report.Extra)
+	entry("duplicate_entry", report.DuplicateEntries)
+	match("matched", report.Matched)
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
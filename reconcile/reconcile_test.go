@@ -0,0 +1,148 @@
+package reconcile_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/lifecycle"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/reconcile"
+)
+
+func TestReconcile(t *testing.T) {
+	entries, err := reconcile.ReadStatement(strings.NewReader(statement))
+	assert.NoError(t, err)
+
+	records := []reconcile.Record{
+		// matched
+		{Receipt: "RE51HBHO4D", Reference: "payout-1", Amount: money.New(150000, money.KES), Status: reconcile.StatusCompleted},
+		// reversal not recorded
+		{Receipt: "RE53HBHO4F", Reference: "payout-2", Amount: money.New(20000, money.KES), Status: reconcile.StatusCompleted},
+		// not in the statement
+		{Receipt: "RE60HBHO4X", Reference: "payout-3", Amount: money.New(5000, money.KES), Status: reconcile.StatusCompleted},
+		// failed payments are not expected in the statement
+		{Reference: "payout-4", Amount: money.New(5000, money.KES), Status: reconcile.StatusFailed},
+		{Receipt: "RE51HBHO4D", Reference: "payout-1", Amount: money.New(150000, money.KES), Status: reconcile.StatusCompleted},
+	}
+
+	report := reconcile.Reconcile(records, entries)
+	assert.False(t, report.OK())
+	if assert.Len(t, report.Matched, 1) {
+		assert.Equal(t, "payout-1", report.Matched[0].Record.Reference)
+	}
+	if assert.Len(t, report.StatusMismatches, 1) {
+		assert.Equal(t, reconcile.StatusReversed, report.StatusMismatches[0].Entry.State())
+	}
+	assert.Empty(t, report.AmountMismatches)
+	if assert.Len(t, report.Missing, 1) {
+		assert.Equal(t, "payout-3", report.Missing[0].Reference)
+	}
+	if assert.Len(t, report.Extra, 1) {
+		assert.Equal(t, "RE52HBHO4E", report.Extra[0].Receipt)
+	}
+	assert.Len(t, report.Duplicates, 1)
+
+	var buf bytes.Buffer
+	assert.NoError(t, reconcile.WriteCSV(&buf, report))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 6)
+	assert.Equal(t, "status_mismatch,RE53HBHO4F,payout-2,200.00,200.00,completed,reversed,9", lines[1])
+}
+
+func TestReconcile_AmountMismatch(t *testing.T) {
+	entries, err := reconcile.ReadStatement(strings.NewReader(statement))
+	assert.NoError(t, err)
+
+	records, err := reconcile.ReadRecords(strings.NewReader("reference,mpesa_receipt_number,amount,status\n" +
+		"payout-1,RE51HBHO4D,1500,completed\npayment-1,RE52HBHO4E,100,\npayout-2,RE53HBHO4F,200,reversed\n"))
+	assert.NoError(t, err)
+
+	report := reconcile.Reconcile(records, entries)
+	assert.Len(t, report.Matched, 2)
+	if assert.Len(t, report.AmountMismatches, 1) {
+		assert.Equal(t, "payment-1", report.AmountMismatches[0].Record.Reference)
+		assert.Equal(t, money.New(100000, money.KES), report.AmountMismatches[0].Entry.Amount())
+	}
+	assert.Empty(t, report.Extra)
+
+	_, err = reconcile.ReadRecords(strings.NewReader("reference,amount\npayout-1,1500\n"))
+	assert.ErrorIs(t, err, reconcile.ErrMissingColumn)
+	_, err = reconcile.ReadRecords(strings.NewReader("txn_id,amount,status\nRE51HBHO4D,1500,paid\n"))
+	assert.ErrorIs(t, err, reconcile.ErrInvalidRow)
+}
+
+func TestReconcile_DuplicateEntries(t *testing.T) {
+	entries, err := reconcile.ReadStatement(strings.NewReader(statement))
+	assert.NoError(t, err)
+
+	// the statement has a second line with the receipt of a payment
+	duplicate := entries[0]
+	duplicate.Line = 20
+	entries = append(entries, duplicate)
+
+	records := []reconcile.Record{
+		{Receipt: duplicate.Receipt, Reference: "payout-1", Amount: duplicate.Amount(), Status: duplicate.State()},
+	}
+	report := reconcile.Reconcile(records, entries)
+	assert.False(t, report.OK())
+	if assert.Len(t, report.Matched, 1) {
+		assert.Equal(t, entries[0].Line, report.Matched[0].Entry.Line)
+	}
+	if assert.Len(t, report.DuplicateEntries, 1) {
+		assert.Equal(t, 20, report.DuplicateEntries[0].Line)
+	}
+	for _, entry := range report.Extra {
+		assert.NotEqual(t, duplicate.Receipt, entry.Receipt)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, reconcile.WriteCSV(&buf, report))
+	assert.Contains(t, buf.String(), "duplicate_entry,"+duplicate.Receipt+",")
+}
+
+func TestRecords(t *testing.T) {
+	var b2c daraja.WebhookRequestB2C
+	err := jsoniter.Unmarshal([]byte(`{"Result":{"ResultType":0,"ResultCode":0,"ResultDesc":"The service request is processed successfully.",
+"OriginatorConversationID":"10571-7910404-1","ConversationID":"AG_20191219_00004e48cf7e3533f581","TransactionID":"RE51HBHO4D",
+"ResultParameters":{"ResultParameter":[{"Key":"TransactionAmount","Value":1500}]}}}`), &b2c)
+	assert.NoError(t, err)
+
+	record, err := reconcile.FromDarajaB2C(b2c)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Record{Receipt: "RE51HBHO4D", Amount: money.New(150000, money.KES), Status: reconcile.StatusCompleted}, record)
+
+	b2c.Result.ResultCode = daraja.ResultCodeInsufficientBalance
+	b2c.Result.ResultParameters = nil
+	record, err = reconcile.FromDarajaB2C(b2c)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.StatusFailed, record.Status)
+
+	var stk daraja.WebhookRequestC2BExpress
+	err = jsoniter.Unmarshal([]byte(`{"Body":{"stkCallback":{"MerchantRequestID":"29115-34620561-1","CheckoutRequestID":"ws_CO_191220191020363925",
+"ResultCode":0,"ResultDesc":"The service request is processed successfully.","CallbackMetadata":{"Item":[{"Name":"Amount","Value":1.00},
+{"Name":"MpesaReceiptNumber","Value":"NLJ7RT61SV"},{"Name":"PhoneNumber","Value":254708374149}]}}}}`), &stk)
+	assert.NoError(t, err)
+	record, err = reconcile.FromDarajaC2BExpress(stk)
+	assert.NoError(t, err)
+	assert.Equal(t, "NLJ7RT61SV", record.Receipt)
+	assert.Equal(t, money.New(100, money.KES), record.Amount)
+
+	var payout quikk.WebhookResult[quikk.WebhookAttributesPayout]
+	err = jsoniter.Unmarshal([]byte(`{"data":{"type":"payout","id":"1","attributes":{"txn_id":"NI51HBHO4D",
+"response_id":"AG_20190905_00005f0dcb86732c611c","amount":10,"txn_created_at":"2022-07-01T10:51:59+0300"}}}`), &payout)
+	assert.NoError(t, err)
+	record, err = reconcile.FromQuikkPayout(payout)
+	assert.NoError(t, err)
+	assert.Equal(t, "NI51HBHO4D", record.Receipt)
+	assert.Equal(t, money.New(1000, money.KES), record.Amount)
+	assert.Equal(t, 2022, record.Time.Year())
+
+	record = reconcile.FromPayment(lifecycle.Payment{ID: "payout-1", Receipt: "RE51HBHO4D", State: lifecycle.StateAccepted})
+	assert.Equal(t, reconcile.StatusPending, record.Status)
+}
@@ -0,0 +1,204 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/internal/csvx"
+	"github.com/SirWaithaka/payments/lifecycle"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/quikk"
+)
+
+// darajaStatus returns the Status of a daraja result code
+func darajaStatus(code daraja.ResultCode) Status {
+	if code == daraja.ResultCodeSuccess {
+		return StatusCompleted
+	}
+	return StatusFailed
+}
+
+// darajaRecord returns the record of a daraja result. The amount is only read from
+// successful results, failed results do not include it.
+func darajaRecord(receipt string, code daraja.ResultCode, amount func() (money.Money, error)) (Record, error) {
+	record := Record{Receipt: receipt, Status: darajaStatus(code), Amount: money.New(0, money.KES)}
+	if record.Status != StatusCompleted {
+		return record, nil
+	}
+
+	var err error
+	if record.Amount, err = amount(); err != nil {
+		return Record{}, fmt.Errorf("receipt %s: %w", receipt, err)
+	}
+	return record, nil
+}
+
+// FromDarajaB2C returns the record of a b2c or b2pochi result, with its TransactionID as
+// the receipt
+func FromDarajaB2C(w daraja.WebhookRequestB2C) (Record, error) {
+	return darajaRecord(w.Result.TransactionID, w.Result.ResultCode, w.Money)
+}
+
+// FromDarajaB2B returns the record of a b2b, b2c account top-up or tax remittance result,
+// with its TransactionID as the receipt
+func FromDarajaB2B(w daraja.WebhookRequestB2B) (Record, error) {
+	return darajaRecord(w.Result.TransactionID, w.Result.ResultCode, w.Money)
+}
+
+// FromDarajaB2BExpressCheckout returns the record of a b2b express checkout callback, with
+// its transactionId as the receipt
+func FromDarajaB2BExpressCheckout(w daraja.WebhookRequestB2BExpressCheckout) (Record, error) {
+	record, err := FromDarajaB2B(w.B2B())
+	record.Reference = w.RequestID
+	return record, err
+}
+
+// FromDarajaC2BExpress returns the record of a stk push callback, with the
+// MpesaReceiptNumber metadata as the receipt
+func FromDarajaC2BExpress(w daraja.WebhookRequestC2BExpress) (Record, error) {
+	callback := w.Body.StkCallback
+
+	var receipt string
+	if callback.CallbackMetadata != nil {
+		for _, item := range callback.CallbackMetadata.Item {
+			if item.Name == "MpesaReceiptNumber" && item.Value != nil {
+				receipt = fmt.Sprint(item.Value)
+			}
+		}
+	}
+	return darajaRecord(receipt, callback.ResultCode, w.Money)
+}
+
+// FromDarajaC2B returns the record of a c2b confirmation, with its TransID as the receipt
+func FromDarajaC2B(w daraja.WebhookRequestDirectC2B) (Record, error) {
+	amount, err := w.Money()
+	if err != nil {
+		return Record{}, fmt.Errorf("receipt %s: %w", w.TransID, err)
+	}
+
+	record := Record{Receipt: w.TransID, Reference: w.BillRefNumber, Amount: amount, Status: StatusCompleted}
+	// TransTime is in the format YYYYMMDDHHMMSS
+	if t, err := time.ParseInLocation("20060102150405", w.TransTime, eat); err == nil {
+		record.Time = t
+	}
+	return record, nil
+}
+
+// quikkRecord returns the record of a quikk webhook with its txn_id as the receipt
+func quikkRecord[T any](w quikk.WebhookResult[T], receipt string, amount float64, created string) (Record, error) {
+	record := Record{Receipt: receipt, Reference: w.Data.ID, Status: StatusCompleted, Amount: money.New(0, money.KES)}
+	if w.Meta != nil && w.Meta.Status == "FAIL" {
+		record.Status = StatusFailed
+		return record, nil
+	}
+
+	var err error
+	if record.Amount, err = money.FromFloat(amount, money.KES); err != nil {
+		return Record{}, fmt.Errorf("receipt %s: %w", receipt, err)
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05-0700", created); err == nil {
+		record.Time = t
+	}
+	return record, nil
+}
+
+// FromQuikkCharge returns the record of a charge webhook
+func FromQuikkCharge(w quikk.WebhookResult[quikk.WebhookAttributesCharge]) (Record, error) {
+	a := w.Data.Attributes
+	return quikkRecord(w, a.TxnID, a.Amount, a.TxnChargeCreatedAt)
+}
+
+// FromQuikkPayout returns the record of a payout webhook
+func FromQuikkPayout(w quikk.WebhookResult[quikk.WebhookAttributesPayout]) (Record, error) {
+	a := w.Data.Attributes
+	return quikkRecord(w, a.TxnID, a.Amount, a.TxnCreatedAt)
+}
+
+// FromQuikkTransfer returns the record of a transfer webhook
+func FromQuikkTransfer(w quikk.WebhookResult[quikk.WebhookAttributesTransfer]) (Record, error) {
+	a := w.Data.Attributes
+	return quikkRecord(w, a.TxnID, a.Amount, a.TxnCreatedAt)
+}
+
+// FromQuikkPayin returns the record of a payin confirmation webhook
+func FromQuikkPayin(w quikk.WebhookResult[quikk.WebhookAttributesPayinConfirmation]) (Record, error) {
+	a := w.Data.Attributes
+	record, err := quikkRecord(w, a.TxnID, a.Amount, a.TxnCreatedAt)
+	if a.Reference != "" {
+		record.Reference = a.Reference
+	}
+	return record, err
+}
+
+// FromPayment returns the record of a payment tracked with the lifecycle package.
+// Payments without a result are pending.
+func FromPayment(p lifecycle.Payment) Record {
+	record := Record{Receipt: p.Receipt, Reference: p.ID, Amount: p.Amount, Status: StatusPending, Time: p.CreatedAt}
+	switch p.State {
+	case lifecycle.StateCompleted:
+		record.Status = StatusCompleted
+	case lifecycle.StateFailed:
+		record.Status = StatusFailed
+	case lifecycle.StateReversed:
+		record.Status = StatusReversed
+	}
+	return record
+}
+
+// csv header names accepted for each Record field
+var columns = csvx.Columns{
+	"receipt":   {"receipt", "receipt_no", "transaction_id", "transid", "trans_id", "mpesa_receipt_number", "txn_id"},
+	"amount":    {"amount"},
+	"status":    {"status"},
+	"reference": {"reference", "id"},
+}
+
+// ReadRecords reads records from csv with a header line. The receipt column can be named
+// receipt, transaction_id, trans_id, mpesa_receipt_number or txn_id. The amount column
+// is required, the status column defaults to completed, and the reference column is optional.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+
+	index := columns.Index(header)
+	if field := index.Missing("receipt", "amount"); field != "" {
+		return nil, fmt.Errorf("%w: %s", ErrMissingColumn, field)
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		value := func(field string) string { return index.Value(row, field) }
+
+		record := Record{Receipt: value("receipt"), Reference: value("reference"), Status: Status(strings.ToLower(value("status")))}
+		switch record.Status {
+		case "":
+			record.Status = StatusCompleted
+		case StatusPending, StatusCompleted, StatusFailed, StatusReversed:
+		default:
+			return nil, fmt.Errorf("%w: line %d: invalid status %q", ErrInvalidRow, line, record.Status)
+		}
+		if record.Amount, err = parseAmount(value("amount")); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidRow, line, err)
+		}
+		records = append(records, record)
+	}
+}
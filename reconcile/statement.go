@@ -0,0 +1,272 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SirWaithaka/payments/money"
+)
+
+var (
+	ErrMissingColumn = errors.New("missing column")
+	ErrInvalidRow    = errors.New("invalid row")
+)
+
+// eat is the timezone of statement times
+var eat = time.FixedZone("EAT", 3*60*60)
+
+// layouts of the completion and initiation times in statement exports
+var layouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"02-01-2006 15:04:05",
+	"02-01-2006 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+}
+
+// Entry is a transaction in an M-PESA org portal statement. Charge rows, which have the
+// receipt of the transaction they were charged for, are added to Charges of that entry.
+type Entry struct {
+	// Line is the line of the statement row, numbered from 1
+	Line           int
+	Receipt        string
+	CompletionTime time.Time
+	InitiationTime time.Time
+	Details        string
+	// Status is the "Transaction Status" column e.g. "Completed"
+	Status    string
+	PaidIn    money.Money
+	Withdrawn money.Money
+	Balance   money.Money
+	// ReasonType describes the kind of transaction e.g. "Business Payment to Customer via API"
+	ReasonType          string
+	OtherParty          string
+	LinkedTransactionID string
+	AccountNumber       string
+	// Charges are the transaction charges withdrawn for the entry
+	Charges money.Money
+	// ReversedBy is the receipt of the reversal of the entry, if it was reversed
+	ReversedBy string
+}
+
+// Amount returns the amount paid in or withdrawn, as a positive amount
+func (e Entry) Amount() money.Money {
+	if !e.PaidIn.IsZero() {
+		return e.PaidIn
+	}
+	return e.Withdrawn
+}
+
+// State returns the Status of the entry to compare with a Record
+func (e Entry) State() Status {
+	switch {
+	case e.ReversedBy != "":
+		return StatusReversed
+	case strings.EqualFold(e.Status, "Completed"):
+		return StatusCompleted
+	case strings.EqualFold(e.Status, "Failed"), strings.EqualFold(e.Status, "Declined"),
+		strings.EqualFold(e.Status, "Cancelled"), strings.EqualFold(e.Status, "Expired"):
+		return StatusFailed
+	default:
+		return StatusPending
+	}
+}
+
+func (e Entry) isCharge() bool {
+	return strings.Contains(strings.ToLower(e.ReasonType), "charge") ||
+		strings.Contains(strings.ToLower(e.Details), "charge")
+}
+
+func (e Entry) isReversal() bool {
+	return e.LinkedTransactionID != "" &&
+		(strings.Contains(strings.ToLower(e.ReasonType), "reversal") ||
+			strings.Contains(strings.ToLower(e.Details), "reversal"))
+}
+
+// statement header names of the Entry fields
+const (
+	columnReceipt        = "receipt no."
+	columnCompletionTime = "completion time"
+	columnInitiationTime = "initiation time"
+	columnDetails        = "details"
+	columnStatus         = "transaction status"
+	columnPaidIn         = "paid in"
+	columnWithdrawn      = "withdrawn"
+	columnBalance        = "balance"
+	columnReasonType     = "reason type"
+	columnOtherParty     = "other party info"
+	columnLinkedID       = "linked transaction id"
+	columnAccountNumber  = "a/c no."
+)
+
+// ReadStatement reads the entries of an M-PESA org portal statement exported as csv.
+// The account details lines before the header row are skipped. Charge rows are added to
+// the entry they were charged for, and reversals mark the entry they reverse with
+// ReversedBy. Reversals of transactions that are not in the statement are returned as
+// entries of their own.
+func ReadStatement(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// the header is the first row with a receipt column
+	index := make(map[string]int)
+	for len(index) == 0 {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, columnReceipt)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.ContainsFunc(record, func(name string) bool { return strings.ToLower(strings.TrimSpace(name)) == columnReceipt }) {
+			continue
+		}
+		for i, name := range record {
+			index[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+	}
+	for _, column := range []string{columnCompletionTime, columnStatus, columnPaidIn, columnWithdrawn} {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, column)
+		}
+	}
+
+	var rows []Entry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		value := func(column string) string {
+			i, ok := index[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		if value(columnReceipt) == "" {
+			// blank and summary rows
+			continue
+		}
+
+		entry, err := parseEntry(line, value)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, entry)
+	}
+	return group(rows), nil
+}
+
+func parseEntry(line int, value func(string) string) (Entry, error) {
+	entry := Entry{
+		Line:                line,
+		Receipt:             value(columnReceipt),
+		Details:             value(columnDetails),
+		Status:              value(columnStatus),
+		ReasonType:          value(columnReasonType),
+		OtherParty:          value(columnOtherParty),
+		LinkedTransactionID: value(columnLinkedID),
+		AccountNumber:       value(columnAccountNumber),
+		Charges:             money.New(0, money.KES),
+	}
+
+	var err error
+	if entry.CompletionTime, err = parseTime(value(columnCompletionTime)); err != nil {
+		return Entry{}, fmt.Errorf("%w: line %d: %w", ErrInvalidRow, line, err)
+	}
+	if entry.InitiationTime, err = parseTime(value(columnInitiationTime)); err != nil {
+		return Entry{}, fmt.Errorf("%w: line %d: %w", ErrInvalidRow, line, err)
+	}
+	for column, m := range map[string]*money.Money{columnPaidIn: &entry.PaidIn, columnWithdrawn: &entry.Withdrawn, columnBalance: &entry.Balance} {
+		if *m, err = parseAmount(value(column)); err != nil {
+			return Entry{}, fmt.Errorf("%w: line %d: %s: %w", ErrInvalidRow, line, column, err)
+		}
+	}
+	// withdrawals are exported as negative amounts
+	if entry.Withdrawn.IsNegative() {
+		entry.Withdrawn = money.New(-entry.Withdrawn.Cents(), money.KES)
+	}
+	return entry, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, eat); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// parseAmount parses amounts with thousands separators e.g. "1,500.00"
+func parseAmount(s string) (money.Money, error) {
+	s = strings.ReplaceAll(s, ",", "")
+	if s == "" {
+		return money.New(0, money.KES), nil
+	}
+	return money.Parse(s, money.KES)
+}
+
+// group adds charge rows to the entry of their receipt, and links reversals
+func group(rows []Entry) []Entry {
+	receipts := make(map[string]int)
+	var entries []Entry
+	for _, row := range rows {
+		if !row.isCharge() {
+			receipts[row.Receipt] = len(entries)
+			entries = append(entries, row)
+		}
+	}
+
+	for _, row := range rows {
+		if !row.isCharge() {
+			continue
+		}
+		i, ok := receipts[row.Receipt]
+		if !ok {
+			// a charge without its transaction in the statement
+			entries = append(entries, row)
+			continue
+		}
+		if charges, err := entries[i].Charges.Add(row.Withdrawn); err == nil {
+			entries[i].Charges = charges
+		}
+	}
+
+	// reversals are removed from the entries when the reversed entry is in the statement
+	linked := make(map[int]bool)
+	for i, entry := range entries {
+		if !entry.isReversal() {
+			continue
+		}
+		if original, ok := receipts[entry.LinkedTransactionID]; ok {
+			entries[original].ReversedBy = entry.Receipt
+			linked[i] = true
+		}
+	}
+
+	result := make([]Entry, 0, len(entries)-len(linked))
+	for i, entry := range entries {
+		if !linked[i] {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
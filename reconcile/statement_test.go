@@ -0,0 +1,68 @@
+package reconcile_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/reconcile"
+)
+
+// statement is an org portal export with account details before the header
+const statement = `Account Holder:,600000 - Safaricom Test
+Short Code:,600000
+Time Period:,From 01-05-2023 To 31-05-2023
+
+Receipt No.,Completion Time,Initiation Time,Details,Transaction Status,Paid In,Withdrawn,Balance,Balance Confirmed,Reason Type,Other Party Info,Linked Transaction ID,A/C No.
+RE51HBHO4D,2023-05-02 10:15:30,2023-05-02 10:15:29,Business Payment to 2547****0024 - John Doe,Completed,,"-1,500.00","98,491.00",true,Business Payment to Customer via API,2547****0024 - John Doe,,
+RE51HBHO4D,2023-05-02 10:15:30,2023-05-02 10:15:29,Business Payment Charge,Completed,,-9.00,"98,482.00",true,Business Payment Charge,,,
+RE52HBHO4E,2023-05-03 08:00:00,2023-05-03 08:00:00,Pay Bill from 2547****0567 - Jane Doe Acc. INV-1,Completed,"1,000.00",,"99,482.00",true,Pay Bill Online,2547****0567 - Jane Doe,,INV-1
+RE53HBHO4F,2023-05-04 09:00:00,2023-05-04 09:00:00,Business Payment to 2547****0024 - John Doe,Completed,,-200.00,"99,282.00",true,Business Payment to Customer via API,2547****0024 - John Doe,,
+RE54HBHO4G,2023-05-04 09:30:00,2023-05-04 09:30:00,Reversal of RE53HBHO4F,Completed,200.00,,"99,482.00",true,Reversal,,RE53HBHO4F,
+`
+
+func TestReadStatement(t *testing.T) {
+	entries, err := reconcile.ReadStatement(strings.NewReader(statement))
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+
+	payment := entries[0]
+	assert.Equal(t, "RE51HBHO4D", payment.Receipt)
+	assert.Equal(t, money.New(150000, money.KES), payment.Amount())
+	assert.Equal(t, money.New(900, money.KES), payment.Charges)
+	assert.Equal(t, money.New(9849100, money.KES), payment.Balance)
+	assert.Equal(t, reconcile.StatusCompleted, payment.State())
+	assert.Equal(t, time.Date(2023, 5, 2, 7, 15, 30, 0, time.UTC), payment.CompletionTime.UTC())
+	assert.Equal(t, 6, payment.Line)
+
+	paybill := entries[1]
+	assert.Equal(t, money.New(100000, money.KES), paybill.Amount())
+	assert.Equal(t, "INV-1", paybill.AccountNumber)
+
+	// the reversal is linked to the payment it reverses
+	reversed := entries[2]
+	assert.Equal(t, "RE53HBHO4F", reversed.Receipt)
+	assert.Equal(t, "RE54HBHO4G", reversed.ReversedBy)
+	assert.Equal(t, reconcile.StatusReversed, reversed.State())
+}
+
+func TestReadStatement_Errors(t *testing.T) {
+	_, err := reconcile.ReadStatement(strings.NewReader("Short Code:,600000\n"))
+	assert.ErrorIs(t, err, reconcile.ErrMissingColumn)
+
+	_, err = reconcile.ReadStatement(strings.NewReader("Receipt No.,Completion Time,Transaction Status,Paid In\n"))
+	assert.ErrorIs(t, err, reconcile.ErrMissingColumn)
+
+	_, err = reconcile.ReadStatement(strings.NewReader("Receipt No.,Completion Time,Transaction Status,Paid In,Withdrawn\n" +
+		"RE51HBHO4D,yesterday,Completed,100.00,\n"))
+	assert.ErrorIs(t, err, reconcile.ErrInvalidRow)
+
+	_, err = reconcile.ReadStatement(strings.NewReader("Receipt No.,Completion Time,Transaction Status,Paid In,Withdrawn\n" +
+		"RE51HBHO4D,2023-05-02 10:15:30,Completed,1.000,\n"))
+	assert.ErrorIs(t, err, reconcile.ErrInvalidRow)
+}
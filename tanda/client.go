@@ -1,7 +1,9 @@
 package tanda
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -46,6 +48,9 @@ type Client struct {
 	Hooks    gorequest.Hooks
 }
 
+// AuthenticationRequestFunc returns a request for an access token, see Client.AuthenticationRequest
+type AuthenticationRequestFunc func() (*gorequest.Request, *ResponseAuthentication)
+
 func (client Client) AuthenticationRequest(clientID, secret string) (*gorequest.Request, *ResponseAuthentication) {
 	op := gorequest.Operation{
 		Name:   OperationAuthenticate,
@@ -65,12 +70,19 @@ func (client Client) AuthenticationRequest(clientID, secret string) (*gorequest.
 	// default hooks
 	hooks := corehooks.Default()
 	hooks.Build.PushFront(gorequest.WithRequestHeader("Content-Type", "application/x-www-form-urlencoded"))
-	hooks.Send.PushFrontHook(corehooks.LogHTTPRequest)
+	// the request carries the client credentials, so it is not logged
+	hooks.Build.RemoveHook(corehooks.LogHTTPRequest)
 	hooks.Unmarshal.PushBackHook(ResponseDecoder)
 
-	input := strings.NewReader(data.Encode())
+	// the form data is the body of the request, corehooks.EncodeRequestBody only encodes json
+	form := data.Encode()
+	hooks.Build.PushBackHook(gorequest.Hook{Name: "tanda.EncodeFormBody", Fn: func(r *gorequest.Request) {
+		r.Request.Body = io.NopCloser(strings.NewReader(form))
+		r.Request.ContentLength = int64(len(form))
+	}})
+
 	output := &ResponseAuthentication{}
-	req := gorequest.New(cfg, op, hooks, nil, input, output)
+	req := gorequest.New(cfg, op, hooks, nil, nil, output)
 
 	return req, output
 }
@@ -92,6 +104,17 @@ func (client Client) PaymentRequest(orgID string, payload RequestPayment, opts .
 
 }
 
+func (client Client) Payment(ctx context.Context, orgID string, payload RequestPayment) (ResponsePayment, error) {
+	req, out := client.PaymentRequest(orgID, payload)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponsePayment{}, err
+	}
+
+	return *out, nil
+}
+
 func (client Client) TransactionStatusRequest(orgID, trackingID, shortCode string, opts ...gorequest.Option) (*gorequest.Request, *ResponseTransactionStatus) {
 	op := gorequest.Operation{
 		Name:   OperationTransactionStatus,
@@ -112,3 +135,14 @@ func (client Client) TransactionStatusRequest(orgID, trackingID, shortCode strin
 
 	return req, output
 }
+
+func (client Client) TransactionStatus(ctx context.Context, orgID, trackingID, shortCode string) (ResponseTransactionStatus, error) {
+	req, out := client.TransactionStatusRequest(orgID, trackingID, shortCode)
+	req.WithContext(ctx)
+
+	if err := req.Send(); err != nil {
+		return ResponseTransactionStatus{}, err
+	}
+
+	return *out, nil
+}
@@ -0,0 +1,65 @@
+package tanda_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+func TestClient_AuthenticationRequest(t *testing.T) {
+	// create a mock test server that accepts the client credentials as a form
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+tanda.EndpointAuthentication, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" ||
+			r.PostFormValue("grant_type") != "client_credentials" ||
+			r.PostFormValue("client_id") != "client_id" ||
+			r.PostFormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":"E400000","category":"Business","severity":"Low","error":"Bad Request","description":"invalid client credentials."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3599}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := tanda.New(tanda.Config{Endpoint: server.URL})
+	req, out := client.AuthenticationRequest("client_id", "secret")
+
+	assert.NoError(t, req.Send())
+	assert.Equal(t, "token", out.AccessToken)
+	assert.Equal(t, uint(3599), out.ExpiresIn)
+
+	t.Run("test that the request is not logged", func(t *testing.T) {
+		var logs []any
+		req, _ := client.AuthenticationRequest("client_id", "secret")
+		req.Config.LogLevel = gorequest.LogDebugWithHTTPBody
+		req.Config.Logger = gorequest.LoggerFunc(func(args ...any) { logs = append(logs, args...) })
+
+		assert.NoError(t, req.Send())
+		assert.Empty(t, logs)
+	})
+}
+
+func TestClient_Payment(t *testing.T) {
+	// create a mock test server that rejects the request
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /io/v3/organizations/{org}/request", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"status":"E401000","category":"Business","severity":"Low","error":"Unauthorized","description":"invalid token."}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := tanda.New(tanda.Config{Endpoint: server.URL})
+	payment := tanda.RequestPayment{CommandID: tanda.CommandMerchantToCustomerMobileMoneyPayment, Reference: "REF00000001"}
+	_, err := client.Payment(t.Context(), "org", payment)
+
+	assert.EqualError(t, err, "<E401000> Unauthorized: invalid token.")
+}
@@ -4,11 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"iter"
-	"slices"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/internal/exchange"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/phone"
 )
 
 // getRequiredParametersForCommand returns the required parameter IDs for each command
@@ -130,8 +138,152 @@ func parameterIDs(params []PaymentRequestParameter) iter.Seq[ParameterID] {
 	}
 }
 
-// PaymentParametersValidator is a build hook that checks that all required parameters
-// in RequestPayment.Request are present for a given command id.
+var (
+	ErrMissingParameter   = errors.New("missing required parameter")
+	ErrUnknownParameter   = errors.New("unknown parameter")
+	ErrDuplicateParameter = errors.New("duplicate parameter")
+	ErrInvalidReference   = errors.New("invalid reference: must be 8-16 alphanumeric characters")
+)
+
+// knownParameters is the set of all parameter ids accepted by tanda
+var knownParameters = map[ParameterID]struct{}{
+	ParameterIDAmount: {}, ParameterIDShortCode: {}, ParameterIDAccountNumber: {}, ParameterIDNarration: {},
+	ParameterIDIpnUrl: {}, ParameterIDAccountName: {}, ParameterIDBankCode: {}, ParameterIDPartyA: {},
+	ParameterIDPartyB: {}, ParameterIDBusinessNumber: {}, ParameterIDAccountReference: {}, ParameterIDCurrency: {},
+	ParameterIDMobileNumber: {}, ParameterIDSenderType: {}, ParameterIDBeneficiaryType: {},
+	ParameterIDBeneficiaryAddress: {}, ParameterIDBeneficiaryActivity: {}, ParameterIDBeneficiaryCountry: {},
+	ParameterIDBeneficiaryEmailAddress: {}, ParameterIDDocumentType: {}, ParameterIDDocumentNumber: {},
+	ParameterIDSenderName: {}, ParameterIDSenderAddress: {}, ParameterIDSenderPhoneNumber: {},
+	ParameterIDSenderDocumentType: {}, ParameterIDSenderDocumentNumber: {}, ParameterIDSenderCountry: {},
+	ParameterIDSenderCurrency: {}, ParameterIDSenderSourceOfFunds: {}, ParameterIDSenderPrincipalActivity: {},
+	ParameterIDSenderBankCode: {}, ParameterIDSenderEmailAddress: {}, ParameterIDSenderPrimaryAccountNumber: {},
+	ParameterIDSenderDateOfBirth: {}, ParameterIDSenderCompanyName: {},
+}
+
+// ParameterError aggregates all the validation errors found for a single parameter
+// in RequestPayment.Request
+type ParameterError struct {
+	ID     ParameterID
+	Errors []error
+}
+
+func (e *ParameterError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%s: %s", e.ID, strings.Join(msgs, ", "))
+}
+
+func (e *ParameterError) Unwrap() []error {
+	return e.Errors
+}
+
+// parameterErrors collects errors per parameter id, preserving the order in
+// which each parameter first failed validation
+type parameterErrors struct {
+	order  []ParameterID
+	errors map[ParameterID]*ParameterError
+}
+
+func (pe *parameterErrors) add(id ParameterID, err error) {
+	if pe.errors == nil {
+		pe.errors = make(map[ParameterID]*ParameterError)
+	}
+
+	if e, ok := pe.errors[id]; ok {
+		e.Errors = append(e.Errors, err)
+		return
+	}
+	pe.order = append(pe.order, id)
+	pe.errors[id] = &ParameterError{ID: id, Errors: []error{err}}
+}
+
+func (pe *parameterErrors) list() []error {
+	errs := make([]error, 0, len(pe.order))
+	for _, id := range pe.order {
+		errs = append(errs, pe.errors[id])
+	}
+	return errs
+}
+
+var (
+	reReference = regexp.MustCompile(`^[a-zA-Z0-9]{8,16}$`)
+	// reAmount matches plain decimal numbers, exponents and hex floats are not sent to tanda
+	reAmount = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+)
+
+func validateAmount(value string) error {
+	if !reAmount.MatchString(value) {
+		return errors.New("must be numeric")
+	}
+	amount, err := money.Parse(value, money.KES)
+	if err != nil {
+		return errors.New("must have at most 2 decimal places")
+	}
+	if amount.IsZero() || amount.IsNegative() {
+		return errors.New("must be positive")
+	}
+	return nil
+}
+
+func validateMSISDN(value string) error {
+	// tanda only accepts numbers in the msisdn format
+	if n, err := phone.Parse(value); err != nil || n.For(phone.ProviderTanda) != value {
+		return errors.New("must be a valid msisdn in the format 254XXXXXXXXX")
+	}
+	return nil
+}
+
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || !u.IsAbs() || u.Scheme != "https" || u.Host == "" {
+		return errors.New("must be an absolute https url")
+	}
+	return nil
+}
+
+func validateCurrency(value string) error {
+	if _, ok := currencyCodes[value]; !ok {
+		return errors.New("must be an ISO 4217 currency code")
+	}
+	return nil
+}
+
+func validateCountry(value string) error {
+	if _, ok := countryCodes[value]; !ok {
+		return errors.New("must be an ISO 3166-1 alpha-2 country code")
+	}
+	return nil
+}
+
+// getParameterValidator returns the value validator of a parameter for a given command,
+// or nil if the parameter value is not validated
+func getParameterValidator(commandID Command, id ParameterID) func(string) error {
+	switch id {
+	case ParameterIDAmount:
+		return validateAmount
+	case ParameterIDIpnUrl:
+		return validateURL
+	case ParameterIDCurrency, ParameterIDSenderCurrency:
+		return validateCurrency
+	case ParameterIDBeneficiaryCountry, ParameterIDSenderCountry:
+		return validateCountry
+	case ParameterIDAccountNumber:
+		// the account number is a phone number only for mobile money commands
+		if commandID == CommandCustomerToMerchantMobileMoneyPayment || commandID == CommandMerchantToCustomerMobileMoneyPayment {
+			return validateMSISDN
+		}
+	}
+	return nil
+}
+
+// PaymentParametersValidator is a build hook that validates RequestPayment for a given command id.
+// It checks that the reference is 8-16 alphanumeric characters, that all required parameters
+// in RequestPayment.Request are present, that no parameter is unknown or repeated, and that
+// parameter values are well-formed.
+//
+// Parameter errors are aggregated per parameter as ParameterError and joined into a single error.
 var PaymentParametersValidator = gorequest.Hook{
 	Name: "tanda.PaymentParametersValidator",
 	Fn: func(r *gorequest.Request) {
@@ -143,24 +295,58 @@ var PaymentParametersValidator = gorequest.Hook{
 		}
 
 		params := payload.Request
-		if params == nil || len(params) == 0 || payload.CommandID == "" {
+		if len(params) == 0 || payload.CommandID == "" {
 			r.Error = errors.New("invalid payload")
 			return
 		}
 
 		requiredParams := getRequiredParametersForCommand(payload.CommandID)
-		if requiredParams == nil || len(requiredParams) == 0 {
+		if len(requiredParams) == 0 {
 			r.Error = fmt.Errorf("invalid command id: %s", payload.CommandID)
 			return
 		}
 
+		var errs []error
+		if !reReference.MatchString(payload.Reference) {
+			errs = append(errs, ErrInvalidReference)
+		}
+
+		// count occurrences of each parameter id in the payload
+		counts := make(map[ParameterID]int, len(params))
+		for id := range parameterIDs(params) {
+			counts[id]++
+		}
+
+		var paramErrs parameterErrors
 		// check that all required parameters are present in the payload parameters
-		for _, param := range requiredParams {
-			if !slices.Contains(slices.Collect(parameterIDs(params)), param) {
-				r.Error = fmt.Errorf("missing required parameter: %s", param)
-				return
+		for _, id := range requiredParams {
+			if counts[id] == 0 {
+				paramErrs.add(id, ErrMissingParameter)
+			}
+		}
+
+		seen := make(map[ParameterID]struct{}, len(params))
+		for _, param := range params {
+			// unknown and duplicate parameters are reported once per parameter id
+			if _, ok := seen[param.ID]; !ok {
+				seen[param.ID] = struct{}{}
+				if _, ok := knownParameters[param.ID]; !ok {
+					paramErrs.add(param.ID, ErrUnknownParameter)
+				} else if counts[param.ID] > 1 {
+					paramErrs.add(param.ID, ErrDuplicateParameter)
+				}
+			}
+
+			if validate := getParameterValidator(payload.CommandID, param.ID); validate != nil {
+				if err := validate(param.Value); err != nil {
+					paramErrs.add(param.ID, err)
+				}
 			}
 		}
+
+		if errs = append(errs, paramErrs.list()...); len(errs) > 0 {
+			r.Error = errors.Join(errs...)
+		}
 	},
 }
 
@@ -172,6 +358,10 @@ func (r errResponse) Error() string {
 	return fmt.Sprintf("<%s> %s: %s", r.Status, r.ErrorResponse.Error, r.Description)
 }
 
+func (r errResponse) ResultCode() string {
+	return r.Status
+}
+
 // ResponseDecoder parse the http.Response body into the property
 // gorequest.Request.Data, if the status code is successful
 // Otherwise for failed requests, it will parse the error response
@@ -182,8 +372,8 @@ var ResponseDecoder = gorequest.Hook{
 		// response formats for non-2xx status codes follow the same format
 		if r.Response.StatusCode < 200 || r.Response.StatusCode >= 300 {
 			response := &errResponse{}
-			if err := jsoniter.NewDecoder(r.Response.Body).Decode(response.ErrorResponse); err != nil {
-				r.Error = err
+			if err := jsoniter.NewDecoder(r.Response.Body).Decode(&response.ErrorResponse); err != nil {
+				r.Error = &exchange.DecodeError{StatusCode: r.Response.StatusCode, Err: err}
 				return
 			}
 			r.Error = response
@@ -195,3 +385,39 @@ var ResponseDecoder = gorequest.Hook{
 		}
 	},
 }
+
+// tokenExpiryMargin is how long before its expiry an access token is renewed
+const tokenExpiryMargin = time.Minute
+
+// Authenticate is a build hook that adds a bearer access token to the request. The token
+// is requested with reqFn and cached until shortly before the expires_in of the response.
+func Authenticate(reqFn AuthenticationRequestFunc) gorequest.Hook {
+	var (
+		mu     sync.Mutex
+		token  string
+		expiry time.Time
+	)
+
+	return gorequest.Hook{
+		Name: "tanda.Authenticate",
+		Fn: func(r *gorequest.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			// make request to authenticate if the cached token is missing or expired
+			if token == "" || time.Now().After(expiry) {
+				req, out := reqFn()
+				req.WithContext(r.Context())
+				req.Config.Logger = r.Config.Logger
+				if err := req.Send(); err != nil {
+					r.Error = err
+					return
+				}
+
+				token = out.AccessToken
+				expiry = time.Now().Add(time.Duration(out.ExpiresIn)*time.Second - tokenExpiryMargin)
+			}
+
+			r.Request.Header.Set("Authorization", "Bearer "+token)
+		}}
+}
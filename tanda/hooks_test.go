@@ -1,6 +1,11 @@
 package tanda
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,6 +13,15 @@ import (
 	"github.com/SirWaithaka/gorequest"
 )
 
+// missingParameters builds the aggregated error message for missing required parameters
+func missingParameters(ids ...ParameterID) string {
+	msgs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", id, ErrMissingParameter))
+	}
+	return strings.Join(msgs, "\n")
+}
+
 func TestPaymentParametersValidator(t *testing.T) {
 	testcases := []struct {
 		name        string
@@ -25,6 +39,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Empty parameters",
 			params: RequestPayment{
 				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
 				Request:   nil,
 			},
 			expectError: true,
@@ -56,6 +71,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Valid CustomerToMerchantMobileMoneyPayment - all required parameters",
 			params: RequestPayment{
 				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
 					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
@@ -70,6 +86,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Valid MerchantToCustomerMobileMoneyPayment - all required parameters",
 			params: RequestPayment{
 				CommandID: CommandMerchantToCustomerMobileMoneyPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
 					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
@@ -84,6 +101,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Missing required parameter for CustomerToMerchantMobileMoneyPayment",
 			params: RequestPayment{
 				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
 					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
@@ -91,12 +109,13 @@ func TestPaymentParametersValidator(t *testing.T) {
 				},
 			},
 			expectError: true,
-			errorMsg:    "missing required parameter: accountNumber",
+			errorMsg:    missingParameters(ParameterIDAccountNumber, ParameterIDNarration, ParameterIDIpnUrl),
 		},
 		{
 			name: "Valid MerchantToCustomerBankPayment - all required parameters",
 			params: RequestPayment{
 				CommandID: CommandMerchantToCustomerBankPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "500", Label: "Amount"},
 					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
@@ -113,6 +132,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Missing bank-specific parameter for MerchantToCustomerBankPayment",
 			params: RequestPayment{
 				CommandID: CommandMerchantToCustomerBankPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "500", Label: "Amount"},
 					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
@@ -123,12 +143,13 @@ func TestPaymentParametersValidator(t *testing.T) {
 				},
 			},
 			expectError: true,
-			errorMsg:    "missing required parameter: accountName",
+			errorMsg:    missingParameters(ParameterIDAccountName, ParameterIDBankCode),
 		},
 		{
 			name: "Valid MerchantTo3rdPartyMerchantPayment - all required parameters",
 			params: RequestPayment{
 				CommandID: CommandMerchantTo3rdPartyMerchantPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "200", Label: "Amount"},
 					{ID: ParameterIDPartyA, Value: "600000", Label: "Party A"},
@@ -143,6 +164,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Valid MerchantToMerchantTandaPayment - all required parameters",
 			params: RequestPayment{
 				CommandID: CommandMerchantToMerchantTandaPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "300", Label: "Amount"},
 					{ID: ParameterIDPartyA, Value: "600000", Label: "Party A"},
@@ -157,6 +179,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Valid MerchantTo3rdPartyBusinessPayment - all required parameters",
 			params: RequestPayment{
 				CommandID: CommandMerchantTo3rdPartyBusinessPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "150", Label: "Amount"},
 					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
@@ -172,6 +195,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Missing parameter for MerchantTo3rdPartyBusinessPayment",
 			params: RequestPayment{
 				CommandID: CommandMerchantTo3rdPartyBusinessPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "150", Label: "Amount"},
 					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
@@ -181,12 +205,13 @@ func TestPaymentParametersValidator(t *testing.T) {
 				},
 			},
 			expectError: true,
-			errorMsg:    "missing required parameter: businessNumber",
+			errorMsg:    missingParameters(ParameterIDBusinessNumber, ParameterIDAccountReference),
 		},
 		{
 			name: "Valid InternationalMoneyTransferBank - all required parameters",
 			params: RequestPayment{
 				CommandID: CommandInternationalMoneyTransferBank,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "1000", Label: "Amount"},
 					{ID: ParameterIDCurrency, Value: "USD", Label: "Currency"},
@@ -226,6 +251,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Valid InternationalMoneyTransferMobile - all required parameters",
 			params: RequestPayment{
 				CommandID: CommandInternationalMoneyTransferMobile,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "500", Label: "Amount"},
 					{ID: ParameterIDCurrency, Value: "USD", Label: "Currency"},
@@ -258,6 +284,7 @@ func TestPaymentParametersValidator(t *testing.T) {
 			name: "Missing parameter for InternationalMoneyTransferMobile",
 			params: RequestPayment{
 				CommandID: CommandInternationalMoneyTransferMobile,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "500", Label: "Amount"},
 					{ID: ParameterIDCurrency, Value: "USD", Label: "Currency"},
@@ -266,12 +293,20 @@ func TestPaymentParametersValidator(t *testing.T) {
 				},
 			},
 			expectError: true,
-			errorMsg:    "missing required parameter: accountName",
+			errorMsg: missingParameters(
+				ParameterIDAccountName, ParameterIDAccountNumber, ParameterIDSenderType, ParameterIDSenderCompanyName,
+				ParameterIDBeneficiaryType, ParameterIDBeneficiaryActivity, ParameterIDBeneficiaryCountry,
+				ParameterIDDocumentType, ParameterIDDocumentNumber, ParameterIDNarration, ParameterIDSenderName,
+				ParameterIDSenderPhoneNumber, ParameterIDSenderDocumentType, ParameterIDSenderDocumentNumber,
+				ParameterIDSenderCountry, ParameterIDSenderCurrency, ParameterIDSenderSourceOfFunds,
+				ParameterIDSenderPrincipalActivity, ParameterIDIpnUrl, ParameterIDShortCode,
+			),
 		},
 		{
 			name: "Extra parameters with valid required ones - should pass",
 			params: RequestPayment{
 				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
 				Request: []PaymentRequestParameter{
 					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
 					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
@@ -284,6 +319,265 @@ func TestPaymentParametersValidator(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Invalid reference - too short",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF1",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "invalid reference: must be 8-16 alphanumeric characters",
+		},
+		{
+			name: "Invalid reference - not alphanumeric",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF-0000-01",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "invalid reference: must be 8-16 alphanumeric characters",
+		},
+		{
+			name: "Non numeric amount",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "ten", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "amount: must be numeric",
+		},
+		{
+			name: "Exponent amount",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "1e3", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "amount: must be numeric",
+		},
+		{
+			name: "Hex float amount",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "0x1p4", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "amount: must be numeric",
+		},
+		{
+			name: "Amount with more than 2 decimal places",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "100.123456", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "amount: must have at most 2 decimal places",
+		},
+		{
+			name: "Zero amount",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "0.00", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "amount: must be positive",
+		},
+		{
+			name: "Non positive amount",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "-100", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "amount: must be positive",
+		},
+		{
+			name: "Invalid msisdn for mobile money payment",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "0712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "accountNumber: must be a valid msisdn in the format 254XXXXXXXXX",
+		},
+		{
+			name: "Non https ipn url",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "http://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "ipnUrl: must be an absolute https url",
+		},
+		{
+			name: "Relative ipn url",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "ipnUrl: must be an absolute https url",
+		},
+		{
+			name: "Unknown parameter",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+					{ID: ParameterID("foo"), Value: "bar", Label: "Foo"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "foo: unknown parameter",
+		},
+		{
+			name: "Duplicate parameter values are validated together",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+					{ID: ParameterIDAmount, Value: "0", Label: "Amount"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "amount: duplicate parameter, must be positive",
+		},
+		{
+			name: "Errors are aggregated across parameters",
+			params: RequestPayment{
+				CommandID: CommandCustomerToMerchantMobileMoneyPayment,
+				Reference: "REF",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "ten", Label: "Amount"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+					{ID: ParameterIDAccountNumber, Value: "254712345678", Label: "Phone Number"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "invalid reference: must be 8-16 alphanumeric characters\nnarration: missing required parameter\namount: must be numeric",
+		},
+		{
+			name: "Invalid currency and country codes",
+			params: RequestPayment{
+				CommandID: CommandInternationalMoneyTransferMobile,
+				Reference: "REF00000001",
+				Request: []PaymentRequestParameter{
+					{ID: ParameterIDAmount, Value: "500", Label: "Amount"},
+					{ID: ParameterIDCurrency, Value: "usd", Label: "Currency"},
+					{ID: ParameterIDMobileNumber, Value: "1234567890", Label: "Mobile Number"},
+					{ID: ParameterIDAccountName, Value: "Alice Johnson", Label: "Account Name"},
+					{ID: ParameterIDAccountNumber, Value: "MOB123456", Label: "Account Number"},
+					{ID: ParameterIDSenderType, Value: "COMPANY", Label: "Sender Type"},
+					{ID: ParameterIDSenderCompanyName, Value: "Tech Corp", Label: "Sender Company Name"},
+					{ID: ParameterIDBeneficiaryType, Value: "INDIVIDUAL", Label: "Beneficiary Type"},
+					{ID: ParameterIDBeneficiaryActivity, Value: "teacher", Label: "Beneficiary Activity"},
+					{ID: ParameterIDBeneficiaryCountry, Value: "UGA", Label: "Beneficiary Country"},
+					{ID: ParameterIDDocumentType, Value: "passport", Label: "Document Type"},
+					{ID: ParameterIDDocumentNumber, Value: "P654321", Label: "Document Number"},
+					{ID: ParameterIDNarration, Value: "Mobile money transfer", Label: "Narration"},
+					{ID: ParameterIDSenderName, Value: "Tech Corp Ltd", Label: "Sender Name"},
+					{ID: ParameterIDSenderPhoneNumber, Value: "254700987654", Label: "Sender Phone"},
+					{ID: ParameterIDSenderDocumentType, Value: "registration", Label: "Sender Document Type"},
+					{ID: ParameterIDSenderDocumentNumber, Value: "REG456789", Label: "Sender Document Number"},
+					{ID: ParameterIDSenderCountry, Value: "KE", Label: "Sender Country"},
+					{ID: ParameterIDSenderCurrency, Value: "KSH", Label: "Sender Currency"},
+					{ID: ParameterIDSenderSourceOfFunds, Value: "business_revenue", Label: "Sender Source of Funds"},
+					{ID: ParameterIDSenderPrincipalActivity, Value: "technology", Label: "Sender Principal Activity"},
+					{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "IPN URL"},
+					{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "currency: must be an ISO 4217 currency code\nbeneficiaryCountry: must be an ISO 3166-1 alpha-2 country code\nsenderCurrency: must be an ISO 4217 currency code",
+		},
 	}
 
 	for _, tt := range testcases {
@@ -389,3 +683,85 @@ func TestParameterIDs(t *testing.T) {
 		assert.Equalf(t, id, result[i], "Expected ID %s at index %d, got %s", id, i, result[i])
 	}
 }
+
+func TestParameterError(t *testing.T) {
+	req := &gorequest.Request{
+		Params: RequestPayment{
+			CommandID: CommandMerchantToCustomerMobileMoneyPayment,
+			Reference: "REF00000001",
+			Request: []PaymentRequestParameter{
+				{ID: ParameterIDAmount, Value: "100", Label: "Amount"},
+				{ID: ParameterIDAmount, Value: "abc", Label: "Amount"},
+				{ID: ParameterIDShortCode, Value: "174379", Label: "Short Code"},
+				{ID: ParameterIDNarration, Value: "Payment", Label: "Description"},
+				{ID: ParameterIDIpnUrl, Value: "https://example.com/callback", Label: "Callback URL"},
+			},
+		},
+	}
+
+	PaymentParametersValidator.Fn(req)
+
+	assert.ErrorIs(t, req.Error, ErrMissingParameter)
+	assert.ErrorIs(t, req.Error, ErrDuplicateParameter)
+
+	// check errors can be inspected per parameter
+	var paramErr *ParameterError
+	if assert.True(t, errors.As(req.Error, &paramErr)) {
+		assert.Equal(t, ParameterIDAccountNumber, paramErr.ID)
+		assert.Equal(t, []error{ErrMissingParameter}, paramErr.Errors)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	var tokens int
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+EndpointAuthentication, func(w http.ResponseWriter, r *http.Request) {
+		tokens++
+		expiresIn := 3599
+		if r.FormValue("client_id") == "short_lived" {
+			expiresIn = 0
+		}
+		_, _ = fmt.Fprintf(w, `{"access_token":"token%d","token_type":"Bearer","expires_in":%d}`, tokens, expiresIn)
+	})
+	mux.HandleFunc("POST /io/v3/organizations/{org}/request", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status":"E401000","category":"Business","severity":"Low","error":"Unauthorized","description":"invalid token."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"P202000","message":"Request received successfully.","trackingId":"1"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	payment := RequestPayment{CommandID: CommandMerchantToCustomerMobileMoneyPayment, Reference: "REF00000001"}
+
+	t.Run("test that the token is cached", func(t *testing.T) {
+		tokens = 0
+		client := New(Config{Endpoint: server.URL})
+		client.Hooks.Build.PushBackHook(Authenticate(func() (*gorequest.Request, *ResponseAuthentication) {
+			return client.AuthenticationRequest("client_id", "secret")
+		}))
+
+		for range 2 {
+			req, _ := client.PaymentRequest("org", payment)
+			assert.NoError(t, req.Send())
+			assert.Equal(t, "Bearer token1", req.Request.Header.Get("Authorization"))
+		}
+		assert.Equal(t, 1, tokens)
+	})
+
+	t.Run("test that an expired token is renewed", func(t *testing.T) {
+		tokens = 0
+		client := New(Config{Endpoint: server.URL})
+		client.Hooks.Build.PushBackHook(Authenticate(func() (*gorequest.Request, *ResponseAuthentication) {
+			return client.AuthenticationRequest("short_lived", "secret")
+		}))
+
+		for range 2 {
+			req, _ := client.PaymentRequest("org", payment)
+			assert.NoError(t, req.Send())
+		}
+		assert.Equal(t, 2, tokens)
+	})
+}
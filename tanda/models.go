@@ -1,7 +1,10 @@
 package tanda
 
 import (
+	"fmt"
 	"time"
+
+	"github.com/SirWaithaka/payments/money"
 )
 
 type Command string
@@ -144,6 +147,16 @@ func (r *RequestPayment) AddParameter(id ParameterID, value string) {
 	})
 }
 
+// Parameter returns the value of the first parameter with the given id
+func (r RequestPayment) Parameter(id ParameterID) (string, bool) {
+	for _, param := range r.Request {
+		if param.ID == id {
+			return param.Value, true
+		}
+	}
+	return "", false
+}
+
 type PaymentRequestParameter struct {
 	ID    ParameterID `json:"id"`
 	Value string      `json:"value"`
@@ -219,3 +232,49 @@ type WebhookRequestPaymentStatus struct {
 		Ref string `json:"ref"`
 	} `json:"result"`
 }
+
+// MONEY ACCESSORS
+
+// setParameter replaces the value of the parameter with the given id, or adds it if absent
+func (r *RequestPayment) setParameter(id ParameterID, value string) {
+	for i := range r.Request {
+		if r.Request[i].ID == id {
+			r.Request[i].Value = value
+			return
+		}
+	}
+	r.AddParameter(id, value)
+}
+
+// Money returns the amount parameter as money.Money, in the currency of the
+// currency parameter if present, otherwise in KES
+func (r RequestPayment) Money() (money.Money, error) {
+	currency := money.KES
+	if c, ok := r.Parameter(ParameterIDCurrency); ok {
+		currency = money.Currency(c)
+	}
+
+	amount, ok := r.Parameter(ParameterIDAmount)
+	if !ok {
+		return money.Money{}, fmt.Errorf("%w: missing %s parameter", money.ErrInvalidAmount, ParameterIDAmount)
+	}
+	return money.Parse(amount, currency)
+}
+
+// SetMoney sets the amount parameter from m. KES amounts must be whole shillings,
+// while amounts of other currencies are set with 2 decimal places along with the
+// currency parameter.
+func (r *RequestPayment) SetMoney(m money.Money) error {
+	if m.Currency() == money.KES {
+		text, err := money.Whole(m).MarshalText()
+		if err != nil {
+			return err
+		}
+		r.setParameter(ParameterIDAmount, string(text))
+		return nil
+	}
+
+	r.setParameter(ParameterIDAmount, m.Decimal())
+	r.setParameter(ParameterIDCurrency, string(m.Currency()))
+	return nil
+}
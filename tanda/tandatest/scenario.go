@@ -0,0 +1,95 @@
+package tandatest
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+// Scenario scripts how the server handles a payment request of a command
+type Scenario struct {
+	//Error is responded synchronously instead of accepting the request, with the http
+	//status of its status code e.g. 422 for E422006. No IPN is sent.
+	Error *tanda.ErrorResponse
+
+	//Status and Message are sent in the IPN of an accepted request, and returned by the
+	//status endpoint once the IPN is sent
+	Status  tanda.PaymentStatus
+	Message string
+
+	//Timeout accepts the request but never sends its IPN, the payment stays P202000
+	Timeout bool
+
+	//Delay is how long the server waits before sending the IPN
+	Delay time.Duration
+}
+
+// messages are the descriptions tanda gives for each payment status
+var messages = map[tanda.PaymentStatus]string{
+	tanda.PaymentStatusS000000: "Request processed successfully.",
+	tanda.PaymentStatusP202000: "Request received successfully.",
+	tanda.PaymentStatusE400000: "Bad request",
+	tanda.PaymentStatusE401000: "Unauthorized",
+	tanda.PaymentStatusE403000: "Access denied",
+	tanda.PaymentStatusE404000: "Not found",
+	tanda.PaymentStatusE409000: "Duplicate resource found",
+	tanda.PaymentStatusE422005: "Request failed. Product not found",
+	tanda.PaymentStatusE422006: "Request failed. Insufficient Wallet balance",
+	tanda.PaymentStatusE422022: "Payment Request Validation Failed",
+	tanda.PaymentStatusE500000: "Internal Server Error",
+	tanda.PaymentStatusE501000: "Not implemented",
+	tanda.PaymentStatusE503000: "Service unavailable. Product or service is disabled or unavailable",
+	tanda.PaymentStatusE000002: "Third party Error",
+}
+
+// httpStatus returns the http status of a payment status code e.g. 422 for E422006,
+// codes without one such as E000002 are internal server errors
+func httpStatus(status tanda.PaymentStatus) int {
+	if len(status) >= 4 {
+		if code, err := strconv.Atoi(string(status[1:4])); err == nil && code >= 400 {
+			return code
+		}
+	}
+	return 500
+}
+
+// errorResponse returns the ErrorResponse of a payment status
+func errorResponse(status tanda.PaymentStatus, description string) *tanda.ErrorResponse {
+	category, severity := "Business", "Low"
+	if httpStatus(status) >= 500 {
+		category, severity = "Technical", "High"
+	}
+	return &tanda.ErrorResponse{
+		Status:      string(status),
+		Category:    category,
+		Severity:    severity,
+		Error:       messages[status],
+		Description: description,
+	}
+}
+
+// Reject returns a scenario that rejects payment requests synchronously with status
+func Reject(status tanda.PaymentStatus) Scenario {
+	return Scenario{Error: errorResponse(status, messages[status])}
+}
+
+// Result returns a scenario that accepts payment requests and sends status in their IPN
+func Result(status tanda.PaymentStatus) Scenario {
+	return Scenario{Status: status, Message: messages[status]}
+}
+
+// Scenarios of accepted requests, distinguished by the status sent in the IPN
+var (
+	Success         = Result(tanda.PaymentStatusS000000)
+	ThirdPartyError = Result(tanda.PaymentStatusE000002)
+	Timeout         = Scenario{Timeout: true}
+)
+
+// Scenarios of requests rejected synchronously with an ErrorResponse
+var (
+	InsufficientBalance = Reject(tanda.PaymentStatusE422006)
+	ProductNotFound     = Reject(tanda.PaymentStatusE422005)
+	ServiceUnavailable  = Reject(tanda.PaymentStatusE503000)
+	InternalError       = Reject(tanda.PaymentStatusE500000)
+)
@@ -0,0 +1,356 @@
+// Package tandatest provides an in-process simulator of the tanda API for tests.
+//
+// A Server implements the token, payment request and transaction status endpoints of the
+// tanda package, and posts the IPN of each accepted payment to the ipnUrl parameter of its
+// request. Requests must carry a token issued by the server for ClientID and ClientSecret,
+// and are validated with tanda.PaymentParametersValidator. Each payment takes the next
+// Scenario scripted for its command, so that every tanda.PaymentStatus can be returned on
+// demand without network access.
+package tandatest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/tanda"
+)
+
+// Credentials accepted by the server
+const (
+	ClientID       = "tandatest_client"
+	ClientSecret   = "tandatest_secret"
+	OrganizationID = "tandatest_org"
+)
+
+// TokenTTL is how long the access tokens issued by the server are valid
+const TokenTTL = 3599 * time.Second
+
+// payment is an accepted payment request
+type payment struct {
+	trackingID string
+	status     tanda.PaymentStatus
+	message    string
+}
+
+// Server is an in-process tanda API. Create one with NewServer and close it with Close.
+type Server struct {
+	//URL is the base url of the server, use it as the tanda.Config Endpoint
+	URL string
+
+	server *httptest.Server
+	ipn    *http.Client
+
+	mu         sync.Mutex
+	seq        int
+	tokens     map[string]time.Time
+	scripts    map[tanda.Command][]Scenario
+	payments   map[string]*payment
+	references map[string]struct{}
+	webhooks   sync.WaitGroup
+	errs       []error
+}
+
+// NewServer starts a Server
+func NewServer() *Server {
+	s := &Server{
+		// tanda only posts IPNs to https urls, the server trusts any certificate so that
+		// IPNs can be received with httptest.NewTLSServer
+		ipn: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+		tokens:     make(map[string]time.Time),
+		scripts:    make(map[tanda.Command][]Scenario),
+		payments:   make(map[string]*payment),
+		references: make(map[string]struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+tanda.EndpointAuthentication, s.authenticate)
+	mux.HandleFunc("POST "+fmt.Sprintf(tanda.EndpointPayments, "{org}"), s.payment)
+	mux.HandleFunc("GET "+fmt.Sprintf(tanda.EndpointTransactionStatus, "{org}", "{trackingId}"), s.transactionStatus)
+
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close waits for pending IPNs and shuts down the server
+func (s *Server) Close() {
+	s.webhooks.Wait()
+	s.server.Close()
+}
+
+// Client returns a tanda.Client for the server, authenticated with ClientID and ClientSecret
+func (s *Server) Client() tanda.Client {
+	client := tanda.New(tanda.Config{Endpoint: s.URL})
+	client.Hooks.Build.PushBackHook(tanda.Authenticate(func() (*gorequest.Request, *tanda.ResponseAuthentication) {
+		return client.AuthenticationRequest(ClientID, ClientSecret)
+	}))
+	return client
+}
+
+// Script queues scenarios for the payment requests of a command. Each request of the
+// command takes the next scenario in the queue, and Success once the queue is empty.
+func (s *Server) Script(command tanda.Command, scenarios ...Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[command] = append(s.scripts[command], scenarios...)
+}
+
+// ExpireTokens expires the access tokens issued so far, later requests with them are
+// rejected with tanda.PaymentStatusE401000
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.tokens)
+}
+
+// Wait blocks until the pending IPNs are sent, and returns the errors of the ones
+// that could not be delivered
+func (s *Server) Wait() error {
+	s.webhooks.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := errors.Join(s.errs...)
+	s.errs = nil
+	return err
+}
+
+func (s *Server) next(command tanda.Command) Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queue := s.scripts[command]
+	if len(queue) == 0 {
+		return Success
+	}
+	s.scripts[command] = queue[1:]
+	return queue[0]
+}
+
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		s.fail(w, errorResponse(tanda.PaymentStatusE400000, "unsupported grant type."))
+		return
+	}
+	if r.PostForm.Get("client_id") != ClientID || r.PostForm.Get("client_secret") != ClientSecret {
+		s.fail(w, errorResponse(tanda.PaymentStatusE401000, "invalid client credentials."))
+		return
+	}
+
+	s.mu.Lock()
+	s.seq++
+	token := fmt.Sprintf("tandatest%024d", s.seq)
+	s.tokens[token] = time.Now().Add(TokenTTL)
+	s.mu.Unlock()
+
+	s.reply(w, tanda.ResponseAuthentication{
+		TokenType:   "Bearer",
+		ExpiresIn:   uint(TokenTTL.Seconds()),
+		AccessToken: token,
+	})
+}
+
+// authorize checks the bearer token and organization of a request
+func (s *Server) authorize(r *http.Request) *tanda.ErrorResponse {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return errorResponse(tanda.PaymentStatusE401000, "missing token.")
+	}
+
+	s.mu.Lock()
+	expiry, ok := s.tokens[token]
+	s.mu.Unlock()
+	if !ok || time.Now().After(expiry) {
+		return errorResponse(tanda.PaymentStatusE401000, "invalid token.")
+	}
+
+	if r.PathValue("org") != OrganizationID {
+		return errorResponse(tanda.PaymentStatusE403000, "organization not accessible with this token.")
+	}
+	return nil
+}
+
+func (s *Server) payment(w http.ResponseWriter, r *http.Request) {
+	if res := s.authorize(r); res != nil {
+		s.fail(w, res)
+		return
+	}
+
+	var req tanda.RequestPayment
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.fail(w, errorResponse(tanda.PaymentStatusE400000, "invalid request body."))
+		return
+	}
+
+	// validate the request the way the client side hook does
+	validation := &gorequest.Request{Params: req}
+	tanda.PaymentParametersValidator.Fn(validation)
+	if validation.Error != nil {
+		description := strings.ReplaceAll(validation.Error.Error(), "\n", "; ")
+		s.fail(w, errorResponse(tanda.PaymentStatusE422022, description))
+		return
+	}
+
+	s.mu.Lock()
+	_, exists := s.references[req.Reference]
+	s.mu.Unlock()
+	if exists {
+		s.fail(w, errorResponse(tanda.PaymentStatusE409000, "reference "+req.Reference+" already exists."))
+		return
+	}
+
+	sc := s.next(req.CommandID)
+	if sc.Error != nil {
+		s.fail(w, sc.Error)
+		return
+	}
+	if sc.Status == "" {
+		sc.Status, sc.Message = Success.Status, Success.Message
+	}
+
+	p := &payment{
+		trackingID: s.uuid(),
+		status:     tanda.PaymentStatusP202000,
+		message:    messages[tanda.PaymentStatusP202000],
+	}
+	s.mu.Lock()
+	s.references[req.Reference] = struct{}{}
+	s.payments[p.trackingID] = p
+	s.mu.Unlock()
+
+	res := tanda.ResponsePayment{
+		TrackingID: p.trackingID,
+		Reference:  req.Reference,
+		Status:     p.status,
+		Message:    p.message,
+	}
+
+	ipnURL, _ := req.Parameter(tanda.ParameterIDIpnUrl)
+	s.deliver(ipnURL, sc, func() any {
+		s.mu.Lock()
+		p.status, p.message = sc.Status, sc.Message
+		s.mu.Unlock()
+
+		ipn := tanda.WebhookRequestPaymentStatus{
+			TrackingID:    p.trackingID,
+			TransactionID: s.uuid(),
+			Reference:     req.Reference,
+			Status:        sc.Status,
+			Message:       sc.Message,
+			Timestamp:     time.Now().UTC(),
+		}
+		if sc.Status == tanda.PaymentStatusS000000 {
+			ipn.Result.Ref = s.receiptNumber()
+		}
+		return ipn
+	})
+
+	s.reply(w, res)
+}
+
+func (s *Server) transactionStatus(w http.ResponseWriter, r *http.Request) {
+	if res := s.authorize(r); res != nil {
+		s.fail(w, res)
+		return
+	}
+	if r.URL.Query().Get("shortCode") == "" {
+		s.fail(w, errorResponse(tanda.PaymentStatusE400000, "shortCode is required."))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[r.PathValue("trackingId")]
+	if !ok {
+		s.fail(w, errorResponse(tanda.PaymentStatusE404000, "payment request not found."))
+		return
+	}
+	s.reply(w, tanda.ResponseTransactionStatus{Status: p.status, Message: p.message})
+}
+
+func (s *Server) reply(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = jsoniter.NewEncoder(w).Encode(body)
+}
+
+func (s *Server) fail(w http.ResponseWriter, res *tanda.ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(tanda.PaymentStatus(res.Status)))
+	_ = jsoniter.NewEncoder(w).Encode(res)
+}
+
+// deliver posts the IPN returned by body to url after the delay of the scenario, unless
+// the scenario times out
+func (s *Server) deliver(url string, sc Scenario, body func() any) {
+	if sc.Timeout {
+		return
+	}
+
+	s.webhooks.Add(1)
+	go func() {
+		defer s.webhooks.Done()
+		time.Sleep(sc.Delay)
+
+		if err := s.post(url, body()); err != nil {
+			s.mu.Lock()
+			s.errs = append(s.errs, fmt.Errorf("ipn %s: %w", url, err))
+			s.mu.Unlock()
+		}
+	}()
+}
+
+func (s *Server) post(url string, body any) error {
+	b, err := jsoniter.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.ipn.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// ids
+
+func (s *Server) id() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return s.seq
+}
+
+// uuid returns an id in the format of tanda tracking and transaction ids
+// e.g. 7dbd1ad8-2d7f-45e4-b4d6-d3cbd4a0bad2
+func (s *Server) uuid() string {
+	return fmt.Sprintf("7a4d0000-0000-4000-8000-%012x", s.id())
+}
+
+// receiptNumber returns a service provider receipt number e.g. NLJ7RT61SV
+func (s *Server) receiptNumber() string {
+	return fmt.Sprintf("TST%07X", s.id())
+}
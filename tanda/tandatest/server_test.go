@@ -0,0 +1,162 @@
+package tandatest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/tanda"
+	"github.com/SirWaithaka/payments/tanda/tandatest"
+)
+
+// ipns starts an https server that collects the IPNs posted to it
+func ipns(t *testing.T) (string, <-chan tanda.WebhookRequestPaymentStatus) {
+	received := make(chan tanda.WebhookRequestPaymentStatus, 10)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ipn tanda.WebhookRequestPaymentStatus
+		assert.NoError(t, jsoniter.NewDecoder(r.Body).Decode(&ipn))
+		received <- ipn
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, received
+}
+
+func receive(t *testing.T, received <-chan tanda.WebhookRequestPaymentStatus) tanda.WebhookRequestPaymentStatus {
+	t.Helper()
+
+	select {
+	case ipn := <-received:
+		return ipn
+	case <-time.After(time.Second):
+		t.Fatal("ipn not received")
+	}
+	return tanda.WebhookRequestPaymentStatus{}
+}
+
+func payment(reference, ipnURL string) tanda.RequestPayment {
+	req := tanda.RequestPayment{
+		CommandID:         tanda.CommandMerchantToCustomerMobileMoneyPayment,
+		ServiceProviderID: "MPESA",
+		Reference:         reference,
+	}
+	req.AddParameter(tanda.ParameterIDAmount, "100")
+	req.AddParameter(tanda.ParameterIDShortCode, "174379")
+	req.AddParameter(tanda.ParameterIDAccountNumber, "254712345678")
+	req.AddParameter(tanda.ParameterIDNarration, "Payment")
+	req.AddParameter(tanda.ParameterIDIpnUrl, ipnURL)
+	return req
+}
+
+func TestServer_Payment(t *testing.T) {
+	server := tandatest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	url, received := ipns(t)
+
+	t.Run("test that a successful payment is posted to the ipn url", func(t *testing.T) {
+		res, err := client.Payment(t.Context(), tandatest.OrganizationID, payment("REF00000001", url))
+		assert.NoError(t, err)
+		assert.Equal(t, tanda.PaymentStatusP202000, res.Status)
+		assert.Equal(t, "REF00000001", res.Reference)
+
+		ipn := receive(t, received)
+		assert.Equal(t, res.TrackingID, ipn.TrackingID)
+		assert.Equal(t, "REF00000001", ipn.Reference)
+		assert.Equal(t, tanda.PaymentStatusS000000, ipn.Status)
+		assert.NotEmpty(t, ipn.Result.Ref)
+
+		status, err := client.TransactionStatus(t.Context(), tandatest.OrganizationID, res.TrackingID, "174379")
+		assert.NoError(t, err)
+		assert.Equal(t, tanda.PaymentStatusS000000, status.Status)
+	})
+
+	t.Run("test that a failed payment is posted to the ipn url", func(t *testing.T) {
+		server.Script(tanda.CommandMerchantToCustomerMobileMoneyPayment, tandatest.ThirdPartyError)
+
+		_, err := client.Payment(t.Context(), tandatest.OrganizationID, payment("REF00000002", url))
+		assert.NoError(t, err)
+
+		ipn := receive(t, received)
+		assert.Equal(t, tanda.PaymentStatusE000002, ipn.Status)
+		assert.Empty(t, ipn.Result.Ref)
+	})
+
+	t.Run("test that a timed out payment stays pending", func(t *testing.T) {
+		server.Script(tanda.CommandMerchantToCustomerMobileMoneyPayment, tandatest.Timeout)
+
+		res, err := client.Payment(t.Context(), tandatest.OrganizationID, payment("REF00000003", url))
+		assert.NoError(t, err)
+		assert.NoError(t, server.Wait())
+		assert.Empty(t, received)
+
+		status, err := client.TransactionStatus(t.Context(), tandatest.OrganizationID, res.TrackingID, "174379")
+		assert.NoError(t, err)
+		assert.Equal(t, tanda.PaymentStatusP202000, status.Status)
+	})
+
+	t.Run("test that scripted statuses are responded synchronously", func(t *testing.T) {
+		statuses := []tanda.PaymentStatus{
+			tanda.PaymentStatusE422006, tanda.PaymentStatusE422005, tanda.PaymentStatusE503000, tanda.PaymentStatusE501000,
+		}
+		for _, status := range statuses {
+			server.Script(tanda.CommandMerchantToCustomerMobileMoneyPayment, tandatest.Reject(status))
+		}
+
+		for _, status := range statuses {
+			_, err := client.Payment(t.Context(), tandatest.OrganizationID, payment("REF00000004", url))
+			assert.ErrorContains(t, err, "<"+string(status)+">")
+		}
+		assert.Empty(t, received)
+	})
+
+	t.Run("test that a reference cannot be reused", func(t *testing.T) {
+		_, err := client.Payment(t.Context(), tandatest.OrganizationID, payment("REF00000001", url))
+		assert.ErrorContains(t, err, "<E409000>")
+	})
+
+	t.Run("test that an invalid payment fails validation", func(t *testing.T) {
+		_, err := client.Payment(t.Context(), tandatest.OrganizationID, payment("REF", url))
+		assert.ErrorContains(t, err, "<E422022>")
+	})
+
+	t.Run("test that an unknown tracking id is not found", func(t *testing.T) {
+		_, err := client.TransactionStatus(t.Context(), tandatest.OrganizationID, "unknown", "174379")
+		assert.ErrorContains(t, err, "<E404000>")
+	})
+}
+
+func TestServer_Authentication(t *testing.T) {
+	server := tandatest.NewServer()
+	defer server.Close()
+	url, _ := ipns(t)
+
+	t.Run("test that invalid credentials are rejected", func(t *testing.T) {
+		req, _ := tanda.New(tanda.Config{Endpoint: server.URL}).AuthenticationRequest(tandatest.ClientID, "wrong")
+		assert.ErrorContains(t, req.Send(), "<E401000>")
+	})
+
+	t.Run("test that issued tokens expire", func(t *testing.T) {
+		req, out := tanda.New(tanda.Config{Endpoint: server.URL}).AuthenticationRequest(tandatest.ClientID, tandatest.ClientSecret)
+		assert.NoError(t, req.Send())
+		assert.Equal(t, "Bearer", out.TokenType)
+		assert.Equal(t, uint(tandatest.TokenTTL.Seconds()), out.ExpiresIn)
+
+		client := server.Client()
+		_, err := client.Payment(t.Context(), tandatest.OrganizationID, payment("REF00000005", url))
+		assert.NoError(t, err)
+
+		server.ExpireTokens()
+		_, err = client.Payment(t.Context(), tandatest.OrganizationID, payment("REF00000006", url))
+		assert.EqualError(t, err, "<E401000> Unauthorized: invalid token.")
+	})
+
+	t.Run("test that other organizations are not accessible", func(t *testing.T) {
+		_, err := server.Client().Payment(t.Context(), "other_org", payment("REF00000007", url))
+		assert.ErrorContains(t, err, "<E403000>")
+	})
+}
@@ -0,0 +1,110 @@
+package tariff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/money"
+)
+
+var ErrInvalidCharges = errors.New("invalid debit party charges")
+
+// Reconciliation compares the fee computed from a tariff table with the charges
+// reported by M-PESA for a completed transaction
+type Reconciliation struct {
+	Version string
+	Command daraja.Command
+	// Amount is the transaction amount
+	Amount money.Money
+	// Expected is the fee computed from the tariff table
+	Expected money.Money
+	// Charged is the fee reported in the DebitPartyCharges result parameter
+	Charged money.Money
+}
+
+// Matches reports whether the charged fee equals the expected fee
+func (r Reconciliation) Matches() bool {
+	return r.Expected.Equal(r.Charged)
+}
+
+// Difference returns the amount charged over the expected fee, it is negative if
+// M-PESA charged less than expected
+func (r Reconciliation) Difference() (money.Money, error) {
+	return r.Charged.Sub(r.Expected)
+}
+
+// ParseCharges parses the DebitPartyCharges result parameter, which is formatted
+// like the balances of an AccountBalance result e.g. "Business Pay Bill Charge|KES|77.00".
+// Multiple charges are separated by "&" and are summed up. An empty value means no charges.
+func ParseCharges(value string) (money.Money, error) {
+	total := money.New(0, money.KES)
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return total, nil
+	}
+
+	for _, charge := range strings.Split(value, "&") {
+		fields := strings.Split(strings.TrimSpace(charge), "|")
+		if len(fields) < 3 {
+			return money.Money{}, fmt.Errorf("%w: %q", ErrInvalidCharges, charge)
+		}
+
+		amount, err := money.Parse(fields[2], money.Currency(fields[1]))
+		if err != nil {
+			return money.Money{}, errors.Join(ErrInvalidCharges, err)
+		}
+		if total, err = total.Add(amount); err != nil {
+			return money.Money{}, errors.Join(ErrInvalidCharges, err)
+		}
+	}
+	return total, nil
+}
+
+// ReconcileB2B computes the fee of a completed b2b transaction sent with command and
+// compares it with the DebitPartyCharges result parameter. The command is not part of
+// the result, so it has to be taken from the original RequestB2B.
+func (t *Table) ReconcileB2B(command daraja.Command, result daraja.WebhookRequestB2B) (Reconciliation, error) {
+	if result.Result.ResultCode != daraja.ResultCodeSuccess {
+		return Reconciliation{}, fmt.Errorf("cannot reconcile failed transaction: %s", result.Result.ResultDesc)
+	}
+
+	amount, err := result.Money()
+	if err != nil {
+		return Reconciliation{}, err
+	}
+
+	expected, err := t.Fee(command, amount)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+
+	charged := money.New(0, money.KES)
+	if result.Result.ResultParameters != nil {
+		for _, param := range result.Result.ResultParameters.ResultParameter {
+			if param.Key != "DebitPartyCharges" {
+				continue
+			}
+			// the parameter is omitted or sent without a value when there are no charges
+			switch v := param.Value.(type) {
+			case string:
+				charged, err = ParseCharges(v)
+			case float64:
+				charged, err = money.FromFloat(v, money.KES)
+			}
+			if err != nil {
+				return Reconciliation{}, err
+			}
+		}
+	}
+
+	return Reconciliation{
+		Version:  t.Version,
+		Command:  command,
+		Amount:   amount,
+		Expected: expected,
+		Charged:  charged,
+	}, nil
+}
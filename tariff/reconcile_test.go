@@ -0,0 +1,94 @@
+package tariff_test
+
+import (
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/tariff"
+)
+
+func b2bResult(t *testing.T, params string) daraja.WebhookRequestB2B {
+	t.Helper()
+
+	body := `{"Result":{"ResultType":0,"ResultCode":0,"ResultDesc":"The service request is processed successfully",
+"OriginatorConversationID":"626f6ddf-ab37-4650-b882-b1de92ec9aa4","ConversationID":"12345677dfdf89099B3",
+"TransactionID":"QKA81LK5CY","ResultParameters":{"ResultParameter":[` + params + `]}}}`
+
+	var result daraja.WebhookRequestB2B
+	assert.NoError(t, jsoniter.Unmarshal([]byte(body), &result))
+	return result
+}
+
+func TestParseCharges(t *testing.T) {
+	tcs := []struct {
+		input string
+		cents int64
+		err   bool
+	}{
+		{"", 0, false},
+		{"Business Pay Bill Charge|KES|77.00", 7700, false},
+		{"Business Pay Bill Charge|KES|77.00&Excise Duty|KES|7.70", 8470, false},
+		{"77.00", 0, true},
+		{"Business Pay Bill Charge|KES|seventy", 0, true},
+		{"Business Pay Bill Charge|USD|1.00", 0, true},
+	}
+
+	for _, tc := range tcs {
+		charges, err := tariff.ParseCharges(tc.input)
+		if tc.err {
+			assert.ErrorIsf(t, err, tariff.ErrInvalidCharges, "input %q", tc.input)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, money.New(tc.cents, money.KES), charges)
+	}
+}
+
+func TestTable_ReconcileB2B(t *testing.T) {
+	table, err := tariff.Version("2023-05-01")
+	assert.NoError(t, err)
+
+	t.Run("test that matching charges reconcile", func(t *testing.T) {
+		result := b2bResult(t, `{"Key":"Amount","Value":"1000.00"},{"Key":"DebitPartyCharges","Value":"Business Pay Bill Charge|KES|13.00"}`)
+
+		rec, err := table.ReconcileB2B(daraja.CommandBusinessPayBill, result)
+		assert.NoError(t, err)
+		assert.True(t, rec.Matches())
+		assert.Equal(t, "2023-05-01", rec.Version)
+		assert.Equal(t, money.FromUnits(1000, money.KES), rec.Amount)
+		assert.Equal(t, money.FromUnits(13, money.KES), rec.Expected)
+	})
+
+	t.Run("test that a different charge is reported", func(t *testing.T) {
+		result := b2bResult(t, `{"Key":"Amount","Value":1000},{"Key":"DebitPartyCharges","Value":"Business Buy Goods Charge|KES|20.00"}`)
+
+		rec, err := table.ReconcileB2B(daraja.CommandBusinessBuyGoods, result)
+		assert.NoError(t, err)
+		assert.False(t, rec.Matches())
+
+		diff, err := rec.Difference()
+		assert.NoError(t, err)
+		assert.Equal(t, money.FromUnits(7, money.KES), diff)
+	})
+
+	t.Run("test that missing charges are treated as zero", func(t *testing.T) {
+		result := b2bResult(t, `{"Key":"Amount","Value":1000},{"Key":"DebitPartyCharges"}`)
+
+		rec, err := table.ReconcileB2B(daraja.CommandBusinessPayBill, result)
+		assert.NoError(t, err)
+		assert.False(t, rec.Matches())
+		assert.Equal(t, money.New(0, money.KES), rec.Charged)
+	})
+
+	t.Run("test that failed transactions are not reconciled", func(t *testing.T) {
+		result := b2bResult(t, `{"Key":"Amount","Value":1000}`)
+		result.Result.ResultCode = daraja.ResultCodeInsufficientBalance
+
+		_, err := table.ReconcileB2B(daraja.CommandBusinessPayBill, result)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,221 @@
+// Package tariff calculates the M-PESA charges of daraja transactions from versioned
+// tariff tables. The tables shipped with the package are embedded from the tables
+// directory, one json file per tariff version, and are updated whenever Safaricom
+// publishes new tariffs. Custom tables can be loaded with Load.
+package tariff
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/money"
+)
+
+var (
+	ErrUnknownVersion   = errors.New("unknown tariff version")
+	ErrNoTariff         = errors.New("no tariff in effect")
+	ErrUnknownCommand   = errors.New("command has no tariff")
+	ErrAmountOutOfRange = errors.New("amount is outside the tariff bands")
+	ErrInvalidTable     = errors.New("invalid tariff table")
+)
+
+//go:embed tables/*.json
+var tables embed.FS
+
+// Band is a range of transaction amounts that attract the same Fee. Tariffs list bands in
+// whole shillings e.g. 10-100 and 101-1500, so a band covers the amounts from its Min up to
+// the Min of the next band, and amounts such as 100.50 are charged at the lower band's
+// Fee. The last band ends at its Max.
+type Band struct {
+	Min money.Money
+	Max money.Money
+	Fee money.Money
+}
+
+// Table is a version of the M-PESA tariff, with the charge bands of each Command
+type Table struct {
+	// Version identifies the table, embedded tables are versioned by their effective date
+	Version string
+	// Effective is the date from which the tariff applies
+	Effective time.Time
+
+	charges map[daraja.Command][]Band
+}
+
+type jsonBand struct {
+	Min money.Whole `json:"min"`
+	Max money.Whole `json:"max"`
+	Fee money.Whole `json:"fee"`
+}
+
+type jsonTable struct {
+	Version   string                        `json:"version"`
+	Effective string                        `json:"effective"`
+	Charges   map[daraja.Command][]jsonBand `json:"charges"`
+}
+
+// Load reads a tariff table in the json format of the embedded tables. The bands of
+// each command must be in ascending order and must not overlap.
+func Load(r io.Reader) (*Table, error) {
+	var v jsonTable
+	if err := jsoniter.NewDecoder(r).Decode(&v); err != nil {
+		return nil, errors.Join(ErrInvalidTable, err)
+	}
+
+	if v.Version == "" {
+		return nil, fmt.Errorf("%w: missing version", ErrInvalidTable)
+	}
+	effective, err := time.Parse(time.DateOnly, v.Effective)
+	if err != nil {
+		return nil, fmt.Errorf("%w: effective date: %w", ErrInvalidTable, err)
+	}
+
+	table := &Table{Version: v.Version, Effective: effective, charges: make(map[daraja.Command][]Band, len(v.Charges))}
+	for command, bands := range v.Charges {
+		for i, b := range bands {
+			band := Band{Min: money.Money(b.Min), Max: money.Money(b.Max), Fee: money.Money(b.Fee)}
+			if band.Min.Cents() > band.Max.Cents() || band.Fee.IsNegative() {
+				return nil, fmt.Errorf("%w: %s band %d", ErrInvalidTable, command, i)
+			}
+			if i > 0 && band.Min.Cents() <= money.Money(bands[i-1].Max).Cents() {
+				return nil, fmt.Errorf("%w: %s band %d overlaps previous band", ErrInvalidTable, command, i)
+			}
+			table.charges[command] = append(table.charges[command], band)
+		}
+	}
+	return table, nil
+}
+
+// Commands returns the commands that have charges in the table
+func (t *Table) Commands() []daraja.Command {
+	commands := make([]daraja.Command, 0, len(t.charges))
+	for command := range t.charges {
+		commands = append(commands, command)
+	}
+	slices.Sort(commands)
+	return commands
+}
+
+// Bands returns the charge bands of command in ascending order
+func (t *Table) Bands(command daraja.Command) []Band {
+	return slices.Clone(t.charges[command])
+}
+
+// Fee returns the charge for sending amount with the given command
+func (t *Table) Fee(command daraja.Command, amount money.Money) (money.Money, error) {
+	bands, ok := t.charges[command]
+	if !ok {
+		return money.Money{}, fmt.Errorf("%w: %s", ErrUnknownCommand, command)
+	}
+	if amount.Currency() != money.KES {
+		return money.Money{}, fmt.Errorf("%w: %s", money.ErrCurrencyMismatch, amount.Currency())
+	}
+
+	for i, band := range bands {
+		if amount.Cents() < band.Min.Cents() {
+			break
+		}
+		// each band is half-open up to the next one, only the last band ends at its Max
+		if i+1 < len(bands) {
+			if amount.Cents() < bands[i+1].Min.Cents() {
+				return band.Fee, nil
+			}
+			continue
+		}
+		if amount.Cents() <= band.Max.Cents() {
+			return band.Fee, nil
+		}
+	}
+	return money.Money{}, fmt.Errorf("%w: %s %s", ErrAmountOutOfRange, command, amount)
+}
+
+// Total returns the amount plus the charge for sending it with the given command,
+// which is the amount debited from the sending organization
+func (t *Table) Total(command daraja.Command, amount money.Money) (money.Money, error) {
+	fee, err := t.Fee(command, amount)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return amount.Add(fee)
+}
+
+var embedded = sync.OnceValues(func() ([]*Table, error) {
+	files, err := fs.Glob(tables, "tables/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make([]*Table, 0, len(files))
+	for _, name := range files {
+		f, err := tables.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		table, err := Load(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.Base(name), err)
+		}
+		loaded = append(loaded, table)
+	}
+
+	// order tables by the date they came into effect
+	slices.SortFunc(loaded, func(a, b *Table) int { return a.Effective.Compare(b.Effective) })
+	return loaded, nil
+})
+
+// Versions returns the versions of the embedded tables, oldest first
+func Versions() ([]string, error) {
+	loaded, err := embedded()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(loaded))
+	for _, table := range loaded {
+		versions = append(versions, table.Version)
+	}
+	return versions, nil
+}
+
+// Version returns the embedded table with the given version
+func Version(version string) (*Table, error) {
+	loaded, err := embedded()
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range loaded {
+		if table.Version == version {
+			return table, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownVersion, version)
+}
+
+// At returns the embedded table that was in effect at t
+func At(t time.Time) (*Table, error) {
+	loaded, err := embedded()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(loaded) - 1; i >= 0; i-- {
+		if !loaded[i].Effective.After(t) {
+			return loaded[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoTariff, t.Format(time.DateOnly))
+}
+
+// Current returns the embedded table in effect now
+func Current() (*Table, error) {
+	return At(time.Now())
+}
@@ -0,0 +1,118 @@
+package tariff_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/money"
+	"github.com/SirWaithaka/payments/tariff"
+)
+
+func TestEmbeddedTables(t *testing.T) {
+	versions, err := tariff.Versions()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, versions)
+
+	for _, version := range versions {
+		table, err := tariff.Version(version)
+		assert.NoError(t, err)
+		assert.Equal(t, version, table.Version)
+		assert.NotEmpty(t, table.Commands())
+	}
+
+	_, err = tariff.Version("1970-01-01")
+	assert.ErrorIs(t, err, tariff.ErrUnknownVersion)
+
+	table, err := tariff.Current()
+	assert.NoError(t, err)
+	assert.Equal(t, versions[len(versions)-1], table.Version)
+
+	_, err = tariff.At(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, tariff.ErrNoTariff)
+}
+
+func TestTable_Fee(t *testing.T) {
+	table, err := tariff.Version("2023-05-01")
+	assert.NoError(t, err)
+
+	tcs := []struct {
+		command daraja.Command
+		amount  int64
+		fee     int64
+		err     error
+	}{
+		{daraja.CommandBusinessPayment, 100, 0, nil},
+		{daraja.CommandBusinessPayment, 101, 5, nil},
+		{daraja.CommandSalaryPayment, 1500, 5, nil},
+		{daraja.CommandPromotionPayment, 1501, 9, nil},
+		{daraja.CommandBusinessPayment, 250000, 11, nil},
+		{daraja.CommandBusinessPayBill, 1, 2, nil},
+		{daraja.CommandBusinessPayBill, 10000, 54, nil},
+		{daraja.CommandBusinessBuyGoods, 50001, 100, nil},
+		{daraja.CommandBusinessPayment, 5, 0, tariff.ErrAmountOutOfRange},
+		{daraja.CommandBusinessPayment, 250001, 0, tariff.ErrAmountOutOfRange},
+		{daraja.CommandAccountBalance, 100, 0, tariff.ErrUnknownCommand},
+	}
+
+	for _, tc := range tcs {
+		fee, err := table.Fee(tc.command, money.FromUnits(tc.amount, money.KES))
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equalf(t, money.FromUnits(tc.fee, money.KES), fee, "fee of %s %d", tc.command, tc.amount)
+	}
+
+	// amounts between the whole shilling bands are charged at the lower band's fee
+	fractions := []struct {
+		cents int64
+		fee   int64
+	}{
+		{10050, 0},
+		{150099, 5},
+		{500050, 9},
+	}
+	for _, tc := range fractions {
+		fee, err := table.Fee(daraja.CommandBusinessPayment, money.New(tc.cents, money.KES))
+		assert.NoError(t, err)
+		assert.Equalf(t, money.FromUnits(tc.fee, money.KES), fee, "fee of %d cents", tc.cents)
+	}
+	_, err = table.Fee(daraja.CommandBusinessPayment, money.New(25000050, money.KES))
+	assert.ErrorIs(t, err, tariff.ErrAmountOutOfRange)
+
+	_, err = table.Fee(daraja.CommandBusinessPayment, money.FromUnits(100, money.USD))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	total, err := table.Total(daraja.CommandBusinessPayBill, money.FromUnits(1000, money.KES))
+	assert.NoError(t, err)
+	assert.Equal(t, money.FromUnits(1013, money.KES), total)
+}
+
+func TestLoad(t *testing.T) {
+	valid := `{"version":"custom","effective":"2025-01-01","charges":{"BusinessPayment":[
+{"min":"1","max":"1000","fee":"10"},{"min":"1001","max":"2000","fee":"20"}]}}`
+
+	table, err := tariff.Load(strings.NewReader(valid))
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", table.Version)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), table.Effective)
+	assert.Len(t, table.Bands(daraja.CommandBusinessPayment), 2)
+
+	invalid := []string{
+		`{`,
+		`{"effective":"2025-01-01"}`,
+		`{"version":"custom","effective":"01-01-2025"}`,
+		`{"version":"custom","effective":"2025-01-01","charges":{"BusinessPayment":[{"min":"100","max":"1","fee":"10"}]}}`,
+		`{"version":"custom","effective":"2025-01-01","charges":{"BusinessPayment":[
+{"min":"1","max":"1000","fee":"10"},{"min":"1000","max":"2000","fee":"20"}]}}`,
+	}
+	for _, input := range invalid {
+		_, err = tariff.Load(strings.NewReader(input))
+		assert.ErrorIsf(t, err, tariff.ErrInvalidTable, "input %s", input)
+	}
+}
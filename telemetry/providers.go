@@ -0,0 +1,20 @@
+package telemetry
+
+import "github.com/SirWaithaka/payments/internal/exchange"
+
+// Daraja creates a Telemetry for daraja requests, that records the ResponseCode of
+// accepted requests and the errorCode of rejected ones
+func Daraja(cfg Config) (Telemetry, error) {
+	return New("daraja", exchange.DarajaFields, cfg)
+}
+
+// Quikk creates a Telemetry for quikk requests, that records the code of the response
+// meta and the status of errors
+func Quikk(cfg Config) (Telemetry, error) {
+	return New("quikk", exchange.QuikkFields, cfg)
+}
+
+// Tanda creates a Telemetry for tanda requests, that records their PaymentStatus
+func Tanda(cfg Config) (Telemetry, error) {
+	return New("tanda", exchange.TandaFields, cfg)
+}
@@ -0,0 +1,194 @@
+// Package telemetry provides request hooks that trace and measure daraja, quikk and tanda
+// requests with OpenTelemetry.
+//
+// A Telemetry is installed on the Hooks of a client and starts a span named after the
+// gorequest.Operation.Name of each request, with the provider, endpoint, http status and
+// the result code the provider returned as attributes: the ResponseCode or errorCode of
+// daraja, the meta code of quikk and the PaymentStatus of tanda. The span is carried in the
+// context of the request, which daraja.Authenticate and tanda.Authenticate pass on to their
+// token requests, so that work done on their behalf is traced within it.
+//
+// The latency of each request is recorded in the MetricDuration histogram, and failed
+// requests are counted in MetricErrors by their error type: provider_error for requests
+// the provider responded to with an error, transport_error for requests that got no
+// response, timeout for requests whose deadline passed and not_sent for requests that
+// failed before they were sent, e.g. in validation or authentication.
+package telemetry
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/internal/exchange"
+	"github.com/SirWaithaka/payments/internal/redact"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter
+const ScopeName = "github.com/SirWaithaka/payments/telemetry"
+
+// Names of the instruments
+const (
+	MetricDuration = "payments.request.duration"
+	MetricErrors   = "payments.request.errors"
+)
+
+// Attribute keys of the spans and instruments
+const (
+	KeyProvider   = attribute.Key("payments.provider")
+	KeyOperation  = attribute.Key("payments.operation")
+	KeyRequestID  = attribute.Key("payments.request_id")
+	KeyResultCode = attribute.Key("payments.result_code")
+	KeyOutcome    = attribute.Key("payments.outcome")
+	KeyMethod     = attribute.Key("http.request.method")
+	KeyEndpoint   = attribute.Key("url.full")
+	KeyStatus     = attribute.Key("http.response.status_code")
+	KeyErrorType  = attribute.Key("error.type")
+)
+
+// Fields names the fields of a provider's response bodies that are recorded as the request
+// id and result code
+type Fields = exchange.Fields
+
+// Config configures a Telemetry
+type Config struct {
+	// TracerProvider creates the tracer of the spans, it defaults to otel.GetTracerProvider()
+	TracerProvider trace.TracerProvider
+	// MeterProvider creates the meter of the instruments, it defaults to
+	// otel.GetMeterProvider()
+	MeterProvider metric.MeterProvider
+}
+
+// Telemetry traces and measures the requests of a provider
+type Telemetry struct {
+	provider string
+	fields   Fields
+	redact   redact.Redactor
+
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+}
+
+// New creates a Telemetry for the requests of provider, that records the request id and
+// result code read from fields of the response
+func New(provider string, fields Fields, cfg Config) (Telemetry, error) {
+	if cfg.TracerProvider == nil {
+		cfg.TracerProvider = otel.GetTracerProvider()
+	}
+	if cfg.MeterProvider == nil {
+		cfg.MeterProvider = otel.GetMeterProvider()
+	}
+
+	meter := cfg.MeterProvider.Meter(ScopeName)
+	duration, err := meter.Float64Histogram(MetricDuration,
+		metric.WithDescription("Latency of requests to payment providers, from when they are sent until they complete"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return Telemetry{}, err
+	}
+	errs, err := meter.Int64Counter(MetricErrors,
+		metric.WithDescription("Requests to payment providers that failed, by error type"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return Telemetry{}, err
+	}
+
+	return Telemetry{
+		provider: provider,
+		fields:   fields,
+		redact:   redact.New(nil, nil, false),
+		tracer:   cfg.TracerProvider.Tracer(ScopeName),
+		duration: duration,
+		errors:   errs,
+	}, nil
+}
+
+// Install adds the Start hook to the front of the validate hooks, a hook that captures the
+// response body to the front of the unmarshal hooks and the End hook to the complete hooks
+func (t Telemetry) Install(hooks *gorequest.Hooks) {
+	hooks.Validate.PushFrontHook(t.Start())
+	hooks.Unmarshal.PushFrontHook(exchange.CaptureResponse(t.provider + ".TelemetryCaptureResponse"))
+	hooks.Complete.PushBackHook(t.End())
+}
+
+// Start is a validate hook that starts the span of the request, and sets it in the
+// context of the request
+func (t Telemetry) Start() gorequest.Hook {
+	return gorequest.Hook{
+		Name: t.provider + ".TelemetryStart",
+		Fn: func(r *gorequest.Request) {
+			attrs := []attribute.KeyValue{
+				KeyProvider.String(t.provider),
+				KeyOperation.String(r.Operation.Name),
+				KeyMethod.String(r.Operation.Method),
+			}
+			if r.Request != nil {
+				// the query is left out, it may carry personal data
+				endpoint := *r.Request.URL
+				endpoint.RawQuery, endpoint.User = "", nil
+				attrs = append(attrs, KeyEndpoint.String(endpoint.String()))
+			}
+
+			ctx, _ := t.tracer.Start(r.Context(), r.Operation.Name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...))
+			r.WithContext(ctx)
+		}}
+}
+
+// End is a complete hook that records the outcome of the request, and ends its span
+func (t Telemetry) End() gorequest.Hook {
+	return gorequest.Hook{
+		Name: t.provider + ".TelemetryEnd",
+		Fn: func(r *gorequest.Request) {
+			ctx := r.Context()
+			span := trace.SpanFromContext(ctx)
+			defer span.End()
+
+			outcome := exchange.Classify(r)
+
+			if r.Response != nil && r.Response.StatusCode != 0 {
+				span.SetAttributes(KeyStatus.Int(r.Response.StatusCode))
+
+				requestID, resultCode := t.fields.Lookup(exchange.Captured(r.Response.Body))
+				if requestID != "" {
+					span.SetAttributes(KeyRequestID.String(requestID))
+				}
+				if resultCode != "" {
+					span.SetAttributes(KeyResultCode.String(resultCode))
+				}
+			}
+
+			if !r.AttemptTime.IsZero() {
+				t.duration.Record(ctx, time.Since(r.AttemptTime).Seconds(), metric.WithAttributes(
+					KeyProvider.String(t.provider),
+					KeyOperation.String(r.Operation.Name),
+					KeyOutcome.String(string(outcome)),
+				))
+			}
+
+			if r.Error == nil {
+				span.SetStatus(codes.Ok, "")
+				return
+			}
+
+			msg := t.redact.String(r.Error.Error())
+			span.SetAttributes(KeyErrorType.String(string(outcome)))
+			span.AddEvent("exception", trace.WithAttributes(
+				attribute.String("exception.message", msg),
+			))
+			span.SetStatus(codes.Error, msg)
+			t.errors.Add(ctx, 1, metric.WithAttributes(
+				KeyProvider.String(t.provider),
+				KeyOperation.String(r.Operation.Name),
+				KeyErrorType.String(string(outcome)),
+			))
+		}}
+}
@@ -0,0 +1,297 @@
+package telemetry_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/SirWaithaka/gorequest"
+
+	"github.com/SirWaithaka/payments/daraja"
+	"github.com/SirWaithaka/payments/daraja/daratest"
+	"github.com/SirWaithaka/payments/quikk"
+	"github.com/SirWaithaka/payments/quikk/quikktest"
+	"github.com/SirWaithaka/payments/tanda"
+	"github.com/SirWaithaka/payments/tanda/tandatest"
+	"github.com/SirWaithaka/payments/telemetry"
+)
+
+// recorder collects the spans and metrics recorded by a Telemetry
+type recorder struct {
+	spans  *tracetest.InMemoryExporter
+	reader *sdkmetric.ManualReader
+	cfg    telemetry.Config
+}
+
+func newRecorder() *recorder {
+	spans := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	return &recorder{
+		spans:  spans,
+		reader: reader,
+		cfg: telemetry.Config{
+			TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)),
+			MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		},
+	}
+}
+
+// attributes returns the attributes of a span as a map
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+// metric returns the data of the named metric
+func (rec *recorder) metric(t *testing.T, name string) metricdata.Aggregation {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, rec.reader.Collect(t.Context(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m.Data
+			}
+		}
+	}
+	t.Fatalf("metric %s not recorded", name)
+	return nil
+}
+
+// callbacks starts an https server that accepts webhooks
+func callbacks(t *testing.T) string {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func b2c(url string) daraja.RequestB2C {
+	return daraja.RequestB2C{
+		OriginatorConversationID: "fake_id",
+		InitiatorName:            "fake_name",
+		SecurityCredential:       "fake_credential",
+		CommandID:                daraja.CommandBusinessPayment,
+		Amount:                   "150",
+		PartyA:                   "600000",
+		PartyB:                   "254712345678",
+		Remarks:                  "test payment",
+		QueueTimeOutURL:          url,
+		ResultURL:                url,
+	}
+}
+
+func TestTelemetry_Daraja(t *testing.T) {
+	server := daratest.NewServer()
+	defer server.Close()
+	url := callbacks(t)
+
+	rec := newRecorder()
+	tel, err := telemetry.Daraja(rec.cfg)
+	require.NoError(t, err)
+
+	client := daraja.New(daraja.Config{Endpoint: server.URL})
+	tel.Install(&client.Hooks)
+
+	// record the span in the context of the token request
+	var authSpan trace.SpanContext
+	auth := client.AuthenticationRequest(daratest.ConsumerKey, daratest.ConsumerSecret)
+	client.Hooks.Build.PushBackHook(daraja.Authenticate(func() (*gorequest.Request, *daraja.ResponseAuthorization) {
+		req, out := auth()
+		req.Hooks.Send.PushFrontHook(gorequest.Hook{Name: "test.AuthSpan", Fn: func(r *gorequest.Request) {
+			authSpan = trace.SpanContextFromContext(r.Context())
+		}})
+		return req, out
+	}))
+
+	res, err := client.B2C(t.Context(), b2c(url))
+	require.NoError(t, err)
+
+	server.Script(daraja.OperationB2C, daratest.SubscriberLocked)
+	_, err = client.B2C(t.Context(), b2c(url))
+	require.Error(t, err)
+
+	spans := rec.spans.GetSpans()
+	require.Len(t, spans, 2)
+
+	t.Run("test that the span of a successful request is recorded", func(t *testing.T) {
+		span := spans[0]
+		assert.Equal(t, daraja.OperationB2C, span.Name)
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+		assert.Equal(t, codes.Ok, span.Status.Code)
+
+		attrs := attributes(span)
+		assert.Equal(t, "daraja", attrs[telemetry.KeyProvider].AsString())
+		assert.Equal(t, server.URL+daraja.EndpointB2cPayment, attrs[telemetry.KeyEndpoint].AsString())
+		assert.Equal(t, int64(http.StatusOK), attrs[telemetry.KeyStatus].AsInt64())
+		assert.Equal(t, "0", attrs[telemetry.KeyResultCode].AsString())
+		assert.Equal(t, res.ConversationID, attrs[telemetry.KeyRequestID].AsString())
+	})
+
+	t.Run("test that the context of the span is passed to the token request", func(t *testing.T) {
+		assert.True(t, authSpan.IsValid())
+		assert.Equal(t, spans[0].SpanContext.SpanID(), authSpan.SpanID())
+	})
+
+	t.Run("test that the span of a failed request is recorded", func(t *testing.T) {
+		span := spans[1]
+		assert.Equal(t, codes.Error, span.Status.Code)
+		assert.NotContains(t, span.Status.Description, "254712345678")
+
+		attrs := attributes(span)
+		assert.Equal(t, daraja.SubscriberLock.String(), attrs[telemetry.KeyResultCode].AsString())
+		assert.Equal(t, "provider_error", attrs[telemetry.KeyErrorType].AsString())
+		require.Len(t, span.Events, 1)
+		assert.Equal(t, "exception", span.Events[0].Name)
+	})
+
+	t.Run("test that latency and errors are measured", func(t *testing.T) {
+		duration := rec.metric(t, telemetry.MetricDuration).(metricdata.Histogram[float64])
+		require.Len(t, duration.DataPoints, 2)
+		for _, dp := range duration.DataPoints {
+			assert.Equal(t, uint64(1), dp.Count)
+			outcome, _ := dp.Attributes.Value(telemetry.KeyOutcome)
+			assert.Contains(t, []string{"success", "provider_error"}, outcome.AsString())
+		}
+
+		errs := rec.metric(t, telemetry.MetricErrors).(metricdata.Sum[int64])
+		require.Len(t, errs.DataPoints, 1)
+		assert.Equal(t, int64(1), errs.DataPoints[0].Value)
+		errorType, _ := errs.DataPoints[0].Attributes.Value(telemetry.KeyErrorType)
+		assert.Equal(t, "provider_error", errorType.AsString())
+	})
+}
+
+func TestTelemetry_ErrorTypes(t *testing.T) {
+	// a server that responds after the deadline of the requests
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	tcs := map[string]struct {
+		client   func() daraja.Client
+		ctx      func(t *testing.T) context.Context
+		expected string
+	}{
+		"transport error": {
+			client:   func() daraja.Client { return daraja.New(daraja.Config{Endpoint: "http://127.0.0.1:0"}) },
+			ctx:      func(t *testing.T) context.Context { return t.Context() },
+			expected: "transport_error",
+		},
+		"timeout": {
+			client: func() daraja.Client { return daraja.New(daraja.Config{Endpoint: slow.URL}) },
+			ctx: func(t *testing.T) context.Context {
+				ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+				t.Cleanup(cancel)
+				return ctx
+			},
+			expected: "timeout",
+		},
+		"not sent": {
+			client: func() daraja.Client {
+				client := daraja.New(daraja.Config{Endpoint: slow.URL})
+				client.Hooks.Validate.PushBackHook(gorequest.Hook{Name: "test.Reject", Fn: func(r *gorequest.Request) {
+					r.Error = assert.AnError
+				}})
+				return client
+			},
+			ctx:      func(t *testing.T) context.Context { return t.Context() },
+			expected: "not_sent",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			rec := newRecorder()
+			tel, err := telemetry.Daraja(rec.cfg)
+			require.NoError(t, err)
+
+			client := tc.client()
+			tel.Install(&client.Hooks)
+
+			_, err = client.B2C(tc.ctx(t), b2c("https://example.com"))
+			require.Error(t, err)
+
+			spans := rec.spans.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.expected, attributes(spans[0])[telemetry.KeyErrorType].AsString())
+			assert.NotContains(t, attributes(spans[0]), telemetry.KeyStatus)
+
+			errs := rec.metric(t, telemetry.MetricErrors).(metricdata.Sum[int64])
+			require.Len(t, errs.DataPoints, 1)
+			errorType, _ := errs.DataPoints[0].Attributes.Value(telemetry.KeyErrorType)
+			assert.Equal(t, tc.expected, errorType.AsString())
+		})
+	}
+}
+
+func TestTelemetry_Quikk(t *testing.T) {
+	server := quikktest.NewServer(callbacks(t))
+	defer server.Close()
+
+	rec := newRecorder()
+	tel, err := telemetry.Quikk(rec.cfg)
+	require.NoError(t, err)
+
+	client := server.Client()
+	tel.Install(&client.Hooks)
+
+	server.Script(quikk.OperationPayout, quikktest.RateLimited)
+	payout := quikk.RequestPayout{Amount: 100, RecipientNo: "254712345678", RecipientType: "msisdn", ShortCode: "174379"}
+	_, err = client.Payout(t.Context(), payout, "payout-1")
+	require.Error(t, err)
+
+	spans := rec.spans.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, quikk.OperationPayout, spans[0].Name)
+	attrs := attributes(spans[0])
+	assert.Equal(t, "quikk", attrs[telemetry.KeyProvider].AsString())
+	assert.Equal(t, "429", attrs[telemetry.KeyResultCode].AsString())
+}
+
+func TestTelemetry_Tanda(t *testing.T) {
+	server := tandatest.NewServer()
+	defer server.Close()
+
+	rec := newRecorder()
+	tel, err := telemetry.Tanda(rec.cfg)
+	require.NoError(t, err)
+
+	client := server.Client()
+	tel.Install(&client.Hooks)
+
+	payment := tanda.RequestPayment{CommandID: tanda.CommandMerchantToCustomerMobileMoneyPayment, Reference: "REF00000001"}
+	payment.AddParameter(tanda.ParameterIDAmount, "100")
+	payment.AddParameter(tanda.ParameterIDShortCode, "174379")
+	payment.AddParameter(tanda.ParameterIDAccountNumber, "254712345678")
+	payment.AddParameter(tanda.ParameterIDNarration, "Payment")
+	payment.AddParameter(tanda.ParameterIDIpnUrl, callbacks(t))
+
+	res, err := client.Payment(t.Context(), tandatest.OrganizationID, payment)
+	require.NoError(t, err)
+
+	spans := rec.spans.GetSpans()
+	require.Len(t, spans, 1)
+	attrs := attributes(spans[0])
+	assert.Equal(t, string(tanda.PaymentStatusP202000), attrs[telemetry.KeyResultCode].AsString())
+	assert.Equal(t, res.TrackingID, attrs[telemetry.KeyRequestID].AsString())
+}